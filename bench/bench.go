@@ -0,0 +1,439 @@
+// Package bench drives a configurable mix of item API requests at a target
+// rate and reports latency percentiles, throughput, errors and the cache hit
+// ratio.
+//
+// Load is open loop: requests are scheduled at fixed intervals whether or
+// not earlier ones have finished, and latency is measured from the scheduled
+// time. A slow server therefore shows up as higher latency instead of a
+// quietly lower request rate. When every worker is busy, scheduled requests
+// are dropped and counted.
+package bench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Operations a Mix can weight.
+const (
+	OpList   = "list"
+	OpGet    = "get"
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+var operations = []string{OpList, OpGet, OpCreate, OpUpdate, OpDelete}
+
+// Mix holds the relative weight of each operation.
+type Mix map[string]int
+
+// DefaultMix is read heavy, like the traffic the cache is meant for.
+var DefaultMix = Mix{OpGet: 70, OpList: 10, OpCreate: 10, OpUpdate: 10}
+
+// ParseMix parses "get=70,list=10,update=20". Operations that are not
+// listed get no traffic.
+func ParseMix(s string) (Mix, error) {
+	mix := Mix{}
+	for _, part := range strings.Split(s, ",") {
+		op, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("bench: invalid mix entry %q", part)
+		}
+		if !isOperation(op) {
+			return nil, fmt.Errorf("bench: unknown operation %q", op)
+		}
+		n, err := strconv.Atoi(weight)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("bench: invalid weight in %q", part)
+		}
+		mix[op] = n
+	}
+	if mix.total() == 0 {
+		return nil, errors.New("bench: mix has no traffic")
+	}
+	return mix, nil
+}
+
+func isOperation(op string) bool {
+	for _, known := range operations {
+		if op == known {
+			return true
+		}
+	}
+	return false
+}
+
+func (m Mix) total() int {
+	total := 0
+	for _, weight := range m {
+		total += weight
+	}
+	return total
+}
+
+func (m Mix) String() string {
+	parts := make([]string, 0, len(m))
+	for _, op := range operations {
+		if m[op] > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", op, m[op]))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+type Options struct {
+	// Items are created before the measured run and serve as targets for
+	// reads, updates and deletes. When it is zero, the items the server
+	// already lists are used instead, such as those inserted by the seed
+	// command.
+	Items    int
+	Duration time.Duration
+	// RPS is the target request rate.
+	RPS int
+	// Concurrency caps the requests in flight.
+	Concurrency int
+	Mix         Mix
+	Timeout     time.Duration
+	// Seed makes the sequence of operations reproducible.
+	Seed uint64
+}
+
+func (o Options) withDefaults() Options {
+	if o.Concurrency <= 0 {
+		o.Concurrency = 32
+	}
+	if o.Mix == nil {
+		o.Mix = DefaultMix
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	return o
+}
+
+// Target is the API under test.
+type Target struct {
+	// BaseURL is the server root, e.g. http://localhost:9000.
+	BaseURL string
+	// Header is sent with every request, typically Authorization.
+	Header http.Header
+	// Cache reports the server's cache counters. It may be nil.
+	Cache CacheCounter
+}
+
+// Run seeds opts.Items items, drives traffic for opts.Duration and returns
+// the report for the measured phase.
+func Run(ctx context.Context, target Target, opts Options) (*Report, error) {
+	opts = opts.withDefaults()
+	if opts.RPS <= 0 || opts.Duration <= 0 {
+		return nil, errors.New("bench: RPS and duration must be positive")
+	}
+	if opts.Mix.total() == 0 {
+		return nil, errors.New("bench: mix has no traffic")
+	}
+
+	r := &runner{
+		target: target,
+		opts:   opts,
+		client: &http.Client{
+			Timeout:   opts.Timeout,
+			Transport: &http.Transport{MaxIdleConnsPerHost: opts.Concurrency},
+		},
+		rng:     rand.New(rand.NewPCG(opts.Seed, opts.Seed)),
+		results: map[string]*opResults{},
+	}
+	defer r.client.CloseIdleConnections()
+
+	if err := r.seed(ctx); err != nil {
+		return nil, err
+	}
+
+	var before cacheCounts
+	if target.Cache != nil {
+		var err error
+		if before, err = readCache(ctx, target.Cache); err != nil {
+			return nil, err
+		}
+	}
+
+	started := time.Now()
+	dropped := r.drive(ctx, started)
+	elapsed := time.Since(started)
+
+	report := r.report(started, elapsed, dropped)
+	if target.Cache != nil {
+		after, err := readCache(ctx, target.Cache)
+		if err != nil {
+			return nil, err
+		}
+		report.Cache = newCacheReport(before, after)
+	}
+	return report, nil
+}
+
+type runner struct {
+	target Target
+	opts   Options
+	client *http.Client
+
+	mu      sync.Mutex
+	rng     *rand.Rand
+	ids     []uuid.UUID
+	created int
+	results map[string]*opResults
+}
+
+type job struct {
+	op        string
+	scheduled time.Time
+}
+
+// seed creates the initial items with all workers, as fast as possible, or
+// loads the existing ones when no items are to be created.
+func (r *runner) seed(ctx context.Context) error {
+	if r.opts.Items == 0 {
+		var existing []struct {
+			ID uuid.UUID `json:"ID"`
+		}
+		if _, err := r.do(ctx, http.MethodGet, "/items/", nil, &existing); err != nil {
+			return fmt.Errorf("bench: listing items: %w", err)
+		}
+		for _, item := range existing {
+			r.ids = append(r.ids, item.ID)
+		}
+		return nil
+	}
+
+	jobs := make(chan int)
+	errs := make(chan error, r.opts.Concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < r.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				if _, err := r.create(ctx); err != nil {
+					select {
+					case errs <- err:
+					default:
+					}
+				}
+			}
+		}()
+	}
+	for i := 0; i < r.opts.Items; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("bench: seeding items: %w", err)
+	default:
+		return nil
+	}
+}
+
+// drive schedules requests every 1/RPS until the duration has passed and
+// returns the number of requests dropped because every worker was busy.
+func (r *runner) drive(ctx context.Context, started time.Time) (dropped int) {
+	jobs := make(chan job, r.opts.Concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < r.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				r.execute(ctx, j)
+			}
+		}()
+	}
+
+	interval := time.Second / time.Duration(r.opts.RPS)
+	deadline := started.Add(r.opts.Duration)
+	next := started
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+schedule:
+	for next.Before(deadline) {
+		for now := time.Now(); !next.After(now) && next.Before(deadline); next = next.Add(interval) {
+			select {
+			case jobs <- job{op: r.pickOperation(), scheduled: next}:
+			default:
+				dropped++
+			}
+		}
+		timer.Reset(time.Until(next))
+		select {
+		case <-ctx.Done():
+			break schedule
+		case <-timer.C:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return dropped
+}
+
+func (r *runner) pickOperation() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := r.rng.IntN(r.opts.Mix.total())
+	for _, op := range operations {
+		if n < r.opts.Mix[op] {
+			return op
+		}
+		n -= r.opts.Mix[op]
+	}
+	return OpGet
+}
+
+// pickID returns a random known item, removing it from the pool when it is
+// about to be deleted.
+func (r *runner) pickID(remove bool) (uuid.UUID, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.ids) == 0 {
+		return uuid.Nil, false
+	}
+	i := r.rng.IntN(len(r.ids))
+	id := r.ids[i]
+	if remove {
+		r.ids[i] = r.ids[len(r.ids)-1]
+		r.ids = r.ids[:len(r.ids)-1]
+	}
+	return id, true
+}
+
+func (r *runner) itemBody() map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.created++
+	return map[string]interface{}{
+		"name":  fmt.Sprintf("bench item %d", r.created),
+		"price": float64(r.rng.IntN(100000)+1) / 100,
+	}
+}
+
+func (r *runner) execute(ctx context.Context, j job) {
+	op := j.op
+	var status int
+	var err error
+	switch op {
+	case OpList:
+		status, err = r.do(ctx, http.MethodGet, "/items/", nil, nil)
+	case OpGet, OpUpdate, OpDelete:
+		id, ok := r.pickID(op == OpDelete)
+		if !ok {
+			// Everything has been deleted; create something to work on.
+			op = OpCreate
+			status, err = r.create(ctx)
+			break
+		}
+		path := "/items/" + id.String()
+		switch op {
+		case OpGet:
+			status, err = r.do(ctx, http.MethodGet, path, nil, nil)
+		case OpUpdate:
+			status, err = r.do(ctx, http.MethodPut, path, r.itemBody(), nil)
+		default:
+			status, err = r.do(ctx, http.MethodDelete, path, nil, nil)
+		}
+	case OpCreate:
+		status, err = r.create(ctx)
+	}
+	r.record(op, time.Since(j.scheduled), status, err)
+}
+
+// create adds an item and makes it available to later operations.
+func (r *runner) create(ctx context.Context) (int, error) {
+	var created struct {
+		ID uuid.UUID `json:"ID"`
+	}
+	status, err := r.do(ctx, http.MethodPost, "/items/", r.itemBody(), &created)
+	if err == nil && status == http.StatusCreated {
+		r.mu.Lock()
+		r.ids = append(r.ids, created.ID)
+		r.mu.Unlock()
+	}
+	return status, err
+}
+
+// do sends one request. Non-2xx responses are returned as errors.
+func (r *runner) do(ctx context.Context, method, path string, body, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(r.target.BaseURL, "/")+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	for key, values := range r.target.Header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if out != nil {
+		return resp.StatusCode, json.NewDecoder(resp.Body).Decode(out)
+	}
+	_, err = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, err
+}
+
+type opResults struct {
+	latencies []time.Duration
+	errors    int
+	statuses  map[int]int
+}
+
+func (r *runner) record(op string, latency time.Duration, status int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results, ok := r.results[op]
+	if !ok {
+		results = &opResults{statuses: map[int]int{}}
+		r.results[op] = results
+	}
+	results.latencies = append(results.latencies, latency)
+	if err != nil {
+		results.errors++
+	}
+	if status != 0 {
+		results.statuses[status]++
+	}
+}
+
+func sortDurations(d []time.Duration) {
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+}
@@ -0,0 +1,124 @@
+package bench_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rahulmishra/go-crud-app/bench"
+	"github.com/rahulmishra/go-crud-app/metrics"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/seed"
+	"github.com/rahulmishra/go-crud-app/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMix(t *testing.T) {
+	mix, err := bench.ParseMix("get=70, list=10,delete=0")
+	require.NoError(t, err)
+	assert.Equal(t, bench.Mix{bench.OpGet: 70, bench.OpList: 10, bench.OpDelete: 0}, mix)
+	assert.Equal(t, "list=10,get=70", mix.String())
+
+	for _, invalid := range []string{"", "get", "get=x", "get=-1", "fetch=1", "get=0"} {
+		_, err := bench.ParseMix(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestRun(t *testing.T) {
+	s := testutil.NewServer(t)
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+s.Token(testutil.Admin("root")))
+
+	report, err := bench.Run(context.Background(), bench.Target{
+		BaseURL: s.URL,
+		Header:  header,
+		Cache:   bench.RegistryCounter(metrics.Registry),
+	}, bench.Options{
+		Items:       20,
+		Duration:    500 * time.Millisecond,
+		RPS:         100,
+		Concurrency: 4,
+		Mix:         bench.Mix{bench.OpGet: 6, bench.OpList: 1, bench.OpCreate: 1, bench.OpUpdate: 1, bench.OpDelete: 1},
+		Seed:        7,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, report.Errors, "operations: %+v", report.Operations)
+	assert.Equal(t, report.Requests+report.Dropped, 50, "every scheduled request is sent or dropped")
+	assert.InDelta(t, 100, report.ThroughputRPS, 30)
+	assert.Greater(t, report.Operations[bench.OpGet].Requests, 0)
+	assert.Equal(t, report.Operations[bench.OpGet].Requests, report.Operations[bench.OpGet].Statuses["200"])
+	assert.LessOrEqual(t, report.Latency.P50, report.Latency.P99)
+	assert.LessOrEqual(t, report.Latency.P99, report.Latency.Max)
+
+	require.NotNil(t, report.Cache)
+	assert.Greater(t, report.Cache.Hits+report.Cache.Misses, int64(0))
+	assert.GreaterOrEqual(t, report.Cache.HitRatio, 0.0)
+	assert.LessOrEqual(t, report.Cache.HitRatio, 1.0)
+
+	// Seeded items plus successful creates, minus successful deletes.
+	var stored int64
+	require.NoError(t, s.DB.Model(&models.Item{}).Count(&stored).Error)
+	created := report.Operations[bench.OpCreate]
+	deleted := report.Operations[bench.OpDelete]
+	want := 20
+	if created != nil {
+		want += created.Statuses["201"]
+	}
+	if deleted != nil {
+		want -= deleted.Statuses["200"]
+	}
+	assert.Equal(t, int64(want), stored)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteJSON(&buf))
+	var decoded bench.Report
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, report.Requests, decoded.Requests)
+	assert.Equal(t, report.Latency, decoded.Latency)
+
+	buf.Reset()
+	require.NoError(t, report.WriteText(&buf))
+	assert.Contains(t, buf.String(), "hit ratio")
+}
+
+func TestRun_CountsErrors(t *testing.T) {
+	s := testutil.NewServer(t)
+
+	// Without credentials the seeding requests are rejected.
+	_, err := bench.Run(context.Background(), bench.Target{BaseURL: s.URL}, bench.Options{
+		Items: 1, Duration: 100 * time.Millisecond, RPS: 10,
+	})
+	assert.ErrorContains(t, err, "401")
+
+	// Readers can list the (empty) catalog but not create items.
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+s.Token(testutil.Reader("carol")))
+	report, err := bench.Run(context.Background(), bench.Target{BaseURL: s.URL, Header: header}, bench.Options{
+		Duration: 200 * time.Millisecond, RPS: 50, Mix: bench.Mix{bench.OpCreate: 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, report.Requests, report.Errors)
+	assert.Equal(t, 1.0, report.ErrorRate)
+	assert.Equal(t, report.Requests, report.Operations[bench.OpCreate].Statuses["403"])
+	assert.Nil(t, report.Cache)
+}
+
+func TestRun_UsesExistingItems(t *testing.T) {
+	s := testutil.NewServer(t)
+	s.Seed(seed.Options{Count: 50, Seed: 3})
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+s.Token(testutil.Admin("root")))
+
+	report, err := bench.Run(context.Background(), bench.Target{BaseURL: s.URL, Header: header}, bench.Options{
+		Duration: 200 * time.Millisecond, RPS: 50, Mix: bench.Mix{bench.OpGet: 1},
+	})
+	require.NoError(t, err)
+	assert.Zero(t, report.Errors)
+	assert.Equal(t, report.Requests, report.Operations[bench.OpGet].Statuses["200"], "only seeded items are read")
+}
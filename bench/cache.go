@@ -0,0 +1,99 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// cacheMetric is the counter the services increment on every cache lookup.
+const cacheMetric = "go_crud_app_cache_requests_total"
+
+// CacheCounter returns the server's cumulative cache metric families. Run
+// reads it before and after the measured phase and reports the difference.
+type CacheCounter func(ctx context.Context) (map[string]*dto.MetricFamily, error)
+
+// RegistryCounter reads the counters of an in-process server.
+func RegistryCounter(g prometheus.Gatherer) CacheCounter {
+	return func(context.Context) (map[string]*dto.MetricFamily, error) {
+		families, err := g.Gather()
+		if err != nil {
+			return nil, err
+		}
+		byName := make(map[string]*dto.MetricFamily, len(families))
+		for _, family := range families {
+			byName[family.GetName()] = family
+		}
+		return byName, nil
+	}
+}
+
+// ScrapeCounter reads the counters from a /metrics endpoint. header is sent
+// with the request and may be nil.
+func ScrapeCounter(metricsURL string, header http.Header) CacheCounter {
+	return func(ctx context.Context) (map[string]*dto.MetricFamily, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, metricsURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("bench: scraping %s: %s", metricsURL, resp.Status)
+		}
+		var parser expfmt.TextParser
+		return parser.TextToMetricFamilies(resp.Body)
+	}
+}
+
+type cacheCounts struct {
+	hits, misses float64
+}
+
+func readCache(ctx context.Context, counter CacheCounter) (cacheCounts, error) {
+	families, err := counter(ctx)
+	if err != nil {
+		return cacheCounts{}, fmt.Errorf("bench: reading cache metrics: %w", err)
+	}
+	var counts cacheCounts
+	family, ok := families[cacheMetric]
+	if !ok {
+		// Nothing has been looked up yet.
+		return counts, nil
+	}
+	for _, metric := range family.GetMetric() {
+		for _, label := range metric.GetLabel() {
+			if label.GetName() != "result" {
+				continue
+			}
+			switch label.GetValue() {
+			case "hit":
+				counts.hits += metric.GetCounter().GetValue()
+			case "miss":
+				counts.misses += metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return counts, nil
+}
+
+func newCacheReport(before, after cacheCounts) *CacheReport {
+	report := &CacheReport{
+		Hits:   int64(after.hits - before.hits),
+		Misses: int64(after.misses - before.misses),
+	}
+	if total := report.Hits + report.Misses; total > 0 {
+		report.HitRatio = float64(report.Hits) / float64(total)
+	}
+	return report
+}
@@ -0,0 +1,168 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+	"time"
+)
+
+// Report is the result of a run. It is written as JSON so runs can be
+// compared, e.g. by a CI job diffing against a baseline.
+type Report struct {
+	Target        string               `json:"target"`
+	StartedAt     time.Time            `json:"started_at"`
+	Duration      float64              `json:"duration_seconds"`
+	TargetRPS     int                  `json:"target_rps"`
+	Concurrency   int                  `json:"concurrency"`
+	Items         int                  `json:"items"`
+	Mix           string               `json:"mix"`
+	Requests      int                  `json:"requests"`
+	Errors        int                  `json:"errors"`
+	Dropped       int                  `json:"dropped"`
+	ThroughputRPS float64              `json:"throughput_rps"`
+	ErrorRate     float64              `json:"error_rate"`
+	Latency       LatencyStats         `json:"latency_ms"`
+	Operations    map[string]*OpReport `json:"operations"`
+	Cache         *CacheReport         `json:"cache,omitempty"`
+}
+
+// LatencyStats are in milliseconds.
+type LatencyStats struct {
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+	Mean float64 `json:"mean"`
+}
+
+type OpReport struct {
+	Requests int            `json:"requests"`
+	Errors   int            `json:"errors"`
+	Statuses map[string]int `json:"statuses"`
+	Latency  LatencyStats   `json:"latency_ms"`
+}
+
+// CacheReport counts the cache lookups made by the server during the
+// measured phase, including those caused by other clients.
+type CacheReport struct {
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+func (r *runner) report(started time.Time, elapsed time.Duration, dropped int) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := &Report{
+		Target:      r.target.BaseURL,
+		StartedAt:   started.UTC(),
+		Duration:    elapsed.Seconds(),
+		TargetRPS:   r.opts.RPS,
+		Concurrency: r.opts.Concurrency,
+		Items:       r.opts.Items,
+		Mix:         r.opts.Mix.String(),
+		Dropped:     dropped,
+		Operations:  map[string]*OpReport{},
+	}
+
+	var all []time.Duration
+	for op, results := range r.results {
+		statuses := make(map[string]int, len(results.statuses))
+		for status, n := range results.statuses {
+			statuses[fmt.Sprint(status)] = n
+		}
+		report.Operations[op] = &OpReport{
+			Requests: len(results.latencies),
+			Errors:   results.errors,
+			Statuses: statuses,
+			Latency:  latencyStats(results.latencies),
+		}
+		report.Requests += len(results.latencies)
+		report.Errors += results.errors
+		all = append(all, results.latencies...)
+	}
+	report.Latency = latencyStats(all)
+	if elapsed > 0 {
+		report.ThroughputRPS = float64(report.Requests) / elapsed.Seconds()
+	}
+	if report.Requests > 0 {
+		report.ErrorRate = float64(report.Errors) / float64(report.Requests)
+	}
+	return report
+}
+
+func latencyStats(latencies []time.Duration) LatencyStats {
+	if len(latencies) == 0 {
+		return LatencyStats{}
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sortDurations(sorted)
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return LatencyStats{
+		P50:  millis(percentile(sorted, 50)),
+		P90:  millis(percentile(sorted, 90)),
+		P95:  millis(percentile(sorted, 95)),
+		P99:  millis(percentile(sorted, 99)),
+		Max:  millis(sorted[len(sorted)-1]),
+		Mean: millis(total / time.Duration(len(sorted))),
+	}
+}
+
+// percentile uses the nearest-rank method on sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func millis(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteText writes a human-readable summary.
+func (r *Report) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "Target:     %s\n", r.Target)
+	fmt.Fprintf(w, "Mix:        %s\n", r.Mix)
+	fmt.Fprintf(w, "Requests:   %d in %.1fs (%.1f/s, target %d/s), %d dropped\n",
+		r.Requests, r.Duration, r.ThroughputRPS, r.TargetRPS, r.Dropped)
+	fmt.Fprintf(w, "Errors:     %d (%.2f%%)\n", r.Errors, 100*r.ErrorRate)
+	if r.Cache != nil {
+		fmt.Fprintf(w, "Cache:      %d hits, %d misses (%.1f%% hit ratio)\n",
+			r.Cache.Hits, r.Cache.Misses, 100*r.Cache.HitRatio)
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "OP\tREQUESTS\tERRORS\tP50\tP90\tP95\tP99\tMAX\t")
+	row := func(name string, requests, errors int, l LatencyStats) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t\n",
+			name, requests, errors, l.P50, l.P90, l.P95, l.P99, l.Max)
+	}
+	for _, op := range operations {
+		if o, ok := r.Operations[op]; ok {
+			row(op, o.Requests, o.Errors, o.Latency)
+		}
+	}
+	row("all", r.Requests, r.Errors, r.Latency)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, "Latencies in milliseconds.")
+	return err
+}
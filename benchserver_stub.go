@@ -0,0 +1,11 @@
+//go:build !benchserver
+
+package main
+
+import "errors"
+
+// startBenchServer is only built with the benchserver tag, which links the
+// SQLite driver and the in-process Redis used by tests into the binary.
+func startBenchServer() (string, func(), error) {
+	return "", nil, errors.New("the in-process bench server is not built in; pass -url, or run with go run -tags benchserver")
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec turns values into bytes and back. Implementations must be safe for
+// concurrent use.
+type Codec interface {
+	ID() byte
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+const (
+	codecJSON    byte = 1
+	codecMsgpack byte = 2
+)
+
+type JSONCodec struct{}
+
+func (JSONCodec) ID() byte     { return codecJSON }
+func (JSONCodec) Name() string { return "json" }
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// MsgpackCodec encodes values as MessagePack, honouring `json` struct tags so
+// field names match the JSON codec.
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) ID() byte     { return codecMsgpack }
+func (MsgpackCodec) Name() string { return "msgpack" }
+
+func (MsgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (MsgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+var codecs = map[byte]Codec{
+	codecJSON:    JSONCodec{},
+	codecMsgpack: MsgpackCodec{},
+}
+
+// CodecByName returns the codec registered under name.
+func CodecByName(name string) (Codec, error) {
+	for _, codec := range codecs {
+		if codec.Name() == name {
+			return codec, nil
+		}
+	}
+	return nil, fmt.Errorf("cache: unknown codec %q", name)
+}
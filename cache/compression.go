@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	compressionNone byte = 0
+	compressionGzip byte = 1
+	compressionZstd byte = 2
+)
+
+var compressionNames = map[string]byte{
+	"none": compressionNone,
+	"gzip": compressionGzip,
+	"zstd": compressionZstd,
+}
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+func compress(algorithm byte, data []byte) ([]byte, error) {
+	switch algorithm {
+	case compressionNone:
+		return data, nil
+	case compressionGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case compressionZstd:
+		return zstdEncoder.EncodeAll(data, nil), nil
+	default:
+		return nil, fmt.Errorf("cache: unknown compression %d", algorithm)
+	}
+}
+
+func decompress(algorithm byte, data []byte) ([]byte, error) {
+	switch algorithm {
+	case compressionNone:
+		return data, nil
+	case compressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case compressionZstd:
+		return zstdDecoder.DecodeAll(data, nil)
+	default:
+		return nil, fmt.Errorf("cache: unknown compression %d", algorithm)
+	}
+}
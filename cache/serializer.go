@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Every cached value starts with a fixed header:
+//
+//	byte 0    magic (0xCA)
+//	byte 1    codec ID
+//	byte 2    compression ID
+//	byte 3-4  schema version, big endian
+const (
+	headerMagic byte = 0xCA
+	headerSize       = 5
+)
+
+var (
+	ErrMalformed      = errors.New("cache: malformed value")
+	ErrSchemaMismatch = errors.New("cache: schema version mismatch")
+)
+
+type Options struct {
+	// Codec is the name of the codec used for new values ("json" or
+	// "msgpack"). Values written with any registered codec can be read.
+	Codec string
+	// Compression is "none", "gzip" or "zstd".
+	Compression string
+	// CompressionThreshold is the encoded size in bytes above which values
+	// are compressed.
+	CompressionThreshold int
+	// SchemaVersion is stamped on every value. Values carrying any other
+	// version are rejected with ErrSchemaMismatch.
+	SchemaVersion uint16
+}
+
+type Serializer struct {
+	codec         Codec
+	compression   byte
+	threshold     int
+	schemaVersion uint16
+}
+
+func NewSerializer(opts Options) (*Serializer, error) {
+	if opts.Codec == "" {
+		opts.Codec = "json"
+	}
+	if opts.Compression == "" {
+		opts.Compression = "none"
+	}
+	codec, err := CodecByName(opts.Codec)
+	if err != nil {
+		return nil, err
+	}
+	compression, ok := compressionNames[opts.Compression]
+	if !ok {
+		return nil, fmt.Errorf("cache: unknown compression %q", opts.Compression)
+	}
+	return &Serializer{
+		codec:         codec,
+		compression:   compression,
+		threshold:     opts.CompressionThreshold,
+		schemaVersion: opts.SchemaVersion,
+	}, nil
+}
+
+func (s *Serializer) Marshal(v interface{}) ([]byte, error) {
+	payload, err := s.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	compression := compressionNone
+	if s.compression != compressionNone && len(payload) > s.threshold {
+		payload, err = compress(s.compression, payload)
+		if err != nil {
+			return nil, err
+		}
+		compression = s.compression
+	}
+
+	out := make([]byte, headerSize, headerSize+len(payload))
+	out[0] = headerMagic
+	out[1] = s.codec.ID()
+	out[2] = compression
+	binary.BigEndian.PutUint16(out[3:5], s.schemaVersion)
+	return append(out, payload...), nil
+}
+
+func (s *Serializer) Unmarshal(data []byte, v interface{}) error {
+	if len(data) < headerSize || data[0] != headerMagic {
+		return ErrMalformed
+	}
+	if version := binary.BigEndian.Uint16(data[3:5]); version != s.schemaVersion {
+		return fmt.Errorf("%w: got %d, want %d", ErrSchemaMismatch, version, s.schemaVersion)
+	}
+	codec, ok := codecs[data[1]]
+	if !ok {
+		return fmt.Errorf("%w: unknown codec %d", ErrMalformed, data[1])
+	}
+	payload, err := decompress(data[2], data[headerSize:])
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if err := codec.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return nil
+}
+
+// Stats counts values that were read from the cache but could not be used.
+type Stats struct {
+	DecodeErrors     int64
+	SchemaMismatches int64
+}
+
+var (
+	defaultSerializer, _ = NewSerializer(Options{})
+	decodeErrors         atomic.Int64
+	schemaMismatches     atomic.Int64
+)
+
+// Configure replaces the serializer used by Marshal and Unmarshal.
+func Configure(opts Options) error {
+	s, err := NewSerializer(opts)
+	if err != nil {
+		return err
+	}
+	defaultSerializer = s
+	return nil
+}
+
+func Marshal(v interface{}) ([]byte, error) {
+	return defaultSerializer.Marshal(v)
+}
+
+// Unmarshal decodes data with the configured serializer and records the
+// failure in the package stats when the value cannot be used.
+func Unmarshal(data []byte, v interface{}) error {
+	err := defaultSerializer.Unmarshal(data, v)
+	switch {
+	case errors.Is(err, ErrSchemaMismatch):
+		schemaMismatches.Add(1)
+	case err != nil:
+		decodeErrors.Add(1)
+	}
+	return err
+}
+
+func GetStats() Stats {
+	return Stats{
+		DecodeErrors:     decodeErrors.Load(),
+		SchemaMismatches: schemaMismatches.Load(),
+	}
+}
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type sample struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Price float64   `json:"price"`
+}
+
+func TestSerializer_RoundTrip(t *testing.T) {
+	in := []sample{{ID: uuid.New(), Name: strings.Repeat("widget ", 200), Price: 9.99}}
+
+	for _, codec := range []string{"json", "msgpack"} {
+		for _, compression := range []string{"none", "gzip", "zstd"} {
+			s, err := NewSerializer(Options{Codec: codec, Compression: compression, CompressionThreshold: 64, SchemaVersion: 3})
+			assert.NoError(t, err)
+
+			data, err := s.Marshal(in)
+			assert.NoError(t, err)
+
+			var out []sample
+			assert.NoError(t, s.Unmarshal(data, &out), "%s/%s", codec, compression)
+			assert.Equal(t, in, out, "%s/%s", codec, compression)
+		}
+	}
+}
+
+func TestSerializer_ReadsOtherCodecs(t *testing.T) {
+	writer, _ := NewSerializer(Options{Codec: "msgpack", SchemaVersion: 1})
+	reader, _ := NewSerializer(Options{Codec: "json", SchemaVersion: 1})
+
+	data, err := writer.Marshal(sample{Name: "a", Price: 1})
+	assert.NoError(t, err)
+
+	var out sample
+	assert.NoError(t, reader.Unmarshal(data, &out))
+	assert.Equal(t, "a", out.Name)
+}
+
+func TestSerializer_Rejections(t *testing.T) {
+	oldVersion, _ := NewSerializer(Options{SchemaVersion: 1})
+	current, _ := NewSerializer(Options{SchemaVersion: 2})
+
+	data, _ := oldVersion.Marshal(sample{Name: "a"})
+	var out sample
+	assert.True(t, errors.Is(current.Unmarshal(data, &out), ErrSchemaMismatch))
+
+	assert.True(t, errors.Is(current.Unmarshal([]byte(`{"name":"a"}`), &out), ErrMalformed))
+	assert.True(t, errors.Is(current.Unmarshal([]byte{headerMagic, 99, 0, 0, 2}, &out), ErrMalformed))
+}
+
+func TestNewSerializer_UnknownOptions(t *testing.T) {
+	_, err := NewSerializer(Options{Codec: "xml"})
+	assert.Error(t, err)
+	_, err = NewSerializer(Options{Compression: "lz4"})
+	assert.Error(t, err)
+}
+
+func allSerializers(t testing.TB) map[string]*Serializer {
+	serializers := map[string]*Serializer{}
+	for _, codec := range []string{"json", "msgpack"} {
+		for _, compression := range []string{"none", "gzip", "zstd"} {
+			s, err := NewSerializer(Options{Codec: codec, Compression: compression, CompressionThreshold: 16, SchemaVersion: 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+			serializers[codec+"/"+compression] = s
+		}
+	}
+	return serializers
+}
+
+func FuzzSerializer_RoundTrip(f *testing.F) {
+	f.Add("widget", 9.99)
+	f.Add("", 0.0)
+	f.Add("ünïcödé 😀 名前", -1e308)
+	f.Add(strings.Repeat("a", 300), math.MaxFloat64)
+	f.Add("\x00\xff", math.SmallestNonzeroFloat64)
+	serializers := allSerializers(f)
+
+	f.Fuzz(func(t *testing.T, name string, price float64) {
+		in := sample{ID: uuid.New(), Name: name, Price: price}
+		for label, s := range serializers {
+			data, err := s.Marshal(in)
+			if err != nil {
+				// JSON cannot represent NaN or infinities.
+				assert.True(t, math.IsNaN(price) || math.IsInf(price, 0), "%s: %v", label, err)
+				continue
+			}
+			var out sample
+			if !assert.NoError(t, s.Unmarshal(data, &out), label) {
+				continue
+			}
+			assert.Equal(t, in.ID, out.ID, label)
+			assert.Equal(t, math.Float64bits(in.Price), math.Float64bits(out.Price), label)
+			if utf8.ValidString(name) {
+				assert.Equal(t, in.Name, out.Name, label)
+			}
+		}
+	})
+}
+
+// FuzzSerializer_Unmarshal feeds arbitrary bytes to every serializer. They
+// must be rejected with an error, never a panic.
+func FuzzSerializer_Unmarshal(f *testing.F) {
+	for _, s := range allSerializers(f) {
+		data, _ := s.Marshal(sample{Name: strings.Repeat("seed ", 10), Price: 1})
+		f.Add(data)
+	}
+	f.Add([]byte{headerMagic, codecJSON, 0, 0, 3})
+	f.Add([]byte{headerMagic, 99, 0, 0, 3, '{'})
+	f.Add([]byte(`{"name":"a"}`))
+	serializers := allSerializers(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, s := range serializers {
+			var out sample
+			_ = s.Unmarshal(data, &out)
+		}
+	})
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/bench"
+	"github.com/rahulmishra/go-crud-app/config"
+	"github.com/rahulmishra/go-crud-app/metrics"
+	"github.com/rahulmishra/go-crud-app/seed"
+	"github.com/rahulmishra/go-crud-app/services"
+)
+
+const usage = `Usage:
+  go-crud-app                                  start the HTTP server
+  go-crud-app apikey create -name N -scopes S [-expires D]
+  go-crud-app apikey list
+  go-crud-app apikey revoke ID
+  go-crud-app seed [-count N] [-seed S] [-owners A,B] [-groups G,H]
+  go-crud-app suggest rebuild [-batch N]
+  go-crud-app bench [-url U] [-token T] [-scheme S] [-items N] [-duration D] [-rps R] [-mix M] [-out FILE]
+`
+
+func runCommand(name string, args []string) {
+	var err error
+	switch name {
+	case "apikey":
+		err = runAPIKeyCommand(args)
+	case "seed":
+		err = runSeedCommand(args)
+	case "suggest":
+		err = runSuggestCommand(args)
+	case "bench":
+		err = runBenchCommand(args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		err = fmt.Errorf("unknown command %q", name)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(1)
+	}
+}
+
+func runAPIKeyCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("apikey requires a subcommand")
+	}
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("apikey create", flag.ExitOnError)
+		name := fs.String("name", "", "descriptive name of the key")
+		scopes := fs.String("scopes", "", "comma-separated scopes, e.g. items:read,items:write")
+		expires := fs.Duration("expires", 0, "lifetime of the key, e.g. 720h (default: never)")
+		fs.Parse(args[1:])
+
+		var expiresAt *time.Time
+		if *expires > 0 {
+			t := time.Now().UTC().Add(*expires)
+			expiresAt = &t
+		}
+
+		connectDatabase()
+		key, plaintext, err := services.CreateAPIKey(context.Background(), *name, strings.Split(*scopes, ","), expiresAt)
+		if err != nil {
+			return err
+		}
+		fmt.Println("ID:    ", key.ID)
+		fmt.Println("Scopes:", strings.Join(key.Scopes, ","))
+		fmt.Println("Key:   ", plaintext)
+		fmt.Println("Store the key now; it cannot be shown again.")
+		return nil
+
+	case "list":
+		connectDatabase()
+		keys, err := services.ListAPIKeys(context.Background())
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tEXPIRES\tLAST USED\tREVOKED")
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Prefix,
+				strings.Join(key.Scopes, ","), formatTime(key.ExpiresAt), formatTime(key.LastUsedAt), formatTime(key.RevokedAt))
+		}
+		return w.Flush()
+
+	case "revoke":
+		if len(args) != 2 {
+			return fmt.Errorf("apikey revoke requires an ID")
+		}
+		id, err := uuid.Parse(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid ID: %w", err)
+		}
+		connectDatabase()
+		if err := services.RevokeAPIKey(context.Background(), id); err != nil {
+			return err
+		}
+		fmt.Println("Revoked", id)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown apikey subcommand %q", args[0])
+	}
+}
+
+// runSeedCommand inserts the deterministic demo catalog. Running it again
+// with the same flags inserts nothing.
+func runSeedCommand(args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	count := fs.Int("count", 1000, "number of items to generate")
+	seedValue := fs.Uint64("seed", 1, "data set to generate; the same seed always yields the same items")
+	batch := fs.Int("batch", 500, "rows per INSERT")
+	owners := fs.String("owners", "demo", "comma-separated owner subjects assigned at random; empty leaves items unowned, so only admins can change them")
+	groups := fs.String("groups", "", "comma-separated groups to share about one item in five with")
+	fs.Parse(args)
+
+	connectDatabase()
+	config.ConnectRedis()
+	opts := seed.Options{Count: *count, Seed: *seedValue, BatchSize: *batch}
+	if *owners != "" {
+		opts.Owners = strings.Split(*owners, ",")
+	}
+	if *groups != "" {
+		opts.Groups = strings.Split(*groups, ",")
+	}
+	data, result, err := seed.Run(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Inserted %d of %d items and %d of %d shares (seed %d)\n",
+		result.Items, len(data.Items), result.Shares, len(data.Shares), *seedValue)
+	return nil
+}
+
+// runSuggestCommand manages the Redis index behind GET /items/suggest.
+func runSuggestCommand(args []string) error {
+	if len(args) == 0 || args[0] != "rebuild" {
+		return fmt.Errorf("suggest requires the rebuild subcommand")
+	}
+	fs := flag.NewFlagSet("suggest rebuild", flag.ExitOnError)
+	batch := fs.Int("batch", 500, "items read from the database at a time")
+	fs.Parse(args[1:])
+
+	connectDatabase()
+	config.ConnectRedis()
+	indexed, err := services.RebuildSuggestIndex(context.Background(), *batch)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Indexed %d items\n", indexed)
+	return nil
+}
+
+// runBenchCommand load tests the item API. Without -url it starts the API
+// in process, against a scratch database and Redis, with authentication and
+// rate limiting disabled; that mode is only built with the benchserver tag.
+func runBenchCommand(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	url := fs.String("url", "", "base URL of a running server (default: start one in process)")
+	token := fs.String("token", "", "bearer token or API key sent with every request")
+	scheme := fs.String("scheme", "Bearer", "authorization scheme for -token: Bearer for a JWT, ApiKey for an API key")
+	metricsURL := fs.String("metrics-url", "", "metrics endpoint of the running server, for the cache hit ratio")
+	items := fs.Int("items", 1000, "items to create before measuring; with -url, 0 uses the items already stored, e.g. by seed")
+	duration := fs.Duration("duration", 30*time.Second, "length of the measured run")
+	rps := fs.Int("rps", 200, "target requests per second")
+	concurrency := fs.Int("concurrency", 32, "maximum requests in flight")
+	mix := fs.String("mix", bench.DefaultMix.String(), "operation weights, e.g. get=70,list=10,create=10,update=10")
+	seed := fs.Uint64("seed", 1, "seed for the sequence of operations")
+	out := fs.String("out", "", "write the JSON report to this file")
+	fs.Parse(args)
+
+	opts := bench.Options{
+		Items:       *items,
+		Duration:    *duration,
+		RPS:         *rps,
+		Concurrency: *concurrency,
+		Seed:        *seed,
+	}
+	var err error
+	if opts.Mix, err = bench.ParseMix(*mix); err != nil {
+		return err
+	}
+
+	if *scheme != "Bearer" && *scheme != "ApiKey" {
+		return fmt.Errorf("-scheme must be Bearer or ApiKey")
+	}
+
+	target := bench.Target{BaseURL: *url, Header: http.Header{}}
+	if *token != "" {
+		target.Header.Set("Authorization", *scheme+" "+*token)
+	}
+	if *metricsURL != "" {
+		target.Cache = bench.ScrapeCounter(*metricsURL, target.Header)
+	}
+	if target.BaseURL == "" {
+		if opts.Items == 0 {
+			return fmt.Errorf("-items 0 requires -url; the in-process server starts with an empty database")
+		}
+		baseURL, stop, err := startBenchServer()
+		if err != nil {
+			return err
+		}
+		defer stop()
+		target.BaseURL = baseURL
+		target.Cache = bench.RegistryCounter(metrics.Registry)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	report, err := bench.Run(ctx, target, opts)
+	if err != nil {
+		return err
+	}
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		if err := report.WriteJSON(f); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return report.WriteText(os.Stdout)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,37 @@
+package config
+
+type AuthConfig struct {
+	// Disabled skips authentication entirely and treats every request as an
+	// admin. Only meant for local development.
+	Disabled bool
+	// APIKeysOnly accepts API keys without JWT settings. Without it,
+	// startup fails when neither JWTSecret nor JWKSFile is set, so a
+	// missing secret is not mistaken for a deliberate choice.
+	APIKeysOnly bool
+	// JWTSecret verifies HS256 tokens.
+	JWTSecret string
+	// JWKSFile is a local JSON Web Key Set used to verify RS256 tokens.
+	JWKSFile    string
+	Issuer      string
+	Audience    string
+	RolesClaim  string
+	GroupsClaim string
+	// PolicyFile is a YAML or JSON authorization policy. When empty, item
+	// access is granted by scope alone.
+	PolicyFile string
+}
+
+// LoadAuthConfig reads the authentication settings from the environment.
+func LoadAuthConfig() AuthConfig {
+	return AuthConfig{
+		Disabled:    getEnvBool("AUTH_DISABLED", false),
+		APIKeysOnly: getEnvBool("AUTH_API_KEYS_ONLY", false),
+		JWTSecret:   getEnv("JWT_SECRET", ""),
+		JWKSFile:    getEnv("JWT_JWKS_FILE", ""),
+		Issuer:      getEnv("JWT_ISSUER", ""),
+		Audience:    getEnv("JWT_AUDIENCE", ""),
+		RolesClaim:  getEnv("JWT_ROLES_CLAIM", "roles"),
+		GroupsClaim: getEnv("JWT_GROUPS_CLAIM", "groups"),
+		PolicyFile:  getEnv("POLICY_FILE", ""),
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import "time"
+
+const (
+	CacheWriteInvalidate = "invalidate"
+	CacheWriteThrough    = "write-through"
+	CacheWriteBehind     = "write-behind"
+)
+
+type CacheConfig struct {
+	Codec                string
+	Compression          string
+	CompressionThreshold int
+	// WriteMode controls how item mutations update the cache: invalidate
+	// (delete the cached entries), write-through (store the committed item)
+	// or write-behind (buffer updates in Redis and flush them in batches).
+	WriteMode            string
+	WriteBehindInterval  time.Duration
+	WriteBehindBatchSize int
+}
+
+// LoadCacheConfig reads the cache settings from the environment.
+func LoadCacheConfig() CacheConfig {
+	return CacheConfig{
+		Codec:                getEnv("CACHE_CODEC", "json"),
+		Compression:          getEnv("CACHE_COMPRESSION", "none"),
+		CompressionThreshold: getEnvInt("CACHE_COMPRESSION_THRESHOLD", 1024),
+		WriteMode:            getEnv("CACHE_WRITE_MODE", CacheWriteInvalidate),
+		WriteBehindInterval:  getEnvDuration("CACHE_WRITE_BEHIND_INTERVAL", 5*time.Second),
+		WriteBehindBatchSize: getEnvInt("CACHE_WRITE_BEHIND_BATCH_SIZE", 500),
+	}
+}
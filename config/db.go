@@ -2,21 +2,28 @@ package config
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 
 	"github.com/joho/godotenv"
+	"github.com/rahulmishra/go-crud-app/logging"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var DB *gorm.DB
 
-func ConnectDatabase() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
+// LoadEnv loads the .env file into the environment. Variables that are
+// already set take precedence. A missing file is fine, since the variables
+// may be set directly, but one that cannot be read or parsed is fatal.
+func LoadEnv() {
+	if err := godotenv.Load(); err != nil && !os.IsNotExist(err) {
+		logging.Fatal("Error loading .env file", "error", err)
 	}
+}
+
+func ConnectDatabase() {
+	LoadEnv()
 
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
 		os.Getenv("DB_HOST"),
@@ -26,13 +33,13 @@ func ConnectDatabase() {
 		os.Getenv("DB_PORT"),
 	)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logging.NewGormLogger()})
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		logging.Fatal("Failed to connect to database", "error", err)
 	}
 
 	DB = db
-	fmt.Println("Database connection established!")
+	slog.Info("Database connection established")
 }
 func SetDB(mockDB *gorm.DB) {
 	DB = mockDB
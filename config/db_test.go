@@ -0,0 +1,21 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEnv(t *testing.T) {
+	t.Chdir(t.TempDir())
+	LoadEnv() // A missing .env is not fatal.
+
+	require.NoError(t, os.WriteFile(filepath.Join(".", ".env"), []byte("LOAD_ENV_TEST=from-file\n"), 0o600))
+	t.Setenv("LOAD_ENV_TEST", "")
+	os.Unsetenv("LOAD_ENV_TEST")
+	LoadEnv()
+	assert.Equal(t, "from-file", os.Getenv("LOAD_ENV_TEST"))
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+type DiagnosticsConfig struct {
+	// Addr is the listener for the admin diagnostics endpoints, such as
+	// "127.0.0.1:6060". They are disabled when it is empty.
+	Addr string
+}
+
+// LoadDiagnosticsConfig reads the diagnostics settings from the environment.
+func LoadDiagnosticsConfig() DiagnosticsConfig {
+	return DiagnosticsConfig{
+		Addr: getEnv("DIAGNOSTICS_ADDR", ""),
+	}
+}
+
+const redacted = "[REDACTED]"
+
+// Effective returns the configuration the process would load from the
+// current environment, with passwords and secrets redacted.
+func Effective() map[string]interface{} {
+	return map[string]interface{}{
+		"database": map[string]interface{}{
+			"host":     getEnv("DB_HOST", ""),
+			"port":     getEnv("DB_PORT", ""),
+			"user":     getEnv("DB_USER", ""),
+			"name":     getEnv("DB_NAME", ""),
+			"password": redactString(getEnv("DB_PASSWORD", "")),
+		},
+		"server":      redact(LoadServerConfig()),
+		"redis":       redact(LoadRedisConfig()),
+		"cache":       redact(LoadCacheConfig()),
+		"auth":        redact(LoadAuthConfig()),
+		"rate_limit":  redact(LoadRateLimitConfig()),
+		"log":         redact(LoadLogConfig()),
+		"metrics":     redact(LoadMetricsConfig()),
+		"tracing":     redact(LoadTracingConfig()),
+		"slow_query":  redact(LoadSlowQueryConfig()),
+		"diagnostics": redact(LoadDiagnosticsConfig()),
+		"faults":      redact(LoadFaultsConfig()),
+		"search":      redact(LoadSearchConfig()),
+	}
+}
+
+// redact converts a config struct to a map keyed by field name. Fields whose
+// names mention a password, secret or token are replaced, and durations are
+// rendered as strings.
+func redact(v interface{}) map[string]interface{} {
+	value := reflect.ValueOf(v)
+	out := make(map[string]interface{}, value.NumField())
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		fieldValue := value.Field(i)
+		switch {
+		case isSecretField(field.Name):
+			out[field.Name] = redactString(fieldValue.String())
+		case field.Type == reflect.TypeOf(time.Duration(0)):
+			out[field.Name] = time.Duration(fieldValue.Int()).String()
+		case fieldValue.Kind() == reflect.Struct:
+			out[field.Name] = redact(fieldValue.Interface())
+		default:
+			out[field.Name] = fieldValue.Interface()
+		}
+	}
+	return out
+}
+
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "password") || strings.Contains(name, "secret") || strings.Contains(name, "token")
+}
+
+// redactString keeps empty values visible so an unset secret can be told
+// apart from a configured one.
+func redactString(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffective_RedactsSecrets(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "db-pass")
+	t.Setenv("REDIS_PASSWORD", "redis-pass")
+	t.Setenv("REDIS_SENTINEL_PASSWORD", "")
+	t.Setenv("JWT_SECRET", "jwt-secret")
+	t.Setenv("CACHE_WRITE_BEHIND_INTERVAL", "3s")
+
+	effective := Effective()
+	data, err := json.Marshal(effective)
+	require.NoError(t, err)
+	for _, secret := range []string{"db-pass", "redis-pass", "jwt-secret"} {
+		assert.NotContains(t, string(data), secret)
+	}
+
+	assert.Equal(t, redacted, effective["database"].(map[string]interface{})["password"])
+	redis := effective["redis"].(map[string]interface{})
+	assert.Equal(t, redacted, redis["Password"])
+	assert.Equal(t, "", redis["SentinelPassword"], "unset secrets stay empty")
+	assert.Equal(t, redacted, effective["auth"].(map[string]interface{})["JWTSecret"])
+	assert.Equal(t, "3s", effective["cache"].(map[string]interface{})["WriteBehindInterval"])
+	assert.IsType(t, map[string]interface{}{}, redis["TLS"])
+}
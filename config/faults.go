@@ -0,0 +1,15 @@
+package config
+
+type FaultsConfig struct {
+	// Enabled installs the fault injector on the database and Redis clients
+	// and serves /admin/faults. It is meant for tests and development only;
+	// nothing is injected until a rule is added.
+	Enabled bool
+}
+
+// LoadFaultsConfig reads the fault injection settings from the environment.
+func LoadFaultsConfig() FaultsConfig {
+	return FaultsConfig{
+		Enabled: getEnvBool("FAULTS_ENABLED", false),
+	}
+}
@@ -0,0 +1,17 @@
+package config
+
+type LogConfig struct {
+	// Level is debug, info, warn or error. GORM statements are logged at
+	// debug.
+	Level string
+	// Format is "json" or "text".
+	Format string
+}
+
+// LoadLogConfig reads the logging settings from the environment.
+func LoadLogConfig() LogConfig {
+	return LogConfig{
+		Level:  getEnv("LOG_LEVEL", "info"),
+		Format: getEnv("LOG_FORMAT", "json"),
+	}
+}
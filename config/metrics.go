@@ -0,0 +1,17 @@
+package config
+
+type MetricsConfig struct {
+	Enabled bool
+	// Addr serves /metrics on a separate listener, such as ":9100", so it
+	// can be kept off the public port. When empty /metrics is served by the
+	// API server and requires an admin.
+	Addr string
+}
+
+// LoadMetricsConfig reads the metrics settings from the environment.
+func LoadMetricsConfig() MetricsConfig {
+	return MetricsConfig{
+		Enabled: getEnvBool("METRICS_ENABLED", true),
+		Addr:    getEnv("METRICS_ADDR", ""),
+	}
+}
@@ -0,0 +1,24 @@
+package config
+
+type RateLimitConfig struct {
+	Enabled bool
+	// PerIP and PerAPIKey are quotas in ratelimit.ParseQuota syntax, such as
+	// "300/1m" or "10/1s:20". Callers using an API key get the API key quota;
+	// everyone else is limited by client IP. Invalid API keys count against
+	// the IP quota.
+	PerIP     string
+	PerAPIKey string
+	// Routes maps route groups to quotas applied per client within that
+	// group, as "items=300/1m,admin=30/1m".
+	Routes []string
+}
+
+// LoadRateLimitConfig reads the rate limit settings from the environment.
+func LoadRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Enabled:   getEnvBool("RATE_LIMIT_ENABLED", true),
+		PerIP:     getEnv("RATE_LIMIT_PER_IP", "300/1m"),
+		PerAPIKey: getEnv("RATE_LIMIT_PER_API_KEY", "1200/1m"),
+		Routes:    getEnvList("RATE_LIMIT_ROUTES", nil),
+	}
+}
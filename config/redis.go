@@ -2,25 +2,194 @@ package config
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"time"
 
+	"github.com/rahulmishra/go-crud-app/logging"
 	"github.com/redis/go-redis/v9"
 )
 
 var RedisClient redis.Cmdable
 
+const (
+	RedisModeStandalone = "standalone"
+	RedisModeSentinel   = "sentinel"
+	RedisModeCluster    = "cluster"
+)
+
+type RedisTLSConfig struct {
+	Enabled            bool
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	ServerName         string
+	InsecureSkipVerify bool
+}
+
+type RedisConfig struct {
+	Mode             string
+	Addrs            []string
+	MasterName       string
+	Username         string
+	Password         string
+	SentinelUsername string
+	SentinelPassword string
+	DB               int
+	TLS              RedisTLSConfig
+	PoolSize         int
+	MinIdleConns     int
+	DialTimeout      time.Duration
+	ReadTimeout      time.Duration
+	WriteTimeout     time.Duration
+	PoolTimeout      time.Duration
+}
+
+// LoadRedisConfig reads the Redis connection settings from the environment.
+// Unset values fall back to a standalone server on localhost:6379.
+func LoadRedisConfig() RedisConfig {
+	return RedisConfig{
+		Mode:             getEnv("REDIS_MODE", RedisModeStandalone),
+		Addrs:            getEnvList("REDIS_ADDRS", []string{"localhost:6379"}),
+		MasterName:       getEnv("REDIS_MASTER_NAME", ""),
+		Username:         getEnv("REDIS_USERNAME", ""),
+		Password:         getEnv("REDIS_PASSWORD", ""),
+		SentinelUsername: getEnv("REDIS_SENTINEL_USERNAME", ""),
+		SentinelPassword: getEnv("REDIS_SENTINEL_PASSWORD", ""),
+		DB:               getEnvInt("REDIS_DB", 0),
+		TLS: RedisTLSConfig{
+			Enabled:            getEnvBool("REDIS_TLS_ENABLED", false),
+			CAFile:             getEnv("REDIS_TLS_CA_FILE", ""),
+			CertFile:           getEnv("REDIS_TLS_CERT_FILE", ""),
+			KeyFile:            getEnv("REDIS_TLS_KEY_FILE", ""),
+			ServerName:         getEnv("REDIS_TLS_SERVER_NAME", ""),
+			InsecureSkipVerify: getEnvBool("REDIS_TLS_INSECURE_SKIP_VERIFY", false),
+		},
+		PoolSize:     getEnvInt("REDIS_POOL_SIZE", 0),
+		MinIdleConns: getEnvInt("REDIS_MIN_IDLE_CONNS", 0),
+		DialTimeout:  getEnvDuration("REDIS_DIAL_TIMEOUT", 5*time.Second),
+		ReadTimeout:  getEnvDuration("REDIS_READ_TIMEOUT", 3*time.Second),
+		WriteTimeout: getEnvDuration("REDIS_WRITE_TIMEOUT", 3*time.Second),
+		PoolTimeout:  getEnvDuration("REDIS_POOL_TIMEOUT", 0),
+	}
+}
+
+// NewRedisClient builds a standalone, Sentinel failover or Cluster client
+// depending on cfg.Mode.
+func NewRedisClient(cfg RedisConfig) (redis.UniversalClient, error) {
+	if len(cfg.Addrs) == 0 {
+		return nil, errors.New("redis: at least one address is required")
+	}
+
+	tlsConfig, err := buildRedisTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Mode {
+	case RedisModeStandalone, "":
+		return redis.NewClient(&redis.Options{
+			Addr:         cfg.Addrs[0],
+			Username:     cfg.Username,
+			Password:     cfg.Password,
+			DB:           cfg.DB,
+			TLSConfig:    tlsConfig,
+			PoolSize:     cfg.PoolSize,
+			MinIdleConns: cfg.MinIdleConns,
+			DialTimeout:  cfg.DialTimeout,
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			PoolTimeout:  cfg.PoolTimeout,
+		}), nil
+	case RedisModeSentinel:
+		if cfg.MasterName == "" {
+			return nil, errors.New("redis: REDIS_MASTER_NAME is required in sentinel mode")
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.MasterName,
+			SentinelAddrs:    cfg.Addrs,
+			SentinelUsername: cfg.SentinelUsername,
+			SentinelPassword: cfg.SentinelPassword,
+			Username:         cfg.Username,
+			Password:         cfg.Password,
+			DB:               cfg.DB,
+			TLSConfig:        tlsConfig,
+			PoolSize:         cfg.PoolSize,
+			MinIdleConns:     cfg.MinIdleConns,
+			DialTimeout:      cfg.DialTimeout,
+			ReadTimeout:      cfg.ReadTimeout,
+			WriteTimeout:     cfg.WriteTimeout,
+			PoolTimeout:      cfg.PoolTimeout,
+		}), nil
+	case RedisModeCluster:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        cfg.Addrs,
+			Username:     cfg.Username,
+			Password:     cfg.Password,
+			TLSConfig:    tlsConfig,
+			PoolSize:     cfg.PoolSize,
+			MinIdleConns: cfg.MinIdleConns,
+			DialTimeout:  cfg.DialTimeout,
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			PoolTimeout:  cfg.PoolTimeout,
+		}), nil
+	default:
+		return nil, fmt.Errorf("redis: unknown mode %q", cfg.Mode)
+	}
+}
+
+func buildRedisTLSConfig(cfg RedisTLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		caPEM, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("redis: reading CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("redis: no certificates found in %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("redis: loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
 func ConnectRedis() {
-	RedisClient = redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379",
-		Password: "",
-		DB:       0,
-	})
+	client, err := NewRedisClient(LoadRedisConfig())
+	if err != nil {
+		logging.Fatal("Failed to configure Redis", "error", err)
+	}
+	RedisClient = client
+
 	ctx := context.Background()
-	_, err := RedisClient.Ping(ctx).Result()
+	_, err = RedisClient.Ping(ctx).Result()
 	if err != nil {
-		fmt.Println("Failed to connect to Redis:", err)
+		slog.Error("Failed to connect to Redis", "error", err)
 	} else {
-		fmt.Println("Connected to Redis successfully!")
+		slog.Info("Connected to Redis")
 	}
 }
 func SetRedisClient(mockClient redis.Cmdable) {
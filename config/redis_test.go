@@ -0,0 +1,57 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRedisClient_Modes(t *testing.T) {
+	cfg := RedisConfig{Addrs: []string{"localhost:6379"}}
+
+	cfg.Mode = RedisModeStandalone
+	client, err := NewRedisClient(cfg)
+	assert.NoError(t, err)
+	assert.IsType(t, &redis.Client{}, client)
+
+	cfg.Mode = RedisModeSentinel
+	_, err = NewRedisClient(cfg)
+	assert.Error(t, err, "sentinel mode requires a master name")
+
+	cfg.MasterName = "mymaster"
+	client, err = NewRedisClient(cfg)
+	assert.NoError(t, err)
+	assert.IsType(t, &redis.Client{}, client)
+
+	cfg.Mode = RedisModeCluster
+	client, err = NewRedisClient(cfg)
+	assert.NoError(t, err)
+	assert.IsType(t, &redis.ClusterClient{}, client)
+
+	cfg.Mode = "bogus"
+	_, err = NewRedisClient(cfg)
+	assert.Error(t, err)
+}
+
+func TestNewRedisClient_TLSMissingCA(t *testing.T) {
+	cfg := RedisConfig{
+		Addrs: []string{"localhost:6380"},
+		TLS:   RedisTLSConfig{Enabled: true, CAFile: "does-not-exist.pem"},
+	}
+	_, err := NewRedisClient(cfg)
+	assert.Error(t, err)
+}
+
+func TestLoadRedisConfig_FromEnv(t *testing.T) {
+	t.Setenv("REDIS_MODE", RedisModeCluster)
+	t.Setenv("REDIS_ADDRS", "a:7000, b:7001")
+	t.Setenv("REDIS_POOL_SIZE", "42")
+	t.Setenv("REDIS_READ_TIMEOUT", "250ms")
+
+	cfg := LoadRedisConfig()
+	assert.Equal(t, RedisModeCluster, cfg.Mode)
+	assert.Equal(t, []string{"a:7000", "b:7001"}, cfg.Addrs)
+	assert.Equal(t, 42, cfg.PoolSize)
+	assert.Equal(t, "250ms", cfg.ReadTimeout.String())
+}
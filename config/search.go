@@ -0,0 +1,15 @@
+package config
+
+type SearchConfig struct {
+	// PriceBuckets are the default price facet boundaries, in increasing
+	// order. Requests can override them with price_buckets or
+	// price_quantiles.
+	PriceBuckets []string
+}
+
+// LoadSearchConfig reads the search settings from the environment.
+func LoadSearchConfig() SearchConfig {
+	return SearchConfig{
+		PriceBuckets: getEnvList("SEARCH_PRICE_BUCKETS", []string{"50", "200"}),
+	}
+}
@@ -0,0 +1,16 @@
+package config
+
+type ServerConfig struct {
+	// TrustedProxies lists the proxy IPs or CIDRs whose X-Forwarded-For and
+	// X-Real-IP headers are believed. Client IPs, which rate limits are keyed
+	// by, come from the connection when it is empty, so clients cannot pick
+	// their own IP by sending the headers.
+	TrustedProxies []string
+}
+
+// LoadServerConfig reads the HTTP server settings from the environment.
+func LoadServerConfig() ServerConfig {
+	return ServerConfig{
+		TrustedProxies: getEnvList("TRUSTED_PROXIES", nil),
+	}
+}
@@ -0,0 +1,24 @@
+package config
+
+import "time"
+
+type SlowQueryConfig struct {
+	// Threshold is the duration above which statements are logged and
+	// recorded. Zero disables the slow query log.
+	Threshold time.Duration
+	// Capacity is the number of distinct statements kept for the admin
+	// endpoint.
+	Capacity int
+	// Explain captures EXPLAIN (FORMAT JSON) plans for slow SELECTs on
+	// Postgres.
+	Explain bool
+}
+
+// LoadSlowQueryConfig reads the slow query log settings from the environment.
+func LoadSlowQueryConfig() SlowQueryConfig {
+	return SlowQueryConfig{
+		Threshold: getEnvDuration("SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+		Capacity:  getEnvInt("SLOW_QUERY_CAPACITY", 50),
+		Explain:   getEnvBool("SLOW_QUERY_EXPLAIN", false),
+	}
+}
@@ -0,0 +1,19 @@
+package config
+
+type TracingConfig struct {
+	// Exporter is "none", "otlp" or "stdout". OTLP uses the standard
+	// OTEL_EXPORTER_OTLP_ENDPOINT and OTEL_EXPORTER_OTLP_HEADERS variables.
+	Exporter    string
+	ServiceName string
+	// SampleRatio is the fraction of traces started here that are recorded.
+	SampleRatio float64
+}
+
+// LoadTracingConfig reads the tracing settings from the environment.
+func LoadTracingConfig() TracingConfig {
+	return TracingConfig{
+		Exporter:    getEnv("TRACING_EXPORTER", "none"),
+		ServiceName: getEnv("OTEL_SERVICE_NAME", "go-crud-app"),
+		SampleRatio: getEnvFloat("TRACING_SAMPLE_RATIO", 1),
+	}
+}
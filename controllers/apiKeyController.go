@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/i18n"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/services"
+	"gorm.io/gorm"
+)
+
+type createAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type createAPIKeyResponse struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Issues a new API key. The plaintext key is only returned once.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param key body createAPIKeyRequest true "Key name, scopes and optional expiry"
+// @Success 201 {object} createAPIKeyResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/api-keys [post]
+func CreateAPIKey(c *gin.Context) {
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeBindError(c, err)
+		return
+	}
+	key, plaintext, err := services.CreateAPIKey(c.Request.Context(), req.Name, req.Scopes, req.ExpiresAt)
+	switch {
+	case errors.Is(err, services.ErrAPIKeyNameRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgAPIKeyNameRequired)})
+		return
+	case errors.Is(err, services.ErrAPIKeyScopesRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgAPIKeyScopesRequired)})
+		return
+	case errors.Is(err, services.ErrUnknownScope):
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgUnknownScope, strings.Join(models.KnownScopes, ", "))})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, createAPIKeyResponse{APIKey: *key, Key: plaintext})
+}
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Description Lists all API keys, including revoked and expired ones
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} models.APIKey
+// @Failure 500 {object} map[string]string
+// @Router /admin/api-keys [get]
+func ListAPIKeys(c *gin.Context) {
+	keys, err := services.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revokes an API key by ID
+// @Tags Admin
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/api-keys/{id} [delete]
+func RevokeAPIKey(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidUUID)})
+		return
+	}
+	if err := services.RevokeAPIKey(c.Request.Context(), id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, i18n.MsgAPIKeyNotFound)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, i18n.MsgAPIKeyRevoked)})
+}
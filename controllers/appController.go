@@ -1,11 +1,18 @@
 package controllers
 
 import (
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/i18n"
+	"github.com/rahulmishra/go-crud-app/middleware"
 	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/policy"
 	"github.com/rahulmishra/go-crud-app/services"
 )
 
@@ -18,17 +25,25 @@ import (
 // @Param item body models.Item true "Item Data"
 // @Success 201 {object} models.Item
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
 // @Router /items/ [post]
 func CreateItem(c *gin.Context) {
 	var item models.Item
-	item.ID = uuid.New()
 	if err := c.ShouldBindJSON(&item); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeBindError(c, err)
+		return
+	}
+	// IDs are always assigned by the server, even if the body names one: a
+	// client-chosen ID could collide with an item another owner holds. The
+	// ID is set before authorizing because policies may read resource.id.
+	item.ID = uuid.New()
+	if !authorize(c, policy.ActionCreate, nil, &item) {
 		return
 	}
-	if err := services.CreateItem(&item); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := services.CreateItem(requestContext(c), &item); err != nil {
+		writeItemError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, item)
@@ -36,19 +51,143 @@ func CreateItem(c *gin.Context) {
 
 // GetAllItems godoc
 // @Summary Get all items
-// @Description Retrieves a list of all items
+// @Description Retrieves all items the caller is allowed to read
 // @Tags Items
 // @Produce json
+// @Param owner query string false "Only items owned by this subject; \"me\" for the caller"
 // @Success 200 {array} models.Item
 // @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
 // @Router /items/ [get]
 func GetAllItems(c *gin.Context) {
-	items, err := services.GetAllItems()
+	var query services.ItemQuery
+	if owner := c.Query("owner"); owner == "me" {
+		query.OwnerID = policySubject(c).ID
+	} else {
+		query.OwnerID = owner
+	}
+
+	items, err := services.GetAllItems(requestContext(c), query)
+	if err != nil {
+		writeItemError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, policy.FilterReadable(policySubject(c), items))
+}
+
+// SearchItems godoc
+// @Summary Search items by name
+// @Description Full-text search over item names, most relevant first. Every word in q must match the start of a word in the name. Matched words are wrapped in <mark> in the snippet. Facet counts cover every match the caller may read, ignoring min_price and max_price.
+// @Tags Items
+// @Produce json
+// @Param q query string true "Search text"
+// @Param page query int false "Page number, starting at 1"
+// @Param page_size query int false "Results per page (max 100)"
+// @Param min_price query number false "Only items priced at least this"
+// @Param max_price query number false "Only items priced below this"
+// @Param facets query string false "Comma-separated facets to count; only \"price\" is supported"
+// @Param price_buckets query string false "Price facet boundaries, e.g. 50,200 (default SEARCH_PRICE_BUCKETS)"
+// @Param price_quantiles query int false "Derive 2 to 10 price ranges of about equal size from the matches instead"
+// @Success 200 {object} services.SearchPage
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /items/search [get]
+func SearchItems(c *gin.Context) {
+	query := services.SearchQuery{Q: c.Query("q")}
+	query.Page, _ = strconv.Atoi(c.Query("page"))
+	query.PageSize, _ = strconv.Atoi(c.Query("page_size"))
+	var ok bool
+	if query.MinPrice, ok = priceParam(c, "min_price"); !ok {
+		return
+	}
+	if query.MaxPrice, ok = priceParam(c, "max_price"); !ok {
+		return
+	}
+	if facets := c.Query("facets"); facets != "" {
+		query.Facets.Names = strings.Split(facets, ",")
+	}
+	if buckets := c.Query("price_buckets"); buckets != "" {
+		bounds, err := services.ParsePriceBounds(buckets)
+		if err != nil {
+			writeItemError(c, err)
+			return
+		}
+		query.Facets.PriceBounds = bounds
+	}
+	if quantiles := c.Query("price_quantiles"); quantiles != "" {
+		n, err := strconv.Atoi(quantiles)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidPriceQuantiles)})
+			return
+		}
+		query.Facets.PriceQuantiles = n
+	}
+	subject := policySubject(c)
+	query.Readable = func(items []models.Item) []models.Item {
+		return policy.FilterReadable(subject, items)
+	}
+	query.Viewer = searchViewer(c)
+
+	page, err := services.SearchItems(requestContext(c), query)
+	if err != nil {
+		writeItemError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// priceParam parses an optional price query parameter, writing a 400 when it
+// is not a number.
+func priceParam(c *gin.Context, name string) (*float64, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(price) || math.IsInf(price, 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidPriceRange)})
+		return nil, false
+	}
+	return &price, true
+}
+
+// searchViewer describes everything that decides which items the caller
+// may read: the principal and the active policy. Callers with the same
+// viewer share cached search pages.
+func searchViewer(c *gin.Context) string {
+	viewer := policy.Current().Fingerprint()
+	if principal := middleware.GetPrincipal(c); principal != nil {
+		viewer += fmt.Sprintf("|%s|%v|%v|%v", principal.Subject, principal.Roles, principal.Groups, principal.Scopes)
+	}
+	return viewer
+}
+
+// SuggestItems godoc
+// @Summary Suggest items by name prefix
+// @Description Type-ahead suggestions: items whose names start with prefix, case-insensitively, in alphabetical order. Served from a Redis index.
+// @Tags Items
+// @Produce json
+// @Param prefix query string true "Start of the item name"
+// @Param limit query int false "Maximum number of suggestions (default 10, max 50)"
+// @Success 200 {array} services.Suggestion
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /items/suggest [get]
+func SuggestItems(c *gin.Context) {
+	query := services.SuggestQuery{Prefix: c.Query("prefix")}
+	query.Limit, _ = strconv.Atoi(c.Query("limit"))
+	subject := policySubject(c)
+	query.Readable = func(items []models.Item) []models.Item {
+		return policy.FilterReadable(subject, items)
+	}
+
+	suggestions, err := services.SuggestItems(requestContext(c), query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeItemError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, items)
+	c.JSON(http.StatusOK, suggestions)
 }
 
 // GetItemByID godoc
@@ -59,19 +198,24 @@ func GetAllItems(c *gin.Context) {
 // @Param id path string true "Item ID (UUID)"
 // @Success 200 {object} models.Item
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 503 {object} map[string]string
 // @Router /items/{id} [get]
 func GetItemByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidUUID)})
 		return
 	}
 
-	item, err := services.GetItemByID(id)
+	item, err := services.GetItemByID(requestContext(c), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		writeItemError(c, err)
+		return
+	}
+	if !authorize(c, policy.ActionRead, item, nil) {
 		return
 	}
 	c.JSON(http.StatusOK, item)
@@ -87,28 +231,40 @@ func GetItemByID(c *gin.Context) {
 // @Param item body models.Item true "Updated Item Data"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
 // @Router /items/{id} [put]
 func UpdateItem(c *gin.Context) {
 	idStr := c.Param("id")
 
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidUUID)})
 		return
 	}
 
 	var updatedItem models.Item
 	if err := c.ShouldBindJSON(&updatedItem); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeBindError(c, err)
 		return
 	}
 	updatedItem.ID = id
-	if err := services.UpdateItem(id, &updatedItem); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+	existing, err := services.GetItemByID(requestContext(c), id)
+	if err != nil {
+		writeItemError(c, err)
+		return
+	}
+	if !authorize(c, policy.ActionUpdate, existing, &updatedItem) {
+		return
+	}
+	if err := services.UpdateItem(requestContext(c), id, &updatedItem); err != nil {
+		writeItemError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Item updated successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, i18n.MsgItemUpdated)})
 }
 
 // DeleteItem godoc
@@ -118,20 +274,32 @@ func UpdateItem(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Item ID"
 // @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
 // @Router /items/{id} [delete]
 func DeleteItem(c *gin.Context) {
 	idStr := c.Param("id")
 
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidUUID)})
 		return
 	}
 
-	if err := services.DeleteItem(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	existing, err := services.GetItemByID(requestContext(c), id)
+	if err != nil {
+		writeItemError(c, err)
+		return
+	}
+	if !authorize(c, policy.ActionDelete, existing, nil) {
+		return
+	}
+	if err := services.DeleteItem(requestContext(c), id); err != nil {
+		writeItemError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Item deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, i18n.MsgItemDeleted)})
 }
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulmishra/go-crud-app/i18n"
+	"github.com/rahulmishra/go-crud-app/middleware"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/policy"
+	"github.com/rahulmishra/go-crud-app/services"
+	"github.com/rahulmishra/go-crud-app/validation"
+)
+
+// requestContext returns the request context with the caller attached as the
+// service-layer actor.
+func requestContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if principal := middleware.GetPrincipal(c); principal != nil {
+		ctx = services.WithActor(ctx, principal.Actor())
+	}
+	return ctx
+}
+
+// writeBindError reports a request body that could not be bound, listing
+// each failing field when the body was well-formed but invalid.
+func writeBindError(c *gin.Context, err error) {
+	var fields validation.Errors
+	if errors.As(validation.Translate(err), &fields) {
+		writeValidationError(c, fields)
+		return
+	}
+	_ = c.Error(err)
+	c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidRequestBody)})
+}
+
+// writeValidationError reports field errors in the caller's language.
+func writeValidationError(c *gin.Context, fields validation.Errors) {
+	lang := i18n.Language(c)
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  i18n.Translate(lang, i18n.MsgValidationFailed),
+		"fields": fields.Localize(lang),
+	})
+}
+
+// writeItemError maps service errors to HTTP responses.
+func writeItemError(c *gin.Context, err error) {
+	var fields validation.Errors
+	switch {
+	case errors.As(err, &fields):
+		writeValidationError(c, fields)
+	case errors.Is(err, services.ErrItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, i18n.MsgItemNotFound)})
+	case errors.Is(err, services.ErrInvalidSearch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidSearchQuery)})
+	case errors.Is(err, services.ErrUnknownFacet):
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgUnknownFacet)})
+	case errors.Is(err, services.ErrInvalidPriceBuckets):
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidPriceBuckets)})
+	case errors.Is(err, services.ErrInvalidPriceQuantiles):
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidPriceQuantiles)})
+	case errors.Is(err, services.ErrInvalidPriceRange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidPriceRange)})
+	case errors.Is(err, services.ErrInvalidPrefix):
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidPrefix)})
+	case errors.Is(err, services.ErrInvalidShare):
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidShare)})
+	case errors.Is(err, services.ErrShareNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, i18n.MsgShareNotFound)})
+	case errors.Is(err, services.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, i18n.MsgForbidden), "reason": i18n.T(c, i18n.MsgAccessDenied)})
+	case errors.Is(err, context.DeadlineExceeded):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": i18n.T(c, i18n.MsgServiceUnavailable)})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func policySubject(c *gin.Context) policy.Subject {
+	principal := middleware.GetPrincipal(c)
+	if principal == nil {
+		return policy.Subject{}
+	}
+	return principal.PolicySubject()
+}
+
+// authorize checks action against the active policy and writes a 403 with
+// the reason when it is denied.
+func authorize(c *gin.Context, action string, item, input *models.Item) bool {
+	decision := policy.Authorize(policySubject(c), action, item, input)
+	if !decision.Allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c, i18n.MsgForbidden), "reason": decision.Reason})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	runtimepprof "runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulmishra/go-crud-app/config"
+	"github.com/rahulmishra/go-crud-app/i18n"
+	"github.com/redis/go-redis/v9"
+)
+
+// Pprof serves the net/http/pprof handlers under /debug/pprof/.
+func Pprof(c *gin.Context) {
+	switch name := strings.TrimPrefix(c.Param("profile"), "/"); name {
+	case "":
+		pprof.Index(c.Writer, c.Request)
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		pprof.Handler(name).ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// DumpGoroutines godoc
+// @Summary Dump goroutines
+// @Description Writes the stack of every goroutine as plain text
+// @Tags Diagnostics
+// @Produce plain
+// @Success 200 {string} string
+// @Router /debug/goroutines [get]
+func DumpGoroutines(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	runtimepprof.Lookup("goroutine").WriteTo(c.Writer, 2)
+}
+
+// RuntimeStats godoc
+// @Summary Runtime and GC statistics
+// @Description Reports goroutine count, heap usage and garbage collector statistics
+// @Tags Diagnostics
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /debug/runtime [get]
+func RuntimeStats(c *gin.Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+
+	var lastGC string
+	if !gc.LastGC.IsZero() {
+		lastGC = gc.LastGC.UTC().Format(time.RFC3339Nano)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"goroutines": runtime.NumGoroutine(),
+		"gomaxprocs": runtime.GOMAXPROCS(0),
+		"num_cpu":    runtime.NumCPU(),
+		"memory": gin.H{
+			"heap_alloc_bytes":   mem.HeapAlloc,
+			"heap_inuse_bytes":   mem.HeapInuse,
+			"heap_objects":       mem.HeapObjects,
+			"heap_sys_bytes":     mem.HeapSys,
+			"total_alloc_bytes":  mem.TotalAlloc,
+			"sys_bytes":          mem.Sys,
+			"stack_inuse_bytes":  mem.StackInuse,
+			"next_gc_bytes":      mem.NextGC,
+			"gc_cpu_fraction":    mem.GCCPUFraction,
+			"memory_limit_bytes": debug.SetMemoryLimit(-1),
+		},
+		"gc": gin.H{
+			"num_gc":        gc.NumGC,
+			"last_gc":       lastGC,
+			"pause_total":   gc.PauseTotal.String(),
+			"recent_pauses": durations(gc.Pause, 10),
+			"forced_gc":     mem.NumForcedGC,
+		},
+	})
+}
+
+func durations(values []time.Duration, limit int) []string {
+	out := make([]string, 0, min(len(values), limit))
+	for _, d := range values[:min(len(values), limit)] {
+		out = append(out, d.String())
+	}
+	return out
+}
+
+// BuildInfo godoc
+// @Summary Build information
+// @Description Reports the Go version, module versions and VCS settings the binary was built with
+// @Tags Diagnostics
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /debug/build [get]
+func BuildInfo(c *gin.Context) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, i18n.MsgBuildInfoUnavailable)})
+		return
+	}
+	deps := make([]gin.H, 0, len(info.Deps))
+	for _, dep := range info.Deps {
+		deps = append(deps, gin.H{"path": dep.Path, "version": dep.Version})
+	}
+	settings := make(map[string]string, len(info.Settings))
+	for _, setting := range info.Settings {
+		settings[setting.Key] = setting.Value
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"go_version": info.GoVersion,
+		"path":       info.Path,
+		"main":       gin.H{"path": info.Main.Path, "version": info.Main.Version},
+		"settings":   settings,
+		"deps":       deps,
+	})
+}
+
+// EffectiveConfig godoc
+// @Summary Effective configuration
+// @Description Reports the configuration loaded from the environment with secrets redacted
+// @Tags Diagnostics
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /debug/config [get]
+func EffectiveConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, config.Effective())
+}
+
+// PoolStats godoc
+// @Summary Connection pool statistics
+// @Description Reports database and Redis connection pool statistics
+// @Tags Diagnostics
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /debug/pools [get]
+func PoolStats(c *gin.Context) {
+	result := gin.H{}
+	if config.DB != nil {
+		if sqlDB, err := config.DB.DB(); err == nil {
+			stats := sqlDB.Stats()
+			result["database"] = gin.H{
+				"max_open_connections": stats.MaxOpenConnections,
+				"open_connections":     stats.OpenConnections,
+				"in_use":               stats.InUse,
+				"idle":                 stats.Idle,
+				"wait_count":           stats.WaitCount,
+				"wait_duration":        stats.WaitDuration.String(),
+				"max_idle_closed":      stats.MaxIdleClosed,
+				"max_idle_time_closed": stats.MaxIdleTimeClosed,
+				"max_lifetime_closed":  stats.MaxLifetimeClosed,
+			}
+		}
+	}
+	if pooled, ok := config.RedisClient.(interface{ PoolStats() *redis.PoolStats }); ok {
+		stats := pooled.PoolStats()
+		result["redis"] = gin.H{
+			"hits":        stats.Hits,
+			"misses":      stats.Misses,
+			"timeouts":    stats.Timeouts,
+			"total_conns": stats.TotalConns,
+			"idle_conns":  stats.IdleConns,
+			"stale_conns": stats.StaleConns,
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulmishra/go-crud-app/faults"
+	"github.com/rahulmishra/go-crud-app/i18n"
+)
+
+// ListFaults godoc
+// @Summary List fault injection rules
+// @Description Returns the active fault injection rules with the number of faults each has injected
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} faults.Rule
+// @Router /admin/faults [get]
+func ListFaults(c *gin.Context) {
+	c.JSON(http.StatusOK, faults.Rules())
+}
+
+// AddFault godoc
+// @Summary Add a fault injection rule
+// @Description Injects latency, errors or timeouts into matching database or Redis operations
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param rule body faults.Rule true "Fault rule"
+// @Success 201 {object} faults.Rule
+// @Failure 400 {object} map[string]string
+// @Router /admin/faults [post]
+func AddFault(c *gin.Context) {
+	var rule faults.Rule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		writeBindError(c, err)
+		return
+	}
+	rule, err := faults.Add(rule)
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidFaultRule)})
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// RemoveFault godoc
+// @Summary Remove a fault injection rule
+// @Tags Admin
+// @Param id path string true "Rule ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /admin/faults/{id} [delete]
+func RemoveFault(c *gin.Context) {
+	if !faults.Remove(c.Param("id")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, i18n.MsgFaultRuleNotFound)})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ResetFaults godoc
+// @Summary Remove all fault injection rules
+// @Tags Admin
+// @Success 204
+// @Router /admin/faults [delete]
+func ResetFaults(c *gin.Context) {
+	faults.Reset()
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulmishra/go-crud-app/i18n"
+	"github.com/rahulmishra/go-crud-app/policy"
+)
+
+// ReloadPolicy godoc
+// @Summary Reload the authorization policy
+// @Description Re-reads the policy file. The previous policy stays active if the file is invalid.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Router /admin/policies/reload [post]
+func ReloadPolicy(c *gin.Context) {
+	if err := policy.Reload(); err != nil {
+		_ = c.Error(err)
+		key := i18n.MsgPolicyReloadFailed
+		if errors.Is(err, policy.ErrNotConfigured) {
+			key = i18n.MsgPolicyNotConfigured
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, key)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, i18n.MsgPolicyReloaded)})
+}
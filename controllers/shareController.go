@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/i18n"
+	"github.com/rahulmishra/go-crud-app/services"
+)
+
+type shareItemRequest struct {
+	GranteeType string `json:"grantee_type" binding:"required"`
+	GranteeID   string `json:"grantee_id" binding:"required"`
+	Permission  string `json:"permission" binding:"required"`
+}
+
+// ShareItem godoc
+// @Summary Share an item
+// @Description Grants a user or group read or write access to an item. Only the owner or an admin may share.
+// @Tags Sharing
+// @Accept json
+// @Produce json
+// @Param id path string true "Item ID"
+// @Param share body shareItemRequest true "Grantee and permission"
+// @Success 201 {object} models.ItemShare
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /items/{id}/shares [post]
+func ShareItem(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidUUID)})
+		return
+	}
+	var req shareItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeBindError(c, err)
+		return
+	}
+
+	share, err := services.ShareItem(requestContext(c), id, req.GranteeType, req.GranteeID, req.Permission)
+	if err != nil {
+		writeItemError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, share)
+}
+
+// ListItemShares godoc
+// @Summary List an item's shares
+// @Tags Sharing
+// @Produce json
+// @Param id path string true "Item ID"
+// @Success 200 {array} models.ItemShare
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /items/{id}/shares [get]
+func ListItemShares(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidUUID)})
+		return
+	}
+	shares, err := services.ListItemShares(requestContext(c), id)
+	if err != nil {
+		writeItemError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, shares)
+}
+
+// RevokeItemShare godoc
+// @Summary Revoke a share
+// @Tags Sharing
+// @Produce json
+// @Param id path string true "Item ID"
+// @Param shareId path string true "Share ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /items/{id}/shares/{shareId} [delete]
+func RevokeItemShare(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidUUID)})
+		return
+	}
+	shareID, err := uuid.Parse(c.Param("shareId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, i18n.MsgInvalidUUID)})
+		return
+	}
+	if err := services.RevokeItemShare(requestContext(c), id, shareID); err != nil {
+		writeItemError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.T(c, i18n.MsgShareRevoked)})
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulmishra/go-crud-app/slowquery"
+)
+
+// ListSlowQueries godoc
+// @Summary List slow queries
+// @Description Returns the slowest recorded SQL statements, slowest first, with their query plans when captured
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} slowquery.Entry
+// @Router /admin/slow-queries [get]
+func ListSlowQueries(c *gin.Context) {
+	c.JSON(http.StatusOK, slowquery.Entries())
+}
+
+// ResetSlowQueries godoc
+// @Summary Clear slow queries
+// @Description Clears the recorded slow statements
+// @Tags Admin
+// @Success 204
+// @Router /admin/slow-queries [delete]
+func ResetSlowQueries(c *gin.Context) {
+	slowquery.Reset()
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,235 @@
+// Package faults injects latency, errors and timeouts into database and
+// Redis operations so the API's behaviour under a failing dependency can be
+// exercised. It is meant for tests and development only.
+//
+// Faults are described by rules matched against each operation. The
+// injector is installed with InstrumentGORM and InstrumentRedis and does
+// nothing until a rule is added.
+package faults
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Targets a rule can apply to.
+const (
+	TargetDB    = "db"
+	TargetCache = "cache"
+)
+
+var (
+	// ErrInjected is wrapped by every injected error.
+	ErrInjected = errors.New("injected fault")
+	// ErrInvalidRule is wrapped by the errors Add returns for invalid rules.
+	ErrInvalidRule = errors.New("faults: invalid rule")
+)
+
+// defaultTimeout is how long timeout faults without a latency block when
+// the operation has no earlier deadline.
+const defaultTimeout = 5 * time.Second
+
+// Rule describes one fault.
+type Rule struct {
+	ID string `json:"id"`
+	// Target is TargetDB or TargetCache.
+	Target string `json:"target"`
+	// Operation restricts the rule to one operation: a GORM callback
+	// (create, query, update, delete, row or raw) for the database, or a
+	// Redis command such as get, set or del. Empty matches every operation.
+	Operation string `json:"operation,omitempty"`
+	// Key is a path.Match pattern matched against the table for the
+	// database and the first key for Redis, such as "item:*". Empty matches
+	// everything.
+	Key string `json:"key,omitempty"`
+	// Probability of injecting the fault into a matching operation, above 0
+	// and at most 1. Add sets it to 1 when it is omitted.
+	Probability *float64 `json:"probability,omitempty"`
+	// Latency delays the operation.
+	Latency Duration `json:"latency,omitempty"`
+	// Error fails the operation with this message, after any latency.
+	Error string `json:"error,omitempty"`
+	// Timeout blocks the operation until its context is done or Latency
+	// (5s by default) has passed, and then fails it with
+	// context.DeadlineExceeded.
+	Timeout bool `json:"timeout,omitempty"`
+	// Limit is the number of times the fault is injected before the rule
+	// stops matching. Zero means no limit.
+	Limit int `json:"limit,omitempty"`
+	// Injected counts the faults injected by this rule.
+	Injected int `json:"injected"`
+}
+
+// Duration is a time.Duration written as a string such as "250ms" in JSON.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"250ms\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+var (
+	mu    sync.Mutex
+	rules []*Rule
+)
+
+// Add validates r, assigns it an ID and activates it.
+func Add(r Rule) (Rule, error) {
+	if err := validate(&r); err != nil {
+		return Rule{}, err
+	}
+	r.ID = uuid.NewString()
+	r.Injected = 0
+	mu.Lock()
+	defer mu.Unlock()
+	rules = append(rules, &r)
+	return r, nil
+}
+
+func validate(r *Rule) error {
+	r.Target = strings.ToLower(r.Target)
+	r.Operation = strings.ToLower(r.Operation)
+	switch {
+	case r.Target != TargetDB && r.Target != TargetCache:
+		return fmt.Errorf("%w: target must be %q or %q", ErrInvalidRule, TargetDB, TargetCache)
+	case r.Probability != nil && (*r.Probability <= 0 || *r.Probability > 1):
+		return fmt.Errorf("%w: probability must be above 0 and at most 1", ErrInvalidRule)
+	case r.Latency < 0 || r.Limit < 0:
+		return fmt.Errorf("%w: latency and limit must not be negative", ErrInvalidRule)
+	case r.Latency == 0 && r.Error == "" && !r.Timeout:
+		return fmt.Errorf("%w: a latency, an error or a timeout is required", ErrInvalidRule)
+	}
+	if _, err := path.Match(r.Key, ""); err != nil {
+		return fmt.Errorf("%w: key pattern %q: %w", ErrInvalidRule, r.Key, err)
+	}
+	// The rule keeps its own copy, so the caller cannot change it while
+	// operations are being matched.
+	probability := 1.0
+	if r.Probability != nil {
+		probability = *r.Probability
+	}
+	r.Probability = &probability
+	return nil
+}
+
+// Rules returns the active rules in the order they were added.
+func Rules() []Rule {
+	mu.Lock()
+	defer mu.Unlock()
+	list := make([]Rule, len(rules))
+	for i, r := range rules {
+		list[i] = *r
+	}
+	return list
+}
+
+// Remove deactivates the rule with the given ID and reports whether it
+// existed.
+func Remove(id string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	for i, r := range rules {
+		if r.ID == id {
+			rules = append(rules[:i], rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Reset removes every rule.
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	rules = nil
+}
+
+// match returns a copy of the first rule that applies to the operation and
+// wins its probability roll, counting the injection.
+func match(target, operation, key string) (Rule, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, r := range rules {
+		if r.Target != target || (r.Operation != "" && r.Operation != operation) {
+			continue
+		}
+		if r.Limit > 0 && r.Injected >= r.Limit {
+			continue
+		}
+		if r.Key != "" {
+			if ok, _ := path.Match(r.Key, key); !ok {
+				continue
+			}
+		}
+		if rand.Float64() >= *r.Probability {
+			continue
+		}
+		r.Injected++
+		return *r, true
+	}
+	return Rule{}, false
+}
+
+// inject applies the first matching rule and returns the error the
+// operation should fail with, if any.
+func inject(ctx context.Context, target, operation, key string) error {
+	r, ok := match(target, operation, key)
+	if !ok {
+		return nil
+	}
+	slog.DebugContext(ctx, "Injecting fault", "rule", r.ID, "target", target, "operation", operation, "key", key)
+
+	if r.Timeout {
+		wait := time.Duration(r.Latency)
+		if wait == 0 {
+			wait = defaultTimeout
+		}
+		sleep(ctx, wait)
+		return fmt.Errorf("%w: %s %s: %w", ErrInjected, target, operation, context.DeadlineExceeded)
+	}
+	if err := sleep(ctx, time.Duration(r.Latency)); err != nil {
+		return err
+	}
+	if r.Error != "" {
+		return fmt.Errorf("%w: %s", ErrInjected, r.Error)
+	}
+	return nil
+}
+
+// sleep waits for d or until ctx is done, and returns the context's error
+// if that ends the wait.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,147 @@
+package faults
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/testutil/redistest"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func addRule(t *testing.T, r Rule) Rule {
+	t.Helper()
+	added, err := Add(r)
+	require.NoError(t, err)
+	t.Cleanup(Reset)
+	return added
+}
+
+func probability(p float64) *float64 {
+	return &p
+}
+
+func TestAdd_Validates(t *testing.T) {
+	for name, r := range map[string]Rule{
+		"target":      {Target: "disk", Error: "x"},
+		"probability": {Target: TargetDB, Error: "x", Probability: probability(1.5)},
+		"never":       {Target: TargetDB, Error: "x", Probability: probability(0)},
+		"latency":     {Target: TargetDB, Latency: Duration(-time.Second)},
+		"no fault":    {Target: TargetCache},
+		"pattern":     {Target: TargetCache, Error: "x", Key: "[item"},
+	} {
+		_, err := Add(r)
+		assert.Error(t, err, name)
+	}
+	assert.Empty(t, Rules())
+}
+
+func TestMatch(t *testing.T) {
+	limited := addRule(t, Rule{Target: "CACHE", Operation: "GET", Key: "item:*", Error: "boom", Limit: 2})
+	addRule(t, Rule{Target: TargetDB, Error: "down", Probability: probability(1)})
+
+	_, ok := match(TargetCache, "set", "item:1")
+	assert.False(t, ok, "other operations")
+	_, ok = match(TargetCache, "get", "all_items")
+	assert.False(t, ok, "other keys")
+
+	for i := 0; i < 2; i++ {
+		r, ok := match(TargetCache, "get", "item:1")
+		require.True(t, ok)
+		assert.Equal(t, limited.ID, r.ID)
+	}
+	_, ok = match(TargetCache, "get", "item:1")
+	assert.False(t, ok, "the limit is used up")
+
+	r, ok := match(TargetDB, "query", "items")
+	require.True(t, ok)
+	assert.Equal(t, "down", r.Error)
+
+	rules := Rules()
+	require.Len(t, rules, 2)
+	assert.Equal(t, 2, rules[0].Injected)
+	assert.Equal(t, 1, rules[1].Injected)
+
+	assert.True(t, Remove(limited.ID))
+	assert.False(t, Remove(limited.ID))
+	assert.Len(t, Rules(), 1)
+}
+
+func TestRule_JSON(t *testing.T) {
+	var r Rule
+	require.NoError(t, json.Unmarshal([]byte(`{"target":"db","latency":"250ms","timeout":true}`), &r))
+	assert.Equal(t, Duration(250*time.Millisecond), r.Latency)
+
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"latency":"250ms"`)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"latency":250}`), &r))
+}
+
+func TestInject_Timeout(t *testing.T) {
+	addRule(t, Rule{Target: TargetCache, Timeout: true})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := inject(ctx, TargetCache, "get", "k")
+	assert.ErrorIs(t, err, ErrInjected)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second, "the wait ends with the context")
+}
+
+func TestInstrumentGORM(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Item{}))
+	require.NoError(t, InstrumentGORM(db))
+
+	addRule(t, Rule{Target: TargetDB, Operation: "create", Key: "items", Error: "disk full"})
+	err = db.Create(&models.Item{ID: uuid.New(), Name: "Lamp", Price: 1}).Error
+	assert.ErrorIs(t, err, ErrInjected)
+	var count int64
+	require.NoError(t, db.Model(&models.Item{}).Count(&count).Error)
+	assert.Zero(t, count, "the statement is not executed")
+
+	Reset()
+	addRule(t, Rule{Target: TargetDB, Operation: "query", Latency: Duration(30 * time.Millisecond)})
+	start := time.Now()
+	var items []models.Item
+	require.NoError(t, db.Find(&items).Error)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+}
+
+func TestInstrumentRedis(t *testing.T) {
+	client := redistest.Run(t).NewClient()
+	defer client.Close()
+	InstrumentRedis(client)
+	ctx := context.Background()
+
+	addRule(t, Rule{Target: TargetCache, Key: "item:*", Error: "connection reset"})
+	assert.ErrorIs(t, client.Set(ctx, "item:1", "x", 0).Err(), ErrInjected)
+	require.NoError(t, client.Set(ctx, "all_items", "x", 0).Err())
+	assert.True(t, errors.Is(client.Get(ctx, "item:1").Err(), ErrInjected))
+
+	cmds, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Get(ctx, "all_items")
+		pipe.Del(ctx, "item:1")
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInjected)
+	for _, cmd := range cmds {
+		assert.ErrorIs(t, cmd.Err(), ErrInjected, "every command in a failed pipeline fails")
+	}
+
+	Reset()
+	assert.Equal(t, "x", client.Get(ctx, "all_items").Val())
+}
@@ -0,0 +1,25 @@
+package faults
+
+import (
+	"github.com/rahulmishra/go-crud-app/gormhooks"
+	"gorm.io/gorm"
+)
+
+// InstrumentGORM runs the injector before every statement executed through
+// db. Rules for TargetDB match the callback name and the table.
+func InstrumentGORM(db *gorm.DB) error {
+	return gormhooks.Register(db, "faults", injectGORM, nil)
+}
+
+// injectGORM adds the injected error to the statement, which stops GORM
+// from executing it.
+func injectGORM(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil {
+			return
+		}
+		if err := inject(db.Statement.Context, TargetDB, operation, db.Statement.Table); err != nil {
+			db.AddError(err)
+		}
+	}
+}
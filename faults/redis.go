@@ -0,0 +1,64 @@
+package faults
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// InstrumentRedis adds the injector as a hook to client. Rules for
+// TargetCache match the command name and its first key. Clients that do not
+// support hooks, such as test doubles, are left unchanged.
+func InstrumentRedis(client redis.Cmdable) {
+	if hooked, ok := client.(interface{ AddHook(redis.Hook) }); ok {
+		hooked.AddHook(redisHook{})
+	}
+}
+
+type redisHook struct{}
+
+func (redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := injectRedis(ctx, cmd); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+// ProcessPipelineHook fails the whole pipeline, or transaction, when a
+// fault is injected into any of its commands.
+func (redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			if err := injectRedis(ctx, cmd); err != nil {
+				for _, cmd := range cmds {
+					cmd.SetErr(err)
+				}
+				return err
+			}
+		}
+		return next(ctx, cmds)
+	}
+}
+
+func injectRedis(ctx context.Context, cmd redis.Cmder) error {
+	switch cmd.Name() {
+	case "multi", "exec":
+		return nil
+	}
+	var key string
+	if args := cmd.Args(); len(args) > 1 {
+		key = fmt.Sprint(args[1])
+	}
+	return inject(ctx, TargetCache, cmd.Name(), key)
+}
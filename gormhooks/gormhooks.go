@@ -0,0 +1,46 @@
+// Package gormhooks registers callbacks that run around every statement GORM
+// executes, for the packages that instrument the database.
+package gormhooks
+
+import "gorm.io/gorm"
+
+// Hook returns the callback to run for statements of operation: create,
+// query, update, delete, row or raw.
+type Hook func(operation string) func(*gorm.DB)
+
+// Register runs before ahead of and after behind each GORM operation on db.
+// Either may be nil. The callbacks are named prefix+":before_"+operation and
+// prefix+":after_"+operation, so each caller needs its own prefix.
+func Register(db *gorm.DB, prefix string, before, after Hook) error {
+	callbacks := db.Callback()
+	registrations := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		{"query", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		{"update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		{"delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		{"row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		{"raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	}
+	for _, r := range registrations {
+		if before != nil {
+			if err := r.before(prefix+":before_"+r.operation, before(r.operation)); err != nil {
+				return err
+			}
+		}
+		if after != nil {
+			if err := r.after(prefix+":after_"+r.operation, after(r.operation)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Always returns a Hook that runs fn for every operation.
+func Always(fn func(*gorm.DB)) Hook {
+	return func(string) func(*gorm.DB) { return fn }
+}
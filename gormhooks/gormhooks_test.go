@@ -0,0 +1,38 @@
+package gormhooks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type widget struct {
+	ID   uint
+	Name string
+}
+
+func TestRegister(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&widget{}))
+
+	var calls []string
+	record := func(stage string) Hook {
+		return func(operation string) func(*gorm.DB) {
+			return func(*gorm.DB) { calls = append(calls, stage+" "+operation) }
+		}
+	}
+	require.NoError(t, Register(db, "test", record("before"), record("after")))
+	require.NoError(t, Register(db, "before_only", Always(func(*gorm.DB) { calls = append(calls, "always") }), nil))
+
+	require.NoError(t, db.Create(&widget{Name: "a"}).Error)
+	assert.Equal(t, []string{"before create", "always", "after create"}, calls)
+
+	calls = nil
+	var widgets []widget
+	require.NoError(t, db.Find(&widgets).Error)
+	assert.Equal(t, []string{"before query", "always", "after query"}, calls)
+}
@@ -0,0 +1,131 @@
+// Package i18n translates user-facing messages into the language requested
+// by the Accept-Language header.
+package i18n
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// Message keys.
+const (
+	MsgInvalidUUID            = "invalid_uuid"
+	MsgItemNotFound           = "item_not_found"
+	MsgShareNotFound          = "share_not_found"
+	MsgAPIKeyNotFound         = "api_key_not_found"
+	MsgForbidden              = "forbidden"
+	MsgAccessDenied           = "access_denied"
+	MsgValidationFailed       = "validation_failed"
+	MsgInvalidSince           = "invalid_since"
+	MsgItemUpdated            = "item_updated"
+	MsgItemDeleted            = "item_deleted"
+	MsgAPIKeyRevoked          = "api_key_revoked"
+	MsgShareRevoked           = "share_revoked"
+	MsgPolicyReloaded         = "policy_reloaded"
+	MsgAuthNotConfigured      = "auth_not_configured"
+	MsgAuthMissingCredentials = "auth_missing_credentials"
+	MsgAuthInvalidToken       = "auth_invalid_token"
+	MsgAuthInvalidAPIKey      = "auth_invalid_api_key"
+	MsgAuthRequired           = "auth_required"
+	MsgInsufficientRole       = "insufficient_role"
+	MsgMissingScope           = "missing_scope"
+	MsgRateLimited            = "rate_limited"
+	MsgBuildInfoUnavailable   = "build_info_unavailable"
+	MsgServiceUnavailable     = "service_unavailable"
+	MsgFaultRuleNotFound      = "fault_rule_not_found"
+	MsgInvalidSearchQuery     = "invalid_search_query"
+	MsgInvalidPrefix          = "invalid_prefix"
+	MsgUnknownFacet           = "unknown_facet"
+	MsgInvalidPriceBuckets    = "invalid_price_buckets"
+	MsgInvalidPriceQuantiles  = "invalid_price_quantiles"
+	MsgInvalidPriceRange      = "invalid_price_range"
+	MsgInvalidRequestBody     = "invalid_request_body"
+	MsgInvalidShare           = "invalid_share"
+	MsgInvalidFaultRule       = "invalid_fault_rule"
+	MsgPolicyNotConfigured    = "policy_not_configured"
+	MsgPolicyReloadFailed     = "policy_reload_failed"
+	MsgAPIKeyNameRequired     = "api_key_name_required"
+	MsgAPIKeyScopesRequired   = "api_key_scopes_required"
+	MsgUnknownScope           = "unknown_scope"
+
+	MsgValidationRequired  = "validation.required"
+	MsgValidationGT        = "validation.gt"
+	MsgValidationGTE       = "validation.gte"
+	MsgValidationLT        = "validation.lt"
+	MsgValidationLTE       = "validation.lte"
+	MsgValidationMinLength = "validation.min_length"
+	MsgValidationMaxLength = "validation.max_length"
+	MsgValidationItemName  = "validation.itemname"
+	MsgValidationPrecision = "validation.precision"
+	MsgValidationOther     = "validation.other"
+)
+
+// Default is used when none of the requested languages is supported, and
+// for any message missing from another catalog.
+var Default = language.English
+
+var catalogs = map[language.Tag]map[string]string{
+	language.English: english,
+	language.German:  german,
+	language.French:  french,
+	language.Spanish: spanish,
+}
+
+// supported lists the catalog languages; the first entry is the matcher's
+// fallback.
+var supported = []language.Tag{
+	language.English,
+	language.German,
+	language.French,
+	language.Spanish,
+}
+
+var matcher = language.NewMatcher(supported)
+
+// Negotiate picks the best supported language for an Accept-Language
+// header. Regional variants fall back to their base language, so "de-AT"
+// resolves to German.
+func Negotiate(acceptLanguage string) language.Tag {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, index, _ := matcher.Match(tags...)
+	return supported[index]
+}
+
+// Translate formats the message key in lang, falling back to Default and
+// finally to the key itself.
+func Translate(lang language.Tag, key string, args ...interface{}) string {
+	format, ok := catalogs[lang][key]
+	if !ok {
+		if format, ok = catalogs[Default][key]; !ok {
+			format = key
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+const contextLanguage = "language"
+
+// Language returns the language negotiated for the request, and sets the
+// Content-Language response header the first time it is called.
+func Language(c *gin.Context) language.Tag {
+	if value, ok := c.Get(contextLanguage); ok {
+		return value.(language.Tag)
+	}
+	lang := Negotiate(c.GetHeader("Accept-Language"))
+	c.Set(contextLanguage, lang)
+	c.Header("Content-Language", lang.String())
+	return lang
+}
+
+// T translates key into the request's language.
+func T(c *gin.Context, key string, args ...interface{}) string {
+	return Translate(Language(c), key, args...)
+}
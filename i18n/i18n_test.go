@@ -0,0 +1,58 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]language.Tag{
+		"":                         language.English,
+		"de":                       language.German,
+		"de-AT":                    language.German,
+		"fr-CA,fr;q=0.9":           language.French,
+		"es-MX, en;q=0.5":          language.Spanish,
+		"ja, de;q=0.8, fr;q=0.9":   language.French,
+		"ja":                       language.English,
+		"not a language header!!!": language.English,
+	}
+	for header, want := range cases {
+		assert.Equal(t, want, Negotiate(header), header)
+	}
+}
+
+func TestTranslate_FallsBackToDefaultThenKey(t *testing.T) {
+	assert.Equal(t, "Artikel nicht gefunden", Translate(language.German, MsgItemNotFound))
+	assert.Equal(t, "Fehlender Scope items:write", Translate(language.German, MsgMissingScope, "items:write"))
+	assert.Equal(t, "Item not found", Translate(language.Japanese, MsgItemNotFound))
+	assert.Equal(t, "unknown.key", Translate(language.German, "unknown.key"))
+}
+
+func TestCatalogsAreComplete(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for key := range english {
+			assert.Contains(t, catalog, key, "%s catalog is missing %s", lang, key)
+		}
+	}
+}
+
+func TestT_SetsContentLanguage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"error": T(c, MsgItemNotFound)})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "es-ES")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "es", w.Header().Get("Content-Language"))
+	assert.JSONEq(t, `{"error":"Artículo no encontrado"}`, w.Body.String())
+}
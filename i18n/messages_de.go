@@ -0,0 +1,53 @@
+package i18n
+
+var german = map[string]string{
+	MsgInvalidUUID:            "Ungültiges UUID-Format",
+	MsgItemNotFound:           "Artikel nicht gefunden",
+	MsgShareNotFound:          "Freigabe nicht gefunden",
+	MsgAPIKeyNotFound:         "API-Schlüssel nicht gefunden",
+	MsgForbidden:              "Zugriff verweigert",
+	MsgAccessDenied:           "Sie haben keinen Zugriff auf diesen Artikel",
+	MsgValidationFailed:       "Validierung fehlgeschlagen",
+	MsgInvalidSince:           "since muss ein RFC-3339-Zeitstempel sein",
+	MsgItemUpdated:            "Artikel erfolgreich aktualisiert",
+	MsgItemDeleted:            "Artikel erfolgreich gelöscht",
+	MsgAPIKeyRevoked:          "API-Schlüssel erfolgreich widerrufen",
+	MsgShareRevoked:           "Freigabe erfolgreich widerrufen",
+	MsgPolicyReloaded:         "Richtlinie erfolgreich neu geladen",
+	MsgAuthNotConfigured:      "Authentifizierung ist nicht konfiguriert",
+	MsgAuthMissingCredentials: "Bearer-Token oder API-Schlüssel fehlt",
+	MsgAuthInvalidToken:       "Ungültiges Token",
+	MsgAuthInvalidAPIKey:      "Ungültiger API-Schlüssel",
+	MsgAuthRequired:           "Authentifizierung erforderlich",
+	MsgInsufficientRole:       "Unzureichende Rolle",
+	MsgMissingScope:           "Fehlender Scope %s",
+	MsgRateLimited:            "Anfragelimit überschritten",
+	MsgBuildInfoUnavailable:   "Build-Informationen sind nicht verfügbar",
+	MsgServiceUnavailable:     "Der Dienst ist vorübergehend nicht verfügbar",
+	MsgFaultRuleNotFound:      "Fehlerregel nicht gefunden",
+	MsgInvalidSearchQuery:     "q muss mindestens ein Wort enthalten",
+	MsgInvalidPrefix:          "prefix darf nicht leer sein",
+	MsgUnknownFacet:           "Unbekannte Facette; unterstützte Facetten: price",
+	MsgInvalidPriceBuckets:    "price_buckets muss aus höchstens 19 aufsteigenden, nicht negativen Preisen bestehen",
+	MsgInvalidPriceQuantiles:  "price_quantiles muss zwischen 2 und 10 liegen",
+	MsgInvalidPriceRange:      "min_price und max_price müssen nicht negative Zahlen sein, und min_price muss kleiner als max_price sein",
+	MsgInvalidRequestBody:     "Der Anfragetext ist kein gültiges JSON oder enthält ein Feld mit falschem Typ",
+	MsgInvalidShare:           "grantee_type muss user oder group sein, grantee_id darf nicht leer sein und permission muss read oder write sein",
+	MsgInvalidFaultRule:       "Ungültige Fehlerregel: target muss db oder cache sein, probability größer als 0 und höchstens 1, latency und limit nicht negativ und key ein gültiges Muster, und die Regel braucht eine Latenz, einen Fehler oder ein Timeout",
+	MsgPolicyNotConfigured:    "Es ist keine Richtliniendatei konfiguriert",
+	MsgPolicyReloadFailed:     "Die Richtliniendatei konnte nicht geladen werden; die bisherige Richtlinie bleibt aktiv",
+	MsgAPIKeyNameRequired:     "name ist erforderlich",
+	MsgAPIKeyScopesRequired:   "Mindestens ein Scope ist erforderlich",
+	MsgUnknownScope:           "Unbekannter Scope; unterstützte Scopes: %s",
+
+	MsgValidationRequired:  "ist erforderlich",
+	MsgValidationGT:        "muss größer als %s sein",
+	MsgValidationGTE:       "muss mindestens %s sein",
+	MsgValidationLT:        "muss kleiner als %s sein",
+	MsgValidationLTE:       "darf höchstens %s sein",
+	MsgValidationMinLength: "muss mindestens %s Zeichen lang sein",
+	MsgValidationMaxLength: "darf höchstens %s Zeichen lang sein",
+	MsgValidationItemName:  "muss sichtbare Zeichen und darf keine Steuerzeichen enthalten",
+	MsgValidationPrecision: "darf höchstens %s Nachkommastellen haben",
+	MsgValidationOther:     "verletzt die Regel %s",
+}
@@ -0,0 +1,53 @@
+package i18n
+
+var english = map[string]string{
+	MsgInvalidUUID:            "Invalid UUID format",
+	MsgItemNotFound:           "Item not found",
+	MsgShareNotFound:          "Share not found",
+	MsgAPIKeyNotFound:         "API key not found",
+	MsgForbidden:              "Forbidden",
+	MsgAccessDenied:           "You do not have access to this item",
+	MsgValidationFailed:       "Validation failed",
+	MsgInvalidSince:           "since must be an RFC 3339 timestamp",
+	MsgItemUpdated:            "Item updated successfully",
+	MsgItemDeleted:            "Item deleted successfully",
+	MsgAPIKeyRevoked:          "API key revoked successfully",
+	MsgShareRevoked:           "Share revoked successfully",
+	MsgPolicyReloaded:         "Policy reloaded successfully",
+	MsgAuthNotConfigured:      "Authentication is not configured",
+	MsgAuthMissingCredentials: "Missing bearer token or API key",
+	MsgAuthInvalidToken:       "Invalid token",
+	MsgAuthInvalidAPIKey:      "Invalid API key",
+	MsgAuthRequired:           "Authentication required",
+	MsgInsufficientRole:       "Insufficient role",
+	MsgMissingScope:           "Missing scope %s",
+	MsgRateLimited:            "Rate limit exceeded",
+	MsgBuildInfoUnavailable:   "Build information is not available",
+	MsgServiceUnavailable:     "The service is temporarily unavailable",
+	MsgFaultRuleNotFound:      "Fault rule not found",
+	MsgInvalidSearchQuery:     "q must contain at least one word",
+	MsgInvalidPrefix:          "prefix must not be empty",
+	MsgUnknownFacet:           "Unknown facet; supported facets: price",
+	MsgInvalidPriceBuckets:    "price_buckets must be at most 19 increasing, non-negative prices",
+	MsgInvalidPriceQuantiles:  "price_quantiles must be between 2 and 10",
+	MsgInvalidPriceRange:      "min_price and max_price must be non-negative numbers with min_price below max_price",
+	MsgInvalidRequestBody:     "The request body is not valid JSON or has a field of the wrong type",
+	MsgInvalidShare:           "grantee_type must be user or group, grantee_id must not be empty and permission must be read or write",
+	MsgInvalidFaultRule:       "Invalid fault rule: target must be db or cache, probability above 0 and at most 1, latency and limit not negative and key a valid pattern, and the rule needs a latency, an error or a timeout",
+	MsgPolicyNotConfigured:    "No policy file is configured",
+	MsgPolicyReloadFailed:     "The policy file could not be loaded; the previous policy is still active",
+	MsgAPIKeyNameRequired:     "name is required",
+	MsgAPIKeyScopesRequired:   "At least one scope is required",
+	MsgUnknownScope:           "Unknown scope; supported scopes: %s",
+
+	MsgValidationRequired:  "is required",
+	MsgValidationGT:        "must be greater than %s",
+	MsgValidationGTE:       "must be at least %s",
+	MsgValidationLT:        "must be less than %s",
+	MsgValidationLTE:       "must be at most %s",
+	MsgValidationMinLength: "must be at least %s characters long",
+	MsgValidationMaxLength: "must be at most %s characters long",
+	MsgValidationItemName:  "must contain visible characters and no control characters",
+	MsgValidationPrecision: "must have at most %s decimal places",
+	MsgValidationOther:     "failed the %s rule",
+}
@@ -0,0 +1,53 @@
+package i18n
+
+var spanish = map[string]string{
+	MsgInvalidUUID:            "Formato de UUID no válido",
+	MsgItemNotFound:           "Artículo no encontrado",
+	MsgShareNotFound:          "Recurso compartido no encontrado",
+	MsgAPIKeyNotFound:         "Clave de API no encontrada",
+	MsgForbidden:              "Acceso denegado",
+	MsgAccessDenied:           "No tiene acceso a este artículo",
+	MsgValidationFailed:       "La validación ha fallado",
+	MsgInvalidSince:           "since debe ser una marca de tiempo RFC 3339",
+	MsgItemUpdated:            "Artículo actualizado correctamente",
+	MsgItemDeleted:            "Artículo eliminado correctamente",
+	MsgAPIKeyRevoked:          "Clave de API revocada correctamente",
+	MsgShareRevoked:           "Recurso compartido revocado correctamente",
+	MsgPolicyReloaded:         "Política recargada correctamente",
+	MsgAuthNotConfigured:      "La autenticación no está configurada",
+	MsgAuthMissingCredentials: "Falta el token bearer o la clave de API",
+	MsgAuthInvalidToken:       "Token no válido",
+	MsgAuthInvalidAPIKey:      "Clave de API no válida",
+	MsgAuthRequired:           "Se requiere autenticación",
+	MsgInsufficientRole:       "Rol insuficiente",
+	MsgMissingScope:           "Falta el ámbito %s",
+	MsgRateLimited:            "Se ha superado el límite de solicitudes",
+	MsgBuildInfoUnavailable:   "La información de compilación no está disponible",
+	MsgServiceUnavailable:     "El servicio no está disponible temporalmente",
+	MsgFaultRuleNotFound:      "Regla de fallo no encontrada",
+	MsgInvalidSearchQuery:     "q debe contener al menos una palabra",
+	MsgInvalidPrefix:          "prefix no debe estar vacío",
+	MsgUnknownFacet:           "Faceta desconocida; facetas admitidas: price",
+	MsgInvalidPriceBuckets:    "price_buckets debe tener como máximo 19 precios crecientes y no negativos",
+	MsgInvalidPriceQuantiles:  "price_quantiles debe estar entre 2 y 10",
+	MsgInvalidPriceRange:      "min_price y max_price deben ser números no negativos y min_price menor que max_price",
+	MsgInvalidRequestBody:     "El cuerpo de la solicitud no es JSON válido o tiene un campo del tipo incorrecto",
+	MsgInvalidShare:           "grantee_type debe ser user o group, grantee_id no debe estar vacío y permission debe ser read o write",
+	MsgInvalidFaultRule:       "Regla de fallo no válida: target debe ser db o cache, probability mayor que 0 y como máximo 1, latency y limit no negativos y key un patrón válido, y la regla necesita una latencia, un error o un tiempo de espera",
+	MsgPolicyNotConfigured:    "No hay ningún archivo de política configurado",
+	MsgPolicyReloadFailed:     "No se pudo cargar el archivo de política; la política anterior sigue activa",
+	MsgAPIKeyNameRequired:     "name es obligatorio",
+	MsgAPIKeyScopesRequired:   "Se requiere al menos un ámbito",
+	MsgUnknownScope:           "Ámbito desconocido; ámbitos admitidos: %s",
+
+	MsgValidationRequired:  "es obligatorio",
+	MsgValidationGT:        "debe ser mayor que %s",
+	MsgValidationGTE:       "debe ser al menos %s",
+	MsgValidationLT:        "debe ser menor que %s",
+	MsgValidationLTE:       "debe ser como máximo %s",
+	MsgValidationMinLength: "debe tener al menos %s caracteres",
+	MsgValidationMaxLength: "debe tener como máximo %s caracteres",
+	MsgValidationItemName:  "debe contener caracteres visibles y ningún carácter de control",
+	MsgValidationPrecision: "debe tener como máximo %s decimales",
+	MsgValidationOther:     "no cumple la regla %s",
+}
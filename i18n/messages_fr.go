@@ -0,0 +1,53 @@
+package i18n
+
+var french = map[string]string{
+	MsgInvalidUUID:            "Format d'UUID invalide",
+	MsgItemNotFound:           "Article introuvable",
+	MsgShareNotFound:          "Partage introuvable",
+	MsgAPIKeyNotFound:         "Clé API introuvable",
+	MsgForbidden:              "Accès refusé",
+	MsgAccessDenied:           "Vous n'avez pas accès à cet article",
+	MsgValidationFailed:       "La validation a échoué",
+	MsgInvalidSince:           "since doit être un horodatage RFC 3339",
+	MsgItemUpdated:            "Article mis à jour avec succès",
+	MsgItemDeleted:            "Article supprimé avec succès",
+	MsgAPIKeyRevoked:          "Clé API révoquée avec succès",
+	MsgShareRevoked:           "Partage révoqué avec succès",
+	MsgPolicyReloaded:         "Politique rechargée avec succès",
+	MsgAuthNotConfigured:      "L'authentification n'est pas configurée",
+	MsgAuthMissingCredentials: "Jeton bearer ou clé API manquant",
+	MsgAuthInvalidToken:       "Jeton invalide",
+	MsgAuthInvalidAPIKey:      "Clé API invalide",
+	MsgAuthRequired:           "Authentification requise",
+	MsgInsufficientRole:       "Rôle insuffisant",
+	MsgMissingScope:           "Portée %s manquante",
+	MsgRateLimited:            "Limite de requêtes dépassée",
+	MsgBuildInfoUnavailable:   "Les informations de build ne sont pas disponibles",
+	MsgServiceUnavailable:     "Le service est temporairement indisponible",
+	MsgFaultRuleNotFound:      "Règle de panne introuvable",
+	MsgInvalidSearchQuery:     "q doit contenir au moins un mot",
+	MsgInvalidPrefix:          "prefix ne doit pas être vide",
+	MsgUnknownFacet:           "Facette inconnue ; facettes prises en charge : price",
+	MsgInvalidPriceBuckets:    "price_buckets doit contenir au plus 19 prix croissants et positifs",
+	MsgInvalidPriceQuantiles:  "price_quantiles doit être compris entre 2 et 10",
+	MsgInvalidPriceRange:      "min_price et max_price doivent être des nombres positifs, avec min_price inférieur à max_price",
+	MsgInvalidRequestBody:     "Le corps de la requête n'est pas un JSON valide ou contient un champ du mauvais type",
+	MsgInvalidShare:           "grantee_type doit valoir user ou group, grantee_id ne doit pas être vide et permission doit valoir read ou write",
+	MsgInvalidFaultRule:       "Règle de panne invalide : target doit valoir db ou cache, probability être supérieure à 0 et au plus 1, latency et limit ne pas être négatifs et key être un motif valide, et la règle nécessite une latence, une erreur ou un délai d'expiration",
+	MsgPolicyNotConfigured:    "Aucun fichier de politique n'est configuré",
+	MsgPolicyReloadFailed:     "Le fichier de politique n'a pas pu être chargé ; la politique précédente reste active",
+	MsgAPIKeyNameRequired:     "name est obligatoire",
+	MsgAPIKeyScopesRequired:   "Au moins une portée est obligatoire",
+	MsgUnknownScope:           "Portée inconnue ; portées prises en charge : %s",
+
+	MsgValidationRequired:  "est obligatoire",
+	MsgValidationGT:        "doit être supérieur à %s",
+	MsgValidationGTE:       "doit être au moins %s",
+	MsgValidationLT:        "doit être inférieur à %s",
+	MsgValidationLTE:       "doit être au plus %s",
+	MsgValidationMinLength: "doit contenir au moins %s caractères",
+	MsgValidationMaxLength: "doit contenir au plus %s caractères",
+	MsgValidationItemName:  "doit contenir des caractères visibles et aucun caractère de contrôle",
+	MsgValidationPrecision: "doit avoir au plus %s décimales",
+	MsgValidationOther:     "ne respecte pas la règle %s",
+}
@@ -0,0 +1,35 @@
+package logging
+
+import "context"
+
+type requestIDKey struct{}
+
+type routeKey struct{}
+
+// WithRequestID attaches the ID of the HTTP request being served to ctx.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID attached to ctx, if any.
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// WithRoute attaches the matched route pattern, such as "/items/:id", to ctx.
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// Route returns the route pattern attached to ctx, if any.
+func Route(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	route, _ := ctx.Value(routeKey{}).(string)
+	return route
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger sends GORM's logs to the default slog logger. Statements are
+// logged at debug level and failures at error; missing records are not
+// treated as failures. Slow statements are reported by package slowquery.
+type GormLogger struct{}
+
+func NewGormLogger() *GormLogger {
+	return &GormLogger{}
+}
+
+// LogMode is a no-op; the slog level decides what is written.
+func (l *GormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	slog.InfoContext(ctx, msg, "args", args)
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	slog.WarnContext(ctx, msg, "args", args)
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	slog.ErrorContext(ctx, msg, "args", args)
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	level := slog.LevelDebug
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		level = slog.LevelError
+	}
+
+	logger := slog.Default()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Duration("elapsed", time.Since(begin)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	logger.LogAttrs(ctx, level, "gorm query", attrs...)
+}
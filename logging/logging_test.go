@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestNew_AddsRequestAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{Level: "debug", Format: "json"})
+	require.NoError(t, err)
+
+	ctx := WithRoute(WithRequestID(context.Background(), "req-42"), "/items/:id")
+	logger.With("component", "cache").DebugContext(ctx, "Cache hit", "key", "item:1")
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "req-42", line["request_id"])
+	assert.Equal(t, "/items/:id", line["route"])
+	assert.Equal(t, "cache", line["component"])
+	assert.Equal(t, "item:1", line["key"])
+}
+
+func TestNew_LevelAndFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{Level: "WARN", Format: "text"})
+	require.NoError(t, err)
+
+	logger.Info("dropped")
+	logger.Warn("kept")
+	assert.NotContains(t, buf.String(), "dropped")
+	assert.Contains(t, buf.String(), "level=WARN msg=kept")
+
+	_, err = New(&buf, Options{Level: "loud"})
+	assert.Error(t, err)
+	_, err = New(&buf, Options{Format: "xml"})
+	assert.Error(t, err)
+}
+
+func TestGormLogger_Trace(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{Level: "info"})
+	require.NoError(t, err)
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(previous)
+
+	ctx := WithRequestID(context.Background(), "req-7")
+	query := func() (string, int64) { return "SELECT 1", 1 }
+	gormLogger := NewGormLogger()
+
+	gormLogger.Trace(ctx, time.Now(), query, nil)
+	assert.Empty(t, buf.String(), "successful statements are debug-level")
+
+	gormLogger.Trace(ctx, time.Now(), query, gorm.ErrRecordNotFound)
+	assert.Empty(t, buf.String(), "missing records are not errors")
+
+	gormLogger.Trace(ctx, time.Now(), query, errors.New("boom"))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], `"level":"ERROR"`)
+	assert.Contains(t, lines[0], `"error":"boom"`)
+	assert.Contains(t, lines[0], `"request_id":"req-7"`)
+	assert.Contains(t, lines[0], `"sql":"SELECT 1"`)
+}
@@ -1,21 +1,203 @@
 package main
 
 import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+
 	"github.com/gin-gonic/gin"
+	"github.com/rahulmishra/go-crud-app/cache"
 	"github.com/rahulmishra/go-crud-app/config"
 	_ "github.com/rahulmishra/go-crud-app/docs"
+	"github.com/rahulmishra/go-crud-app/faults"
+	"github.com/rahulmishra/go-crud-app/logging"
+	"github.com/rahulmishra/go-crud-app/metrics"
+	"github.com/rahulmishra/go-crud-app/middleware"
 	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/policy"
+	"github.com/rahulmishra/go-crud-app/repository"
 	"github.com/rahulmishra/go-crud-app/routes"
+	"github.com/rahulmishra/go-crud-app/services"
+	"github.com/rahulmishra/go-crud-app/slowquery"
+	"github.com/rahulmishra/go-crud-app/tracing"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
 func main() {
+	setupLogging()
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
+	serve()
+}
+
+// setupLogging loads .env early so LOG_LEVEL and LOG_FORMAT apply to
+// everything that follows, including connection messages.
+func setupLogging() {
+	config.LoadEnv()
+	logConfig := config.LoadLogConfig()
+	if err := logging.Setup(logging.Options{Level: logConfig.Level, Format: logConfig.Format}); err != nil {
+		logging.Fatal("Invalid log configuration", "error", err)
+	}
+}
+
+func connectDatabase() {
 	config.ConnectDatabase()
-	config.DB.AutoMigrate(&models.Item{})
+	prepareDatabase()
+}
+
+// prepareDatabase creates the tables and search index config.DB needs.
+func prepareDatabase() {
+	config.DB.AutoMigrate(&models.Item{}, &models.ItemShare{}, &models.APIKey{}, &models.AuditRecord{})
+	if err := repository.ProtectAuditLog(context.Background()); err != nil {
+		logging.Fatal("Failed to protect audit log", "error", err)
+	}
+	if err := repository.PrepareItemSearch(context.Background()); err != nil {
+		logging.Fatal("Failed to prepare item search", "error", err)
+	}
+}
+
+func serve() {
+	connectDatabase()
+	slowQueryConfig := config.LoadSlowQueryConfig()
+	if err := slowquery.Instrument(config.DB, slowquery.Options{
+		Threshold: slowQueryConfig.Threshold,
+		Capacity:  slowQueryConfig.Capacity,
+		Explain:   slowQueryConfig.Explain,
+	}); err != nil {
+		logging.Fatal("Failed to instrument database", "error", err)
+	}
 	config.ConnectRedis()
-	r := gin.Default()
+	configureCache()
+	if err := services.SetDefaultPriceBuckets(config.LoadSearchConfig().PriceBuckets); err != nil {
+		logging.Fatal("Invalid search configuration", "error", err)
+	}
+	authConfig := config.LoadAuthConfig()
+	if err := middleware.ConfigureAuth(authConfig); err != nil {
+		logging.Fatal("Invalid auth configuration", "error", err)
+	}
+	if err := policy.Configure(authConfig.PolicyFile); err != nil {
+		logging.Fatal("Invalid policy", "error", err)
+	}
+	policy.ReloadOnSIGHUP()
+	if err := middleware.ConfigureRateLimit(config.LoadRateLimitConfig(), config.RedisClient); err != nil {
+		logging.Fatal("Invalid rate limit configuration", "error", err)
+	}
+	shutdownTracing := setupTracing(config.LoadTracingConfig())
+	defer shutdownTracing(context.Background())
+
+	r, err := routes.NewEngine(config.LoadServerConfig().TrustedProxies)
+	if err != nil {
+		logging.Fatal("Invalid trusted proxies", "error", err)
+	}
+	r.Use(gin.Recovery(), middleware.Tracing(), middleware.RequestLogger())
+	setupMetrics(r, config.LoadMetricsConfig())
+	faultsConfig := config.LoadFaultsConfig()
+	if faultsConfig.Enabled {
+		setupFaults()
+	}
+	serveDiagnostics(config.LoadDiagnosticsConfig())
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	routes.SetupItemRoutes(r)
+	routes.SetupAuditRoutes(r)
+	routes.SetupAdminRoutes(r)
+	if faultsConfig.Enabled {
+		routes.SetupFaultRoutes(r)
+	}
 	r.Run(":9000")
 }
+
+// configureCache applies the cache settings and starts the write-behind
+// worker when that mode is selected.
+func configureCache() {
+	cacheConfig := config.LoadCacheConfig()
+	if err := cache.Configure(cache.Options{
+		Codec:                cacheConfig.Codec,
+		Compression:          cacheConfig.Compression,
+		CompressionThreshold: cacheConfig.CompressionThreshold,
+		SchemaVersion:        models.CacheSchemaVersion,
+	}); err != nil {
+		logging.Fatal("Invalid cache configuration", "error", err)
+	}
+	if err := services.SetCacheWriteMode(cacheConfig.WriteMode); err != nil {
+		logging.Fatal("Invalid cache configuration", "error", err)
+	}
+	if cacheConfig.WriteMode == config.CacheWriteBehind {
+		services.StartWriteBehindWorker(context.Background(), cacheConfig.WriteBehindInterval, cacheConfig.WriteBehindBatchSize)
+	}
+}
+
+// setupFaults installs the fault injector on the database and Redis
+// clients. It is installed after the tracing and metrics hooks so that
+// injected latency and errors show up in both.
+func setupFaults() {
+	slog.Warn("Fault injection is enabled; do not use this in production")
+	if err := faults.InstrumentGORM(config.DB); err != nil {
+		logging.Fatal("Failed to instrument database", "error", err)
+	}
+	faults.InstrumentRedis(config.RedisClient)
+}
+
+// setupTracing installs the tracer provider and instruments the database and
+// Redis clients. The returned function flushes pending spans.
+func setupTracing(cfg config.TracingConfig) func(context.Context) error {
+	shutdown, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.Exporter,
+		ServiceName: cfg.ServiceName,
+		SampleRatio: cfg.SampleRatio,
+	})
+	if err != nil {
+		logging.Fatal("Invalid tracing configuration", "error", err)
+	}
+	if err := tracing.InstrumentGORM(config.DB); err != nil {
+		logging.Fatal("Failed to instrument database", "error", err)
+	}
+	tracing.InstrumentRedis(config.RedisClient)
+	return shutdown
+}
+
+// setupMetrics instruments the database, Redis and r, and serves /metrics
+// either on its own listener or, to admins only, on r.
+func setupMetrics(r *gin.Engine, cfg config.MetricsConfig) {
+	if !cfg.Enabled {
+		return
+	}
+	if err := metrics.InstrumentGORM(config.DB); err != nil {
+		logging.Fatal("Failed to instrument database", "error", err)
+	}
+	metrics.InstrumentRedis(config.RedisClient)
+	r.Use(middleware.Metrics())
+
+	if cfg.Addr == "" {
+		routes.SetupMetricsRoutes(r)
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	go func() {
+		slog.Info("Serving metrics", "addr", cfg.Addr)
+		if err := http.ListenAndServe(cfg.Addr, mux); err != nil {
+			logging.Fatal("Metrics listener failed", "error", err)
+		}
+	}()
+}
+
+// serveDiagnostics starts the admin listener for pprof and runtime
+// diagnostics when DIAGNOSTICS_ADDR is set.
+func serveDiagnostics(cfg config.DiagnosticsConfig) {
+	if cfg.Addr == "" {
+		return
+	}
+	admin := gin.New()
+	admin.Use(gin.Recovery(), middleware.RequestLogger())
+	routes.SetupDiagnosticsRoutes(admin)
+	go func() {
+		slog.Info("Serving diagnostics", "addr", cfg.Addr)
+		if err := admin.Run(cfg.Addr); err != nil {
+			logging.Fatal("Diagnostics listener failed", "error", err)
+		}
+	}()
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/rahulmishra/go-crud-app/gormhooks"
+	"gorm.io/gorm"
+)
+
+const startTimeKey = "metrics:start_time"
+
+var dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "db_query_duration_seconds",
+	Help:      "GORM statement latency by operation and outcome.",
+	Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"operation", "status"})
+
+var dbStatsOnce sync.Once
+
+// InstrumentGORM times every statement run through db and exports the pool
+// statistics of its sql.DB.
+func InstrumentGORM(db *gorm.DB) error {
+	err := gormhooks.Register(db, "metrics", gormhooks.Always(startTimer), observeQuery)
+	if err != nil {
+		return err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	registerDBStats(sqlDB)
+	return nil
+}
+
+func registerDBStats(sqlDB *sql.DB) {
+	dbStatsOnce.Do(func() {
+		Registry.MustRegister(collectors.NewDBStatsCollector(sqlDB, "app"))
+	})
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(startTimeKey, time.Now())
+}
+
+func observeQuery(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startTimeKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+		status := "ok"
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			status = "error"
+		}
+		dbQueryDuration.WithLabelValues(operation, status).Observe(time.Since(start).Seconds())
+	}
+}
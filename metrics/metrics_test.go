@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// The registry is global and other tests record into it too, so tests
+// compare values before and after rather than expecting absolute ones.
+
+// histogramCount returns the number of observations in h with labels.
+func histogramCount(t *testing.T, h *prometheus.HistogramVec, labels ...string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	require.NoError(t, h.WithLabelValues(labels...).(prometheus.Metric).Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestHandler_ServesRecordedMetrics(t *testing.T) {
+	requests := testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/items/:id", "200"))
+	unmatched := testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "unmatched", "404"))
+	fast := histogramCount(t, httpDuration, http.MethodGet, "/items/:id", "200")
+	hits := testutil.ToFloat64(cacheRequests.WithLabelValues("item", "hit"))
+	misses := testutil.ToFloat64(cacheRequests.WithLabelValues("all_items", "miss"))
+
+	ObserveHTTPRequest(http.MethodGet, "/items/:id", http.StatusOK, 15*time.Millisecond)
+	ObserveHTTPRequest(http.MethodGet, "", http.StatusNotFound, time.Millisecond)
+	ObserveCache("item", true)
+	ObserveCache("all_items", false)
+
+	assert.Equal(t, requests+1, testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/items/:id", "200")))
+	assert.Equal(t, unmatched+1, testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "unmatched", "404")))
+	assert.Equal(t, fast+1, histogramCount(t, httpDuration, http.MethodGet, "/items/:id", "200"))
+	assert.Equal(t, hits+1, testutil.ToFloat64(cacheRequests.WithLabelValues("item", "hit")))
+	assert.Equal(t, misses+1, testutil.ToFloat64(cacheRequests.WithLabelValues("all_items", "miss")))
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	assert.Contains(t, body, `go_crud_app_http_requests_total{method="GET",route="/items/:id",status="200"}`)
+	assert.Contains(t, body, `go_crud_app_http_request_duration_seconds_bucket{method="GET",route="/items/:id",status="200",le="0.025"}`)
+	assert.Contains(t, body, `go_crud_app_cache_requests_total{namespace="item",result="hit"}`)
+	assert.Contains(t, body, "go_crud_app_cache_decode_errors_total")
+}
+
+func TestInstrumentGORM(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, InstrumentGORM(db))
+
+	type widget struct {
+		ID   uint
+		Name string
+	}
+	require.NoError(t, db.AutoMigrate(&widget{}))
+	creates := histogramCount(t, dbQueryDuration, "create", "ok")
+	queries := histogramCount(t, dbQueryDuration, "query", "ok")
+	failures := histogramCount(t, dbQueryDuration, "raw", "error")
+
+	require.NoError(t, db.Create(&widget{Name: "a"}).Error)
+	var found widget
+	require.NoError(t, db.First(&found).Error)
+	assert.ErrorIs(t, db.First(&found, 99).Error, gorm.ErrRecordNotFound)
+	assert.Error(t, db.Exec("SELECT * FROM missing_table").Error)
+
+	assert.Equal(t, creates+1, histogramCount(t, dbQueryDuration, "create", "ok"))
+	assert.Equal(t, queries+2, histogramCount(t, dbQueryDuration, "query", "ok"), "record not found is not an error")
+	assert.Equal(t, failures+1, histogramCount(t, dbQueryDuration, "raw", "error"))
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, w.Body.String(), "go_sql_max_open_connections")
+}
+
+func TestInstrumentRedis(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	defer client.Close()
+	InstrumentRedis(client)
+
+	client.Get(context.Background(), "item:1")
+	assert.Equal(t, 1, testutil.CollectAndCount(redisCommandDuration, "go_crud_app_redis_command_duration_seconds"))
+	assert.Equal(t, "error", redisStatus(assert.AnError))
+	assert.Equal(t, "ok", redisStatus(redis.Nil))
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rahulmishra/go-crud-app/config"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/services"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const testSecret = "test-secret"
+
+func newAuthRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	group := r.Group("/items", Authenticate())
+	group.GET("/", RequireRole(RoleReader, RoleEditor), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(ContextSubject))
+	})
+	group.POST("/", RequireRole(RoleEditor), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	return r
+}
+
+func signHS256(t *testing.T, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	assert.NoError(t, err)
+	return token
+}
+
+func doRequest(r *gin.Engine, method, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/items/", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthenticate_HS256Roles(t *testing.T) {
+	assert.NoError(t, ConfigureAuth(config.AuthConfig{JWTSecret: testSecret, Issuer: "issuer", Audience: "items-api", RolesClaim: "roles"}))
+	r := newAuthRouter()
+
+	valid := func(roles ...string) jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub":   "alice",
+			"iss":   "issuer",
+			"aud":   "items-api",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"roles": roles,
+		}
+	}
+
+	reader := signHS256(t, valid(RoleReader))
+	w := doRequest(r, http.MethodGet, reader)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice", w.Body.String())
+	assert.Equal(t, http.StatusForbidden, doRequest(r, http.MethodPost, reader).Code)
+
+	assert.Equal(t, http.StatusCreated, doRequest(r, http.MethodPost, signHS256(t, valid(RoleEditor))).Code)
+	assert.Equal(t, http.StatusCreated, doRequest(r, http.MethodPost, signHS256(t, valid(RoleAdmin))).Code)
+	assert.Equal(t, http.StatusForbidden, doRequest(r, http.MethodGet, signHS256(t, valid())).Code)
+
+	expired := valid(RoleReader)
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	w = doRequest(r, http.MethodGet, signHS256(t, expired))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{"error":"Invalid token"}`, w.Body.String(), "the reason is not disclosed")
+
+	wrongAudience := valid(RoleReader)
+	wrongAudience["aud"] = "someone-else"
+	assert.Equal(t, http.StatusUnauthorized, doRequest(r, http.MethodGet, signHS256(t, wrongAudience)).Code)
+
+	noExpiry := valid(RoleReader)
+	delete(noExpiry, "exp")
+	assert.Equal(t, http.StatusUnauthorized, doRequest(r, http.MethodGet, signHS256(t, noExpiry)).Code)
+
+	assert.Equal(t, http.StatusUnauthorized, doRequest(r, http.MethodGet, "").Code)
+}
+
+func TestAuthenticate_RS256FromJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	jwks, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(path, jwks, 0o600))
+
+	assert.NoError(t, ConfigureAuth(config.AuthConfig{JWKSFile: path, RolesClaim: "roles"}))
+	r := newAuthRouter()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"sub":   "batch",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": "reader",
+	})
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, doRequest(r, http.MethodGet, signed).Code)
+
+	// HS256 tokens are rejected when only a JWKS is configured.
+	assert.Equal(t, http.StatusUnauthorized, doRequest(r, http.MethodGet, signHS256(t, jwt.MapClaims{
+		"sub": "batch", "exp": time.Now().Add(time.Hour).Unix(), "roles": "reader",
+	})).Code)
+}
+
+func TestAuthenticate_APIKey(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	config.SetDB(db)
+	assert.NoError(t, db.AutoMigrate(&models.APIKey{}))
+
+	assert.ErrorContains(t, ConfigureAuth(config.AuthConfig{RolesClaim: "roles"}), "JWT_SECRET",
+		"missing JWT settings are rejected unless API keys alone are asked for")
+	assert.Error(t, ConfigureAuth(config.AuthConfig{APIKeysOnly: true, JWTSecret: testSecret}))
+	assert.NoError(t, ConfigureAuth(config.AuthConfig{APIKeysOnly: true, RolesClaim: "roles"}))
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	group := r.Group("/items", Authenticate())
+	group.GET("/", RequireScope(models.ScopeItemsRead), func(c *gin.Context) { c.Status(http.StatusOK) })
+	group.POST("/", RequireScope(models.ScopeItemsWrite), func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	_, plaintext, err := services.CreateAPIKey(context.Background(), "batch", []string{models.ScopeItemsRead}, nil)
+	assert.NoError(t, err)
+
+	send := func(method, header string) int {
+		req := httptest.NewRequest(method, "/items/", nil)
+		req.Header.Set("Authorization", header)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, "ApiKey "+plaintext))
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPost, "ApiKey "+plaintext))
+	assert.Equal(t, http.StatusUnauthorized, send(http.MethodGet, "ApiKey "+plaintext+"x"))
+	assert.Equal(t, http.StatusUnauthorized, send(http.MethodGet, "Bearer "+signHS256(t, jwt.MapClaims{
+		"sub": "alice", "exp": time.Now().Add(time.Hour).Unix(), "roles": "admin",
+	})))
+}
@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulmishra/go-crud-app/config"
+	"github.com/rahulmishra/go-crud-app/i18n"
+	"github.com/rahulmishra/go-crud-app/ratelimit"
+	"github.com/redis/go-redis/v9"
+)
+
+type rateLimits struct {
+	limiter   ratelimit.Limiter
+	perIP     ratelimit.Quota
+	perAPIKey ratelimit.Quota
+	routes    map[string]ratelimit.Quota
+}
+
+var limits *rateLimits
+
+// ConfigureRateLimit sets up RateLimit. Limits are shared through client when
+// it is reachable and fall back to per-process limits when it is not.
+func ConfigureRateLimit(cfg config.RateLimitConfig, client redis.Cmdable) error {
+	if !cfg.Enabled {
+		limits = nil
+		return nil
+	}
+
+	l := &rateLimits{routes: make(map[string]ratelimit.Quota)}
+	var err error
+	if l.perIP, err = ratelimit.ParseQuota(cfg.PerIP); err != nil {
+		return err
+	}
+	if l.perAPIKey, err = ratelimit.ParseQuota(cfg.PerAPIKey); err != nil {
+		return err
+	}
+	for _, entry := range cfg.Routes {
+		group, quota, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("ratelimit: invalid route quota %q", entry)
+		}
+		if l.routes[group], err = ratelimit.ParseQuota(quota); err != nil {
+			return err
+		}
+	}
+
+	memory := ratelimit.NewMemoryLimiter()
+	if client == nil {
+		l.limiter = memory
+	} else {
+		l.limiter = &ratelimit.FallbackLimiter{
+			Primary:  ratelimit.NewRedisLimiter(client, "ratelimit:"),
+			Fallback: memory,
+			Cooldown: rateLimitCooldown,
+			OnError: func(err error) {
+				slog.Warn("Rate limiter falling back to in-memory limits", "retry_every", rateLimitCooldown, "error", err)
+			},
+			OnRecover: func() {
+				slog.Info("Rate limiter using Redis again")
+			},
+		}
+	}
+	limits = l
+	return nil
+}
+
+// rateLimitCooldown is how long the limiter stays on in-memory limits after
+// Redis fails before trying it again.
+const rateLimitCooldown = 5 * time.Second
+
+// contextRateLimit holds the tightest result seen so far, so the headers
+// describe the quota closest to running out across RateLimitIP and
+// RateLimit.
+const contextRateLimit = "ratelimit"
+
+type rateLimitCheck struct {
+	key   string
+	quota ratelimit.Quota
+}
+
+// RateLimitIP enforces the per-IP quota on callers without an API key. It
+// runs before Authenticate, so requests with missing or invalid credentials
+// are limited before they cost a token check or an API key hash.
+//
+// API key callers are held to the API key quota instead, so that a key used
+// from one address can reach it. Only a failed key check counts against the
+// IP, and an IP that has used up its quota that way is turned away before
+// the next check.
+func RateLimitIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := limits
+		if l == nil {
+			c.Next()
+			return
+		}
+		check := rateLimitCheck{"ip:" + c.ClientIP(), l.perIP}
+		if !presentsAPIKey(c) {
+			if l.enforce(c, check) {
+				c.Next()
+			}
+			return
+		}
+
+		if result, err := l.limiter.Peek(c.Request.Context(), check.key, check.quota); err == nil && !result.Allowed {
+			rejectRateLimited(c, result)
+			return
+		}
+		c.Next()
+		if GetPrincipal(c) == nil {
+			l.limiter.Allow(c.Request.Context(), check.key, check.quota)
+		}
+	}
+}
+
+// presentsAPIKey reports whether the request carries credentials for the
+// ApiKey scheme, valid or not.
+func presentsAPIKey(c *gin.Context) bool {
+	scheme, credentials, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+	return credentials != "" && strings.EqualFold(scheme, "ApiKey")
+}
+
+// RateLimit enforces the API key quota and the quota of route group. It
+// runs after Authenticate so API key callers are recognised; callers without
+// a key are limited per IP by RateLimitIP.
+func RateLimit(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := limits
+		if l == nil {
+			c.Next()
+			return
+		}
+
+		client := "ip:" + c.ClientIP()
+		var checks []rateLimitCheck
+		if principal := GetPrincipal(c); principal != nil && principal.Method == AuthMethodAPIKey {
+			client = principal.Subject
+			checks = append(checks, rateLimitCheck{client, l.perAPIKey})
+		}
+		if routeQuota, ok := l.routes[group]; ok {
+			checks = append(checks, rateLimitCheck{client + ":" + group, routeQuota})
+		}
+		if l.enforce(c, checks...) {
+			c.Next()
+		}
+	}
+}
+
+// enforce runs checks and aborts the request with 429 if one is exhausted.
+// It reports whether the request may continue.
+func (l *rateLimits) enforce(c *gin.Context, checks ...rateLimitCheck) bool {
+	var tightest *ratelimit.Result
+	if previous, ok := c.Get(contextRateLimit); ok {
+		tightest = previous.(*ratelimit.Result)
+	}
+	for _, chk := range checks {
+		result, err := l.limiter.Allow(c.Request.Context(), chk.key, chk.quota)
+		if err != nil {
+			continue
+		}
+		if !result.Allowed {
+			rejectRateLimited(c, result)
+			return false
+		}
+		if tightest == nil || result.Remaining < tightest.Remaining {
+			tightest = &result
+		}
+	}
+	if tightest != nil {
+		c.Set(contextRateLimit, tightest)
+		setRateLimitHeaders(c, *tightest)
+	}
+	return true
+}
+
+// rejectRateLimited aborts the request with 429 and tells the client when to
+// retry.
+func rejectRateLimited(c *gin.Context, result ratelimit.Result) {
+	setRateLimitHeaders(c, result)
+	c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": i18n.T(c, i18n.MsgRateLimited)})
+}
+
+func setRateLimitHeaders(c *gin.Context, result ratelimit.Result) {
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
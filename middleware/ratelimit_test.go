@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulmishra/go-crud-app/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit_PerRouteQuota(t *testing.T) {
+	assert.NoError(t, ConfigureRateLimit(config.RateLimitConfig{
+		Enabled:   true,
+		PerIP:     "100/1m",
+		PerAPIKey: "100/1m",
+		Routes:    []string{"items=2/1m"},
+	}, nil))
+	defer ConfigureRateLimit(config.RateLimitConfig{}, nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/items/", RateLimitIP(), RateLimit("items"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/other", RateLimitIP(), RateLimit("other"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "203.0.113.7:4000"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/items/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+
+	assert.Equal(t, http.StatusOK, get("/items/").Code)
+
+	w = get("/items/")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+	w = get("/other")
+	assert.Equal(t, http.StatusOK, w.Code, "other groups only count against the per-IP quota")
+	assert.Equal(t, "100", w.Header().Get("RateLimit-Limit"))
+}
+
+// Requests that fail authentication still count against the per-IP quota.
+func TestRateLimitIP_BeforeAuthentication(t *testing.T) {
+	assert.NoError(t, ConfigureAuth(config.AuthConfig{JWTSecret: testSecret, RolesClaim: "roles"}))
+	assert.NoError(t, ConfigureRateLimit(config.RateLimitConfig{Enabled: true, PerIP: "2/1m", PerAPIKey: "100/1m"}, nil))
+	defer ConfigureRateLimit(config.RateLimitConfig{}, nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/items/", RateLimitIP(), Authenticate(), RateLimit("items"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/items/", nil)
+		req.RemoteAddr = "203.0.113.8:4000"
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, get("forged"))
+	assert.Equal(t, http.StatusUnauthorized, get("forged"))
+	assert.Equal(t, http.StatusTooManyRequests, get("forged"))
+}
+
+func TestRateLimit_InvalidConfig(t *testing.T) {
+	assert.Error(t, ConfigureRateLimit(config.RateLimitConfig{Enabled: true, PerIP: "lots", PerAPIKey: "1/1s"}, nil))
+	assert.Error(t, ConfigureRateLimit(config.RateLimitConfig{Enabled: true, PerIP: "1/1s", PerAPIKey: "1/1s", Routes: []string{"items"}}, nil))
+}
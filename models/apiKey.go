@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ScopeItemsRead  = "items:read"
+	ScopeItemsWrite = "items:write"
+	// ScopeAdmin is only granted through the admin role, never to API keys.
+	ScopeAdmin = "admin"
+)
+
+// KnownScopes lists the scopes an API key may be granted.
+var KnownScopes = []string{ScopeItemsRead, ScopeItemsWrite}
+
+// APIKey is a long-lived credential. Only a hash of the secret part is
+// stored; Prefix identifies the key for lookup and display.
+type APIKey struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Name       string     `gorm:"not null" json:"name"`
+	Prefix     string     `gorm:"uniqueIndex;not null" json:"prefix"`
+	Hash       string     `gorm:"not null" json:"-"`
+	Scopes     []string   `gorm:"serializer:json" json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (key *APIKey) BeforeCreate(tx *gorm.DB) (err error) {
+	if key.ID == (uuid.UUID{}) {
+		key.ID = uuid.New()
+	}
+	return
+}
+
+// Active reports whether the key can still be used at time now.
+func (key *APIKey) Active(now time.Time) bool {
+	if key.RevokedAt != nil {
+		return false
+	}
+	return key.ExpiresAt == nil || now.Before(*key.ExpiresAt)
+}
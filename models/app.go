@@ -1,14 +1,25 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// CacheSchemaVersion is stamped on every cached Item. Bump it whenever the
+// shape of Item changes so stale cache entries are treated as misses.
+const CacheSchemaVersion = 2
+
+// Item validation rules live in the binding tags and are enforced both by
+// the gin binder and by validation.Struct in the service layer.
 type Item struct {
 	ID    uuid.UUID `gorm:"type:uuid;primaryKey"`
-	Name  string    `json:"name"`
-	Price float64   `json:"price"`
+	Name  string    `json:"name" binding:"required,itemname,max=200"`
+	Price float64   `json:"price" binding:"required,gt=0,lte=1000000000,precision=2"`
+	// OwnerID is the subject that created the item. It is set by the server
+	// and empty for items created before ownership was tracked.
+	OwnerID string `gorm:"index" json:"owner_id"`
 }
 
 func (item *Item) BeforeCreate(tx *gorm.DB) (err error) {
@@ -17,3 +28,32 @@ func (item *Item) BeforeCreate(tx *gorm.DB) (err error) {
 	}
 	return
 }
+
+const (
+	GranteeUser  = "user"
+	GranteeGroup = "group"
+)
+
+const (
+	PermissionRead  = "read"
+	PermissionWrite = "write"
+)
+
+// ItemShare grants a user or group access to an item they do not own. Write
+// access implies read access.
+type ItemShare struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	ItemID      uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_item_share_grantee;not null" json:"item_id"`
+	GranteeType string    `gorm:"uniqueIndex:idx_item_share_grantee;not null" json:"grantee_type"`
+	GranteeID   string    `gorm:"uniqueIndex:idx_item_share_grantee;index:idx_item_share_lookup;not null" json:"grantee_id"`
+	Permission  string    `gorm:"not null" json:"permission"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (share *ItemShare) BeforeCreate(tx *gorm.DB) (err error) {
+	if share.ID == (uuid.UUID{}) {
+		share.ID = uuid.New()
+	}
+	return
+}
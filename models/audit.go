@@ -0,0 +1,62 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	AuditOperationCreate = "create"
+	AuditOperationUpdate = "update"
+	AuditOperationDelete = "delete"
+	AuditOperationImport = "import"
+)
+
+var ErrAuditAppendOnly = errors.New("audit records are append-only")
+
+// AuditRecord captures one item mutation. Records form a hash chain: each
+// Hash covers the record's fields and the Hash of the record before it, so
+// editing or removing a record breaks every hash after it.
+type AuditRecord struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	ItemID    uuid.UUID `gorm:"type:uuid;index" json:"item_id"`
+	Actor     string    `gorm:"index" json:"actor"`
+	RequestID string    `json:"request_id"`
+	Operation string    `json:"operation"`
+	Before    JSON      `gorm:"type:text" json:"before"`
+	After     JSON      `gorm:"type:text" json:"after"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `gorm:"uniqueIndex" json:"hash"`
+}
+
+// ComputeHash returns the chain hash of the record given its PrevHash.
+func (r *AuditRecord) ComputeHash() string {
+	fields := []string{
+		r.PrevHash,
+		r.ItemID.String(),
+		r.Actor,
+		r.RequestID,
+		r.Operation,
+		string(r.Before),
+		string(r.After),
+		strconv.FormatInt(r.CreatedAt.UTC().UnixMicro(), 10),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
+
+func (r *AuditRecord) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditAppendOnly
+}
+
+func (r *AuditRecord) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditAppendOnly
+}
@@ -0,0 +1,116 @@
+package policy
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/rahulmishra/go-crud-app/models"
+)
+
+// DefaultPolicy is used when no policy file is configured. It grants item
+// access purely by scope.
+var DefaultPolicy = &Policy{Rules: []Rule{
+	{Name: "scope-read", Effect: EffectAllow, Actions: []string{ActionRead}, Scopes: []string{models.ScopeItemsRead}},
+	{Name: "scope-write", Effect: EffectAllow, Actions: []string{ActionCreate, ActionUpdate, ActionDelete}, Scopes: []string{models.ScopeItemsWrite}},
+}}
+
+// ErrNotConfigured is returned by Reload when no policy file is configured.
+var ErrNotConfigured = errors.New("policy: no policy file configured")
+
+var (
+	current    atomic.Pointer[Policy]
+	policyPath string
+	reloadMu   sync.Mutex
+)
+
+func init() {
+	current.Store(DefaultPolicy)
+}
+
+// Configure loads the policy at path, or restores DefaultPolicy when path is
+// empty.
+func Configure(path string) error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	policyPath = path
+	if path == "" {
+		current.Store(DefaultPolicy)
+		return nil
+	}
+	p, err := LoadFile(path)
+	if err != nil {
+		return err
+	}
+	current.Store(p)
+	return nil
+}
+
+// Reload re-reads the configured policy file. The active policy is left in
+// place if the file cannot be loaded.
+func Reload() error {
+	reloadMu.Lock()
+	path := policyPath
+	reloadMu.Unlock()
+	if path == "" {
+		return ErrNotConfigured
+	}
+	return Configure(path)
+}
+
+// ReloadOnSIGHUP reloads the policy file whenever the process receives
+// SIGHUP.
+func ReloadOnSIGHUP() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			if err := Reload(); err != nil {
+				slog.Error("Policy reload failed", "error", err)
+			} else {
+				slog.Info("Policy reloaded", "path", policyPath)
+			}
+		}
+	}()
+}
+
+// Current returns the active policy.
+func Current() *Policy {
+	return current.Load()
+}
+
+// Authorize evaluates action on item with the active policy. input carries
+// the proposed values for create and update and may be nil.
+func Authorize(subject Subject, action string, item, input *models.Item) Decision {
+	return Current().Evaluate(subject, action, ItemAttributes(item), ItemAttributes(input))
+}
+
+// FilterReadable returns the items subject is allowed to read.
+func FilterReadable(subject Subject, items []models.Item) []models.Item {
+	p := Current()
+	readable := make([]models.Item, 0, len(items))
+	for i := range items {
+		if p.Evaluate(subject, ActionRead, ItemAttributes(&items[i]), nil).Allowed {
+			readable = append(readable, items[i])
+		}
+	}
+	return readable
+}
+
+// ItemAttributes exposes the fields of item that rules can refer to.
+func ItemAttributes(item *models.Item) map[string]interface{} {
+	if item == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"id":       item.ID.String(),
+		"name":     item.Name,
+		"price":    item.Price,
+		"owner_id": item.OwnerID,
+	}
+}
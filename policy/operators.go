@@ -0,0 +1,71 @@
+package policy
+
+import (
+	"fmt"
+	"slices"
+)
+
+var operators = map[string]func(left, right interface{}) bool{
+	"eq":  func(l, r interface{}) bool { return equal(l, r) },
+	"ne":  func(l, r interface{}) bool { return !equal(l, r) },
+	"lt":  numeric(func(l, r float64) bool { return l < r }),
+	"lte": numeric(func(l, r float64) bool { return l <= r }),
+	"gt":  numeric(func(l, r float64) bool { return l > r }),
+	"gte": numeric(func(l, r float64) bool { return l >= r }),
+	"in": func(l, r interface{}) bool {
+		return slices.ContainsFunc(toSlice(r), func(v interface{}) bool { return equal(l, v) })
+	},
+	"contains": func(l, r interface{}) bool {
+		return slices.ContainsFunc(toSlice(l), func(v interface{}) bool { return equal(v, r) })
+	},
+	"exists": nil, // handled in Condition.holds
+}
+
+func numeric(cmp func(l, r float64) bool) func(l, r interface{}) bool {
+	return func(l, r interface{}) bool {
+		lf, lok := toFloat(l)
+		rf, rok := toFloat(r)
+		return lok && rok && cmp(lf, rf)
+	}
+}
+
+func equal(l, r interface{}) bool {
+	lf, lok := toFloat(l)
+	rf, rok := toFloat(r)
+	if lok && rok {
+		return lf == rf
+	}
+	return fmt.Sprint(l) == fmt.Sprint(r)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+func toSlice(v interface{}) []interface{} {
+	switch s := v.(type) {
+	case []interface{}:
+		return s
+	case []string:
+		out := make([]interface{}, len(s))
+		for i, str := range s {
+			out[i] = str
+		}
+		return out
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,206 @@
+// Package policy decides whether a caller may perform an action on an item.
+// Rules are loaded from a YAML or JSON file and can be reloaded at runtime.
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ActionCreate = "create"
+	ActionRead   = "read"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// Subject is the caller being authorized.
+type Subject struct {
+	ID     string
+	Roles  []string
+	Scopes []string
+}
+
+// Condition compares the value at Field with Value. Field and string values
+// starting with "$" are dotted paths into the request attributes, for example
+// "resource.price" or "$subject.id".
+type Condition struct {
+	Field string      `yaml:"field" json:"field"`
+	Op    string      `yaml:"op" json:"op"`
+	Value interface{} `yaml:"value" json:"value"`
+}
+
+type Rule struct {
+	Name    string   `yaml:"name" json:"name"`
+	Effect  string   `yaml:"effect" json:"effect"`
+	Actions []string `yaml:"actions" json:"actions"`
+	// Roles and Scopes restrict the rule to subjects holding at least one of
+	// them. Empty means any subject.
+	Roles      []string    `yaml:"roles" json:"roles"`
+	Scopes     []string    `yaml:"scopes" json:"scopes"`
+	Conditions []Condition `yaml:"conditions" json:"conditions"`
+	// Reason is reported when a deny rule matches.
+	Reason string `yaml:"reason" json:"reason"`
+}
+
+type Policy struct {
+	Rules []Rule `yaml:"rules" json:"rules"`
+}
+
+// Decision is the outcome of an authorization check.
+type Decision struct {
+	Allowed bool
+	Rule    string
+	Reason  string
+}
+
+// LoadFile parses a policy from a .yaml, .yml or .json file. JSON is a subset
+// of YAML, so both go through the YAML decoder.
+func LoadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("policy: parsing %s: %w", filepath.Base(path), err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Fingerprint identifies the rules of p, so results computed under one
+// policy can be cached without being served under another.
+func (p *Policy) Fingerprint() string {
+	data, err := json.Marshal(p)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%#v", p))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+func (p *Policy) Validate() error {
+	for i, rule := range p.Rules {
+		if rule.Effect != EffectAllow && rule.Effect != EffectDeny {
+			return fmt.Errorf("policy: rule %d (%s): effect must be allow or deny", i, rule.Name)
+		}
+		if len(rule.Actions) == 0 {
+			return fmt.Errorf("policy: rule %d (%s): at least one action is required", i, rule.Name)
+		}
+		for _, cond := range rule.Conditions {
+			if _, ok := operators[cond.Op]; !ok {
+				return fmt.Errorf("policy: rule %d (%s): unknown operator %q", i, rule.Name, cond.Op)
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate checks action against the rules. A matching deny rule always
+// wins; otherwise the action is allowed if any allow rule matches.
+func (p *Policy) Evaluate(subject Subject, action string, resource, input map[string]interface{}) Decision {
+	attrs := map[string]interface{}{
+		"subject": map[string]interface{}{
+			"id":     subject.ID,
+			"roles":  subject.Roles,
+			"scopes": subject.Scopes,
+		},
+		"resource": resource,
+		"input":    input,
+	}
+
+	var allowedBy string
+	for _, rule := range p.Rules {
+		if !rule.matches(subject, action, attrs) {
+			continue
+		}
+		if rule.Effect == EffectDeny {
+			reason := rule.Reason
+			if reason == "" {
+				reason = fmt.Sprintf("denied by rule %q", rule.Name)
+			}
+			return Decision{Allowed: false, Rule: rule.Name, Reason: reason}
+		}
+		if allowedBy == "" {
+			allowedBy = rule.Name
+		}
+	}
+	if allowedBy != "" {
+		return Decision{Allowed: true, Rule: allowedBy}
+	}
+	return Decision{Allowed: false, Reason: fmt.Sprintf("no rule allows %s", action)}
+}
+
+func (rule *Rule) matches(subject Subject, action string, attrs map[string]interface{}) bool {
+	if !slices.Contains(rule.Actions, action) && !slices.Contains(rule.Actions, "*") {
+		return false
+	}
+	if len(rule.Roles) > 0 && !overlaps(rule.Roles, subject.Roles) {
+		return false
+	}
+	if len(rule.Scopes) > 0 && !overlaps(rule.Scopes, subject.Scopes) {
+		return false
+	}
+	for _, cond := range rule.Conditions {
+		if !cond.holds(attrs) {
+			return false
+		}
+	}
+	return true
+}
+
+func (cond *Condition) holds(attrs map[string]interface{}) bool {
+	left, ok := lookup(attrs, cond.Field)
+	if cond.Op == "exists" {
+		return ok && left != nil && left != ""
+	}
+	if !ok {
+		return false
+	}
+	right := cond.Value
+	if ref, isRef := right.(string); isRef && strings.HasPrefix(ref, "$") {
+		if right, ok = lookup(attrs, ref[1:]); !ok {
+			return false
+		}
+	}
+	return operators[cond.Op](left, right)
+}
+
+func lookup(attrs map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = attrs
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func overlaps(a, b []string) bool {
+	for _, v := range a {
+		if slices.Contains(b, v) {
+			return true
+		}
+	}
+	return false
+}
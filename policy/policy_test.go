@@ -0,0 +1,104 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const testPolicy = `
+rules:
+  - name: readers
+    effect: allow
+    actions: [read]
+    roles: [reader, editor]
+  - name: editors-cheap-items
+    effect: allow
+    actions: [update]
+    roles: [editor]
+    conditions:
+      - field: input.price
+        op: lt
+        value: 1000
+  - name: hidden
+    effect: deny
+    actions: [read]
+    conditions:
+      - field: resource.name
+        op: eq
+        value: secret
+    reason: item is hidden
+`
+
+func writePolicy(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func TestEvaluate(t *testing.T) {
+	assert.NoError(t, Configure(writePolicy(t, testPolicy)))
+	defer Configure("")
+
+	editor := Subject{ID: "alice", Roles: []string{"editor"}}
+	reader := Subject{ID: "bob", Roles: []string{"reader"}}
+	item := &models.Item{ID: uuid.New(), Name: "Laptop", Price: 900}
+
+	assert.True(t, Authorize(editor, ActionUpdate, item, &models.Item{Price: 999}).Allowed)
+
+	decision := Authorize(editor, ActionUpdate, item, &models.Item{Price: 1500})
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, "no rule allows update", decision.Reason)
+
+	assert.False(t, Authorize(reader, ActionUpdate, item, &models.Item{Price: 10}).Allowed)
+
+	decision = Authorize(reader, ActionRead, &models.Item{Name: "secret"}, nil)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, "item is hidden", decision.Reason)
+
+	items := []models.Item{{Name: "a"}, {Name: "secret"}, {Name: "b"}}
+	assert.Len(t, FilterReadable(reader, items), 2)
+	assert.Empty(t, FilterReadable(Subject{ID: "nobody"}, items))
+}
+
+func TestSubjectReferences(t *testing.T) {
+	p := &Policy{Rules: []Rule{{
+		Name:       "self",
+		Effect:     EffectAllow,
+		Actions:    []string{ActionDelete},
+		Conditions: []Condition{{Field: "resource.name", Op: "eq", Value: "$subject.id"}},
+	}}}
+	assert.NoError(t, p.Validate())
+
+	assert.True(t, p.Evaluate(Subject{ID: "alice"}, ActionDelete, map[string]interface{}{"name": "alice"}, nil).Allowed)
+	assert.False(t, p.Evaluate(Subject{ID: "bob"}, ActionDelete, map[string]interface{}{"name": "alice"}, nil).Allowed)
+}
+
+func TestReloadKeepsPolicyOnError(t *testing.T) {
+	path := writePolicy(t, testPolicy)
+	assert.NoError(t, Configure(path))
+	defer Configure("")
+	before := Current()
+
+	assert.NoError(t, os.WriteFile(path, []byte("rules:\n  - name: bad\n    effect: maybe\n    actions: [read]\n"), 0o600))
+	assert.Error(t, Reload())
+	assert.Same(t, before, Current())
+}
+
+func TestDefaultPolicyUsesScopes(t *testing.T) {
+	assert.NoError(t, Configure(""))
+	item := &models.Item{Name: "x", Price: 1}
+
+	assert.True(t, Authorize(Subject{Scopes: []string{models.ScopeItemsRead}}, ActionRead, item, nil).Allowed)
+	assert.False(t, Authorize(Subject{Scopes: []string{models.ScopeItemsRead}}, ActionDelete, item, nil).Allowed)
+	assert.True(t, Authorize(Subject{Scopes: []string{models.ScopeItemsWrite}}, ActionDelete, item, nil).Allowed)
+}
+
+func TestExamplePolicyIsValid(t *testing.T) {
+	_, err := LoadFile("../config/policy.example.yaml")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLimiter keeps state in process. It is used when Redis is unreachable,
+// so limits are enforced per replica rather than globally.
+type MemoryLimiter struct {
+	mu    sync.Mutex
+	tats  map[string]time.Time
+	now   func() time.Time
+	sweep time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{tats: make(map[string]time.Time), now: time.Now}
+}
+
+func (l *MemoryLimiter) Allow(_ context.Context, key string, q Quota) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.evictExpired(now)
+
+	tat, result := gcra(now, l.tats[key], q)
+	l.tats[key] = tat
+	return result, nil
+}
+
+func (l *MemoryLimiter) Peek(_ context.Context, key string, q Quota) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, result := gcra(l.now(), l.tats[key], q)
+	return result, nil
+}
+
+// evictExpired drops keys whose arrival time has passed, at most once a
+// minute.
+func (l *MemoryLimiter) evictExpired(now time.Time) {
+	if now.Sub(l.sweep) < time.Minute {
+		return
+	}
+	l.sweep = now
+	for key, tat := range l.tats {
+		if tat.Before(now) {
+			delete(l.tats, key)
+		}
+	}
+}
+
+// FallbackLimiter uses primary and switches to fallback for any check where
+// primary returns an error, so an outage fails open to local limits. After a
+// failure, checks skip primary for Cooldown, so an outage does not add a
+// connection timeout to every request.
+type FallbackLimiter struct {
+	Primary  Limiter
+	Fallback Limiter
+	Cooldown time.Duration
+	// OnError is called when primary starts failing and OnRecover when it
+	// answers again, rather than on every check.
+	OnError   func(error)
+	OnRecover func()
+
+	mu      sync.Mutex
+	down    bool
+	retryAt time.Time
+	now     func() time.Time
+}
+
+func (l *FallbackLimiter) Allow(ctx context.Context, key string, q Quota) (Result, error) {
+	return l.check(func(limiter Limiter) (Result, error) { return limiter.Allow(ctx, key, q) })
+}
+
+func (l *FallbackLimiter) Peek(ctx context.Context, key string, q Quota) (Result, error) {
+	return l.check(func(limiter Limiter) (Result, error) { return limiter.Peek(ctx, key, q) })
+}
+
+// check runs fn against primary, or against fallback while primary is down.
+func (l *FallbackLimiter) check(fn func(Limiter) (Result, error)) (Result, error) {
+	l.mu.Lock()
+	skip := l.down && l.clock().Before(l.retryAt)
+	l.mu.Unlock()
+	if skip {
+		return fn(l.Fallback)
+	}
+
+	result, err := fn(l.Primary)
+	l.mu.Lock()
+	wasDown := l.down
+	l.down = err != nil
+	if err != nil {
+		l.retryAt = l.clock().Add(l.Cooldown)
+	}
+	l.mu.Unlock()
+
+	if err == nil {
+		if wasDown && l.OnRecover != nil {
+			l.OnRecover()
+		}
+		return result, nil
+	}
+	if !wasDown && l.OnError != nil {
+		l.OnError(err)
+	}
+	return fn(l.Fallback)
+}
+
+func (l *FallbackLimiter) clock() time.Time {
+	if l.now != nil {
+		return l.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,95 @@
+// Package ratelimit implements the generic cell rate algorithm (GCRA) on top
+// of Redis, with an in-memory implementation used as a fallback.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Quota allows Limit requests per Period, with bursts of up to Burst requests.
+type Quota struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// ParseQuota parses "<limit>/<period>[:<burst>]", for example "100/1m" or
+// "10/1s:20". Burst defaults to the limit.
+func ParseQuota(s string) (Quota, error) {
+	rate, burst, hasBurst := strings.Cut(strings.TrimSpace(s), ":")
+	limitStr, periodStr, ok := strings.Cut(rate, "/")
+	if !ok {
+		return Quota{}, fmt.Errorf("ratelimit: invalid quota %q", s)
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 {
+		return Quota{}, fmt.Errorf("ratelimit: invalid limit in %q", s)
+	}
+	period, err := time.ParseDuration(periodStr)
+	if err != nil || period <= 0 {
+		return Quota{}, fmt.Errorf("ratelimit: invalid period in %q", s)
+	}
+	q := Quota{Limit: limit, Period: period, Burst: limit}
+	if hasBurst {
+		if q.Burst, err = strconv.Atoi(burst); err != nil || q.Burst <= 0 {
+			return Quota{}, fmt.Errorf("ratelimit: invalid burst in %q", s)
+		}
+	}
+	return q, nil
+}
+
+// emissionInterval is the time between requests at a steady rate.
+func (q Quota) emissionInterval() time.Duration {
+	return q.Period / time.Duration(q.Limit)
+}
+
+// Result describes the outcome of a single check.
+type Result struct {
+	Allowed bool
+	Limit   int
+	// Remaining is the number of requests that could be made right now.
+	Remaining int
+	// RetryAfter is how long to wait before the next request is allowed. It
+	// is zero when the request was allowed.
+	RetryAfter time.Duration
+	// ResetAfter is how long until the client is back to a full burst.
+	ResetAfter time.Duration
+}
+
+type Limiter interface {
+	// Allow counts a request against key and reports whether it may go
+	// ahead.
+	Allow(ctx context.Context, key string, q Quota) (Result, error)
+	// Peek reports what Allow would return for key without counting a
+	// request.
+	Peek(ctx context.Context, key string, q Quota) (Result, error)
+}
+
+// gcra applies one request to the theoretical arrival time tat and returns
+// the new tat together with the result. It is shared by the in-memory
+// limiter and mirrored by the Redis script.
+func gcra(now, tat time.Time, q Quota) (time.Time, Result) {
+	emission := q.emissionInterval()
+	tolerance := emission * time.Duration(q.Burst)
+
+	if tat.Before(now) {
+		tat = now
+	}
+	newTAT := tat.Add(emission)
+	allowAt := newTAT.Add(-tolerance)
+
+	diff := now.Sub(allowAt)
+	if diff < 0 {
+		return tat, Result{Limit: q.Limit, RetryAfter: -diff, ResetAfter: tat.Sub(now)}
+	}
+	return newTAT, Result{
+		Allowed:    true,
+		Limit:      q.Limit,
+		Remaining:  int(diff / emission),
+		ResetAfter: newTAT.Sub(now),
+	}
+}
@@ -0,0 +1,152 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rahulmishra/go-crud-app/testutil/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuota(t *testing.T) {
+	q, err := ParseQuota("100/1m")
+	assert.NoError(t, err)
+	assert.Equal(t, Quota{Limit: 100, Period: time.Minute, Burst: 100}, q)
+
+	q, err = ParseQuota("10/1s:20")
+	assert.NoError(t, err)
+	assert.Equal(t, 20, q.Burst)
+
+	for _, bad := range []string{"", "100", "0/1m", "10/forever", "10/1s:x"} {
+		_, err := ParseQuota(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestMemoryLimiter_BurstThenSteadyRate(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := NewMemoryLimiter()
+	l.now = func() time.Time { return now }
+	q := Quota{Limit: 10, Period: time.Second, Burst: 3}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		result, _ := l.Allow(ctx, "k", q)
+		assert.True(t, result.Allowed, "request %d within burst", i)
+		assert.Equal(t, 2-i, result.Remaining)
+	}
+
+	result, _ := l.Allow(ctx, "k", q)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 100*time.Millisecond, result.RetryAfter)
+
+	result, _ = l.Allow(ctx, "other", q)
+	assert.True(t, result.Allowed, "keys are limited independently")
+
+	now = now.Add(100 * time.Millisecond)
+	result, _ = l.Allow(ctx, "k", q)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+}
+
+type failingLimiter struct{ calls, failures int }
+
+func (l *failingLimiter) Allow(context.Context, string, Quota) (Result, error) {
+	l.calls++
+	if l.failures > 0 {
+		l.failures--
+		return Result{}, errors.New("connection refused")
+	}
+	return Result{Allowed: true, Limit: 1}, nil
+}
+
+func (l *failingLimiter) Peek(ctx context.Context, key string, q Quota) (Result, error) {
+	return l.Allow(ctx, key, q)
+}
+
+func TestFallbackLimiter_FailsOpenToMemory(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	primary := &failingLimiter{failures: 2}
+	var failures, recoveries int
+	l := &FallbackLimiter{
+		Primary:   primary,
+		Fallback:  NewMemoryLimiter(),
+		Cooldown:  5 * time.Second,
+		OnError:   func(error) { failures++ },
+		OnRecover: func() { recoveries++ },
+		now:       func() time.Time { return now },
+	}
+	q := Quota{Limit: 1, Period: time.Hour, Burst: 1}
+	ctx := context.Background()
+
+	result, err := l.Allow(ctx, "k", q)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, _ = l.Allow(ctx, "k", q)
+	assert.False(t, result.Allowed, "the fallback still enforces the quota")
+	assert.Equal(t, 1, primary.calls, "primary is skipped during the cooldown")
+
+	now = now.Add(5 * time.Second)
+	l.Allow(ctx, "k", q)
+	assert.Equal(t, 2, primary.calls, "primary is retried after the cooldown")
+	assert.Equal(t, 1, failures, "an ongoing outage is reported once")
+
+	now = now.Add(5 * time.Second)
+	result, _ = l.Allow(ctx, "k", q)
+	assert.True(t, result.Allowed)
+	l.Allow(ctx, "k", q)
+	assert.Equal(t, 4, primary.calls)
+	assert.Equal(t, 1, recoveries)
+}
+
+func TestRedisLimiter_RunsScriptAgainstRedis(t *testing.T) {
+	server := redistest.Run(t)
+	client := server.NewClient()
+	defer client.Close()
+	l := NewRedisLimiter(client, "rl:")
+	q := Quota{Limit: 1, Period: time.Hour, Burst: 3}
+	ctx := context.Background()
+
+	result, err := l.Peek(ctx, "k", q)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+	assert.False(t, server.Has("rl:k"), "peeking does not count a request")
+
+	for i := 0; i < 3; i++ {
+		result, err := l.Allow(ctx, "k", q)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed, "request %d within burst", i)
+		assert.Equal(t, 2-i, result.Remaining)
+	}
+
+	result, err = l.Peek(ctx, "k", q)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed, "the burst is used up")
+
+	result, err = l.Allow(ctx, "k", q)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.InDelta(t, time.Hour, result.RetryAfter, float64(time.Second))
+	assert.InDelta(t, 3*time.Hour, server.TTL("rl:k"), float64(time.Second), "the state expires once the burst refills")
+
+	result, _ = l.Allow(ctx, "other", q)
+	assert.True(t, result.Allowed, "keys are limited independently")
+}
+
+func TestMemoryLimiter_Peek(t *testing.T) {
+	l := NewMemoryLimiter()
+	q := Quota{Limit: 1, Period: time.Hour, Burst: 1}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		result, _ := l.Peek(ctx, "k", q)
+		assert.True(t, result.Allowed, "peeking does not count a request")
+	}
+	l.Allow(ctx, "k", q)
+	result, _ := l.Peek(ctx, "k", q)
+	assert.False(t, result.Allowed)
+}
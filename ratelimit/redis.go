@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// gcraScript stores the theoretical arrival time, in milliseconds, under
+// KEYS[1]. The caller supplies the current time so the script stays
+// deterministic.
+//
+// ARGV: emission interval (ms), burst, now (ms)
+// Returns: {allowed, remaining, retry_after_ms, reset_after_ms}
+var gcraScript = redis.NewScript(`
+local emission = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local tolerance = emission * burst
+
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if not tat or tat < now then
+  tat = now
+end
+
+local new_tat = tat + emission
+local diff = now - (new_tat - tolerance)
+if diff < 0 then
+  return {0, 0, -diff, tat - now}
+end
+
+redis.call("SET", KEYS[1], new_tat, "PX", new_tat - now)
+return {1, math.floor(diff / emission), 0, new_tat - now}
+`)
+
+type RedisLimiter struct {
+	client redis.Cmdable
+	prefix string
+}
+
+func NewRedisLimiter(client redis.Cmdable, prefix string) *RedisLimiter {
+	return &RedisLimiter{client: client, prefix: prefix}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, q Quota) (Result, error) {
+	emission := q.emissionInterval().Milliseconds()
+	if emission < 1 {
+		emission = 1
+	}
+	now := time.Now().UnixMilli()
+
+	values, err := gcraScript.Run(ctx, l.client, []string{l.prefix + key}, emission, q.Burst, now).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      q.Limit,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
+
+func (l *RedisLimiter) Peek(ctx context.Context, key string, q Quota) (Result, error) {
+	now := time.Now()
+	var tat time.Time
+	ms, err := l.client.Get(ctx, l.prefix+key).Int64()
+	switch {
+	case err == nil:
+		tat = time.UnixMilli(ms)
+	case !errors.Is(err, redis.Nil):
+		return Result{}, err
+	}
+	_, result := gcra(now, tat, q)
+	return result, nil
+}
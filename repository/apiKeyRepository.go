@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/models"
+	"gorm.io/gorm"
+)
+
+func CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	return db(ctx).Create(key).Error
+}
+
+func GetAPIKeyByPrefix(ctx context.Context, prefix string, key *models.APIKey) error {
+	return db(ctx).Where("prefix = ?", prefix).First(key).Error
+}
+
+func ListAPIKeys(ctx context.Context, keys *[]models.APIKey) error {
+	return db(ctx).Order("created_at").Find(keys).Error
+}
+
+// RevokeAPIKey marks the key as revoked. It returns gorm.ErrRecordNotFound
+// when no active key has the given ID.
+func RevokeAPIKey(ctx context.Context, id uuid.UUID, at time.Time) error {
+	result := db(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func TouchAPIKey(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return db(ctx).Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
@@ -1,39 +1,80 @@
 package repository
 
 import (
+	"context"
 	"errors"
 
 	"github.com/google/uuid"
 	"github.com/rahulmishra/go-crud-app/config"
 	"github.com/rahulmishra/go-crud-app/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-func CreateItem(item *models.Item) error {
-	result := config.DB.Create(item)
+func CreateItem(ctx context.Context, item *models.Item) error {
+	result := db(ctx).Create(item)
 	return result.Error
 }
 
-func GetAllItems(items *[]models.Item) error {
-	result := config.DB.Find(items)
+// CreateItemsIfMissing inserts items in batches of batchSize, skipping any
+// whose ID already exists, and returns the number inserted.
+func CreateItemsIfMissing(ctx context.Context, items []models.Item, batchSize int) (int64, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+	result := db(ctx).Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(items, batchSize)
+	return result.RowsAffected, result.Error
+}
+
+func GetAllItems(ctx context.Context, items *[]models.Item) error {
+	result := db(ctx).Find(items)
 	return result.Error
 }
 
-func GetItemByID(id uuid.UUID, item *models.Item) error {
-	return config.DB.Where("id = ?", id).First(item).Error
+// ScanItems calls fn with every item, batchSize at a time.
+func ScanItems(ctx context.Context, batchSize int, fn func(items []models.Item) error) error {
+	var batch []models.Item
+	return db(ctx).Order("id").FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
 }
 
-func UpdateItem(item *models.Item) error {
-	result := config.DB.Save(item)
+func GetItemByID(ctx context.Context, id uuid.UUID, item *models.Item) error {
+	return db(ctx).Where("id = ?", id).First(item).Error
+}
+
+func UpdateItem(ctx context.Context, item *models.Item) error {
+	result := db(ctx).Save(item)
 	return result.Error
 }
 
-func DeleteItem(id uint) error {
-	result := config.DB.Delete(&models.Item{}, id)
+// UpdateItems writes the name and price of every item in one transaction and
+// returns the IDs of the items it updated. Items that no longer exist are
+// skipped rather than re-created.
+func UpdateItems(ctx context.Context, items []models.Item) (updated []uuid.UUID, err error) {
+	err = WithTransaction(ctx, func(ctx context.Context) error {
+		for _, item := range items {
+			result := db(ctx).Model(&models.Item{}).Where("id = ?", item.ID).
+				Updates(map[string]interface{}{"name": item.Name, "price": item.Price})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				updated = append(updated, item.ID)
+			}
+		}
+		return nil
+	})
+	return updated, err
+}
+
+func DeleteItem(ctx context.Context, id uint) error {
+	result := db(ctx).Delete(&models.Item{}, id)
 	return result.Error
 }
-func SoftDeleteItem(id uuid.UUID) error {
+func SoftDeleteItem(ctx context.Context, id uuid.UUID) error {
 	if config.DB == nil {
 		return errors.New("database is not initialized")
 	}
-	return config.DB.Where("id = ?", id).Delete(&models.Item{}).Error
+	return db(ctx).Where("id = ?", id).Delete(&models.Item{}).Error
 }
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/models"
+	"gorm.io/gorm"
+)
+
+// auditChainLock serialises appends on Postgres so concurrent transactions
+// cannot both extend the chain from the same record.
+const auditChainLock = 7_301_034
+
+type AuditFilter struct {
+	ItemID *uuid.UUID
+	Actor  string
+	Since  time.Time
+	Offset int
+	Limit  int
+}
+
+// AppendAuditRecord links record to the end of the hash chain and inserts it.
+func AppendAuditRecord(ctx context.Context, record *models.AuditRecord) error {
+	return WithTransaction(ctx, func(ctx context.Context) error {
+		tx := db(ctx)
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLock).Error; err != nil {
+				return err
+			}
+		}
+
+		var last models.AuditRecord
+		err := tx.Select("hash").Order("id DESC").Limit(1).Take(&last).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		record.CreatedAt = record.CreatedAt.UTC().Truncate(time.Microsecond)
+		record.PrevHash = last.Hash
+		record.Hash = record.ComputeHash()
+		return tx.Create(record).Error
+	})
+}
+
+func ListAuditRecords(ctx context.Context, filter AuditFilter, records *[]models.AuditRecord) (int64, error) {
+	query := db(ctx).Model(&models.AuditRecord{})
+	if filter.ItemID != nil {
+		query = query.Where("item_id = ?", *filter.ItemID)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return 0, err
+	}
+	err := query.Order("id DESC").Offset(filter.Offset).Limit(filter.Limit).Find(records).Error
+	return total, err
+}
+
+// ScanAuditRecords calls fn with every record in chain order, batchSize at a
+// time.
+func ScanAuditRecords(ctx context.Context, batchSize int, fn func(records []models.AuditRecord) error) error {
+	var batch []models.AuditRecord
+	return db(ctx).Order("id").FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+// ProtectAuditLog installs triggers that reject UPDATE and DELETE on the
+// audit table, so the log stays append-only even outside the application.
+func ProtectAuditLog(ctx context.Context) error {
+	tx := db(ctx)
+	switch tx.Dialector.Name() {
+	case "postgres":
+		return tx.Exec(`
+CREATE OR REPLACE FUNCTION audit_records_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_records is append-only';
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS audit_records_append_only ON audit_records;
+CREATE TRIGGER audit_records_append_only BEFORE UPDATE OR DELETE ON audit_records
+	FOR EACH ROW EXECUTE FUNCTION audit_records_append_only();`).Error
+	case "sqlite":
+		for _, event := range []string{"UPDATE", "DELETE"} {
+			err := tx.Exec(`CREATE TRIGGER IF NOT EXISTS audit_records_no_` + event + ` BEFORE ` + event + ` ON audit_records
+BEGIN SELECT RAISE(ABORT, 'audit_records is append-only'); END;`).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
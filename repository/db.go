@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"github.com/rahulmishra/go-crud-app/config"
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// db returns the transaction started by WithTransaction for ctx, or the shared
+// connection bound to ctx.
+func db(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return config.DB.WithContext(ctx)
+}
+
+// WithTransaction runs fn in a database transaction. Repository calls made
+// with the context passed to fn join that transaction, and calling
+// WithTransaction again from inside fn reuses it.
+func WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	return config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// CountMatchesByPrice counts the items filter matches in each price range.
+// With quantiles set, the ranges split the matches into that many groups of
+// about equal size instead of following bounds. It returns the boundaries
+// used and len(bounds)+1 counts: range i is [bounds[i-1], bounds[i]), with
+// an open range below the first bound and above the last.
+func CountMatchesByPrice(ctx context.Context, filter SearchFilter, bounds []float64, quantiles int) ([]float64, []int64, error) {
+	tx := db(ctx)
+	backend, err := searchBackend(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	matches, _, _, err := filter.matching(tx, backend)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Each query below starts from the same conditions.
+	matches = matches.Session(&gorm.Session{})
+
+	if quantiles > 0 {
+		if bounds, err = priceQuantiles(db(ctx), matches, quantiles); err != nil {
+			return nil, nil, err
+		}
+	}
+	counts := make([]int64, len(bounds)+1)
+	if len(bounds) == 0 {
+		// A CASE needs at least one WHEN, and one open range is a count.
+		return bounds, counts, matches.Count(&counts[0]).Error
+	}
+
+	var bucket strings.Builder
+	args := make([]interface{}, 0, len(bounds))
+	bucket.WriteString("CASE")
+	for i, bound := range bounds {
+		fmt.Fprintf(&bucket, " WHEN items.price < ? THEN %d", i)
+		args = append(args, bound)
+	}
+	fmt.Fprintf(&bucket, " ELSE %d END", len(bounds))
+
+	var rows []struct {
+		Bucket int
+		Count  int64
+	}
+	err = matches.Select(bucket.String()+" AS bucket, count(*) AS count", args...).Group("bucket").Scan(&rows).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, row := range rows {
+		counts[row.Bucket] = row.Count
+	}
+	return bounds, counts, nil
+}
+
+// priceQuantiles returns the prices that split matches into n groups of
+// about equal size, using the nearest-rank method. Repeated prices are
+// returned once, and none equals the lowest price, which would only bound
+// an empty range, so there may be fewer than n-1.
+func priceQuantiles(tx, matches *gorm.DB, n int) ([]float64, error) {
+	var total int64
+	if err := matches.Count(&total).Error; err != nil || total == 0 {
+		return nil, err
+	}
+	// Position 0 holds the lowest price.
+	positions := []int64{0}
+	for k := int64(1); k < int64(n); k++ {
+		positions = append(positions, k*total/int64(n))
+	}
+
+	ranked := matches.Select("items.price, ROW_NUMBER() OVER (ORDER BY items.price) - 1 AS position")
+	var prices []float64
+	err := tx.Table("(?) AS ranked", ranked).Where("position IN ?", positions).Order("position").Pluck("price", &prices).Error
+	if err != nil || len(prices) == 0 {
+		return nil, err
+	}
+	var quantiles []float64
+	for _, price := range prices[1:] {
+		if price > prices[0] && (len(quantiles) == 0 || price > quantiles[len(quantiles)-1]) {
+			quantiles = append(quantiles, price)
+		}
+	}
+	return quantiles, nil
+}
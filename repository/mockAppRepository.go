@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/google/uuid"
 	"github.com/rahulmishra/go-crud-app/models"
 	"github.com/stretchr/testify/mock"
@@ -10,27 +12,42 @@ type MockAppRepository struct {
 	mock.Mock
 }
 
-func (m *MockAppRepository) CreateItem(item *models.Item) error {
+func (m *MockAppRepository) CreateItem(ctx context.Context, item *models.Item) error {
 	args := m.Called(item)
 	return args.Error(0)
 }
 
-func (m *MockAppRepository) GetAllItems(items *[]models.Item) error {
+func (m *MockAppRepository) GetAllItems(ctx context.Context, items *[]models.Item) error {
 	args := m.Called(items)
 	return args.Error(0)
 }
 
-func (m *MockAppRepository) GetItemByID(id uuid.UUID, item *models.Item) error {
+func (m *MockAppRepository) GetItemByID(ctx context.Context, id uuid.UUID, item *models.Item) error {
 	args := m.Called(id, item)
 	return args.Error(0)
 }
 
-func (m *MockAppRepository) UpdateItem(item *models.Item) error {
+func (m *MockAppRepository) UpdateItem(ctx context.Context, item *models.Item) error {
 	args := m.Called(item)
 	return args.Error(0)
 }
 
-func (m *MockAppRepository) SoftDeleteItem(id uuid.UUID) error {
+func (m *MockAppRepository) UpdateItems(ctx context.Context, items []models.Item) ([]uuid.UUID, error) {
+	args := m.Called(items)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockAppRepository) SoftDeleteItem(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
+
+func (m *MockAppRepository) CreateItemsIfMissing(ctx context.Context, items []models.Item, batchSize int) (int64, error) {
+	args := m.Called(items, batchSize)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAppRepository) ScanItems(ctx context.Context, batchSize int, fn func(items []models.Item) error) error {
+	args := m.Called(batchSize, fn)
+	return args.Error(0)
+}
@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/models"
+	"gorm.io/gorm"
+)
+
+// SearchMatch is an item matching a search, with its relevance. Ranks are
+// only comparable within one result set; each backend scores differently.
+type SearchMatch struct {
+	models.Item
+	Rank float64
+}
+
+// PrepareItemSearch creates the full-text index on item names: a generated
+// tsvector column with a GIN index on Postgres, and an FTS5 table kept in
+// sync by triggers on SQLite. SQLite builds without FTS5 fall back to
+// scanning names in SearchItems.
+func PrepareItemSearch(ctx context.Context) error {
+	tx := db(ctx)
+	switch tx.Dialector.Name() {
+	case "postgres":
+		return tx.Exec(`
+ALTER TABLE items ADD COLUMN IF NOT EXISTS search_vector tsvector
+	GENERATED ALWAYS AS (to_tsvector('simple', coalesce(name, ''))) STORED;
+CREATE INDEX IF NOT EXISTS idx_items_search_vector ON items USING GIN (search_vector);`).Error
+	case "sqlite":
+		err := tx.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS items_fts USING fts5(id UNINDEXED, name, tokenize = 'unicode61')`).Error
+		if err != nil {
+			if strings.Contains(err.Error(), "no such module: fts5") {
+				slog.InfoContext(ctx, "SQLite was built without FTS5; item search scans names instead")
+				return nil
+			}
+			return err
+		}
+		// The table is keyed by item ID rather than rowid, which VACUUM may
+		// renumber.
+		for _, stmt := range []string{
+			`CREATE TRIGGER IF NOT EXISTS items_fts_insert AFTER INSERT ON items BEGIN
+	INSERT INTO items_fts (id, name) VALUES (new.id, new.name);
+END`,
+			`CREATE TRIGGER IF NOT EXISTS items_fts_update AFTER UPDATE OF name ON items BEGIN
+	DELETE FROM items_fts WHERE id = old.id;
+	INSERT INTO items_fts (id, name) VALUES (new.id, new.name);
+END`,
+			`CREATE TRIGGER IF NOT EXISTS items_fts_delete AFTER DELETE ON items BEGIN
+	DELETE FROM items_fts WHERE id = old.id;
+END`,
+			`DELETE FROM items_fts`,
+			`INSERT INTO items_fts (id, name) SELECT id, name FROM items`,
+		} {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SearchViewer limits a search to the items a caller may read: unowned
+// items, their own, and items shared with them or one of their groups.
+type SearchViewer struct {
+	UserID string
+	Groups []string
+}
+
+// condition returns the SQL condition, and its arguments, that an item row
+// is visible to v. A nil viewer sees every item.
+func (v *SearchViewer) condition() (string, []interface{}) {
+	if v == nil {
+		return "1 = 1", nil
+	}
+	shared := "grantee_type = ? AND grantee_id = ?"
+	args := []interface{}{v.UserID, models.GranteeUser, v.UserID}
+	if len(v.Groups) > 0 {
+		shared += " OR grantee_type = ? AND grantee_id IN ?"
+		args = append(args, models.GranteeGroup, v.Groups)
+	}
+	// Rows from before ownership was tracked may hold NULL rather than "".
+	return "(items.owner_id IS NULL OR items.owner_id IN ('', ?) OR items.id IN (SELECT item_id FROM item_shares WHERE " + shared + "))", args
+}
+
+// SearchFilter selects the items a search matches.
+type SearchFilter struct {
+	// Terms must be lower case and consist of letters and digits only. An
+	// item matches when its name contains a word starting with each of them.
+	Terms  []string
+	Viewer *SearchViewer
+	// MinPrice, inclusive, and MaxPrice, exclusive, bound the price when set.
+	MinPrice *float64
+	MaxPrice *float64
+	// Exclude lists items to leave out, such as those the read policy hides.
+	Exclude []uuid.UUID
+}
+
+// where applies every condition of f except the text match.
+func (f SearchFilter) where(tx *gorm.DB) *gorm.DB {
+	visible, visibleArgs := f.Viewer.condition()
+	tx = tx.Where(visible, visibleArgs...)
+	if f.MinPrice != nil {
+		tx = tx.Where("items.price >= ?", *f.MinPrice)
+	}
+	if f.MaxPrice != nil {
+		tx = tx.Where("items.price < ?", *f.MaxPrice)
+	}
+	if len(f.Exclude) > 0 {
+		tx = tx.Where("items.id NOT IN ?", f.Exclude)
+	}
+	return tx
+}
+
+// How a database matches search terms.
+const (
+	searchTSVector = "tsvector"
+	searchFTS5     = "fts5"
+	searchScan     = "scan"
+)
+
+// searchBackend returns how tx matches search terms: with the index that
+// PrepareItemSearch created, or by scanning names when there is none.
+func searchBackend(tx *gorm.DB) (string, error) {
+	switch tx.Dialector.Name() {
+	case "postgres":
+		return searchTSVector, nil
+	case "sqlite":
+		ok, err := hasSearchIndex(tx)
+		if err != nil || !ok {
+			return searchScan, err
+		}
+		return searchFTS5, nil
+	default:
+		return searchScan, nil
+	}
+}
+
+// matching returns a query over the items f matches on backend, with the
+// expression, and its arguments, that ranks them. When scanning, the names
+// are matched first and the query selects the matching IDs with rank 0.
+func (f SearchFilter) matching(tx *gorm.DB, backend string) (query *gorm.DB, rank string, rankArgs []interface{}, err error) {
+	query = tx.Model(&models.Item{})
+	switch backend {
+	case searchTSVector:
+		prefixes := make([]string, len(f.Terms))
+		for i, term := range f.Terms {
+			prefixes[i] = term + ":*"
+		}
+		tsquery := strings.Join(prefixes, " & ")
+		query = query.Where("items.search_vector @@ to_tsquery('simple', ?)", tsquery)
+		return f.where(query), "ts_rank(items.search_vector, to_tsquery('simple', ?))", []interface{}{tsquery}, nil
+	case searchFTS5:
+		prefixes := make([]string, len(f.Terms))
+		for i, term := range f.Terms {
+			prefixes[i] = `"` + term + `"*`
+		}
+		query = query.Joins("JOIN items_fts ON items_fts.id = items.id").
+			Where("items_fts MATCH ?", "name : ("+strings.Join(prefixes, " AND ")+")")
+		return f.where(query), "-bm25(items_fts)", nil, nil
+	}
+	matches, err := scanItemNames(f.where(tx), f.Terms, 0)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	ids := make([]uuid.UUID, len(matches))
+	for i, match := range matches {
+		ids[i] = match.ID
+	}
+	if len(ids) == 0 {
+		return query.Where("1 = 0"), "0", nil, nil
+	}
+	return query.Where("items.id IN ?", ids), "0", nil, nil
+}
+
+// SearchItems returns up to limit items matching filter, best match first.
+// Visibility is decided before the limit, so a caller is not shown fewer
+// matches because of items they cannot read.
+func SearchItems(ctx context.Context, filter SearchFilter, limit int) ([]SearchMatch, error) {
+	tx := db(ctx)
+	backend, err := searchBackend(tx)
+	if err != nil {
+		return nil, err
+	}
+	if backend == searchScan {
+		return scanItemNames(filter.where(tx), filter.Terms, limit)
+	}
+	query, rank, rankArgs, err := filter.matching(tx, backend)
+	if err != nil {
+		return nil, err
+	}
+	var matches []SearchMatch
+	err = query.Select("items.id, items.name, items.price, items.owner_id, "+rank+" AS rank", rankArgs...).
+		Order("rank DESC, items.name, items.id").
+		Limit(limit).
+		Scan(&matches).Error
+	return matches, err
+}
+
+func hasSearchIndex(tx *gorm.DB) (bool, error) {
+	var count int64
+	err := tx.Raw(`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'items_fts'`).Scan(&count).Error
+	return count > 0, err
+}
+
+// scanItemNames narrows the candidates with LIKE and ranks them in Go by the
+// share of name words the terms match, exact words counting double. A limit
+// of 0 returns every match.
+func scanItemNames(tx *gorm.DB, terms []string, limit int) ([]SearchMatch, error) {
+	query := tx.Model(&models.Item{})
+	for _, term := range terms {
+		query = query.Where("lower(name) LIKE ?", "%"+term+"%")
+	}
+	var items []models.Item
+	if err := query.Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	matches := make([]SearchMatch, 0, len(items))
+	for _, item := range items {
+		if rank, ok := rankName(item.Name, terms); ok {
+			matches = append(matches, SearchMatch{Item: item, Rank: rank})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID.String() < b.ID.String()
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+func rankName(name string, terms []string) (float64, bool) {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var score float64
+	for _, term := range terms {
+		best := 0.0
+		for _, word := range words {
+			if word == term {
+				best = 2
+				break
+			}
+			if strings.HasPrefix(word, term) {
+				best = 1
+			}
+		}
+		if best == 0 {
+			return 0, false
+		}
+		score += best
+	}
+	return score / float64(len(words)), true
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/models"
+	"gorm.io/gorm/clause"
+)
+
+// UpsertItemShare creates the share or updates the permission of an existing
+// share for the same grantee. share is reloaded so it reflects the stored row.
+func UpsertItemShare(ctx context.Context, share *models.ItemShare) error {
+	err := db(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "item_id"}, {Name: "grantee_type"}, {Name: "grantee_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"permission"}),
+	}).Create(share).Error
+	if err != nil {
+		return err
+	}
+	var stored models.ItemShare
+	err = db(ctx).Where("item_id = ? AND grantee_type = ? AND grantee_id = ?",
+		share.ItemID, share.GranteeType, share.GranteeID).First(&stored).Error
+	if err != nil {
+		return err
+	}
+	*share = stored
+	return nil
+}
+
+// CreateItemSharesIfMissing inserts shares in batches of batchSize, skipping
+// any that conflict with an existing share, and returns the number inserted.
+func CreateItemSharesIfMissing(ctx context.Context, shares []models.ItemShare, batchSize int) (int64, error) {
+	if len(shares) == 0 {
+		return 0, nil
+	}
+	result := db(ctx).Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(shares, batchSize)
+	return result.RowsAffected, result.Error
+}
+
+func GetItemShares(ctx context.Context, itemID uuid.UUID, shares *[]models.ItemShare) error {
+	return db(ctx).Where("item_id = ?", itemID).Order("created_at").Find(shares).Error
+}
+
+// GetSharesForGrantee returns every share granted to userID directly or to
+// any of groups.
+func GetSharesForGrantee(ctx context.Context, userID string, groups []string, shares *[]models.ItemShare) error {
+	query := db(ctx).Where("grantee_type = ? AND grantee_id = ?", models.GranteeUser, userID)
+	if len(groups) > 0 {
+		query = query.Or("grantee_type = ? AND grantee_id IN ?", models.GranteeGroup, groups)
+	}
+	return query.Find(shares).Error
+}
+
+func DeleteItemShare(ctx context.Context, itemID, shareID uuid.UUID) (int64, error) {
+	result := db(ctx).Where("item_id = ? AND id = ?", itemID, shareID).Delete(&models.ItemShare{})
+	return result.RowsAffected, result.Error
+}
+
+func DeleteItemShares(ctx context.Context, itemID uuid.UUID) error {
+	return db(ctx).Where("item_id = ?", itemID).Delete(&models.ItemShare{}).Error
+}
@@ -0,0 +1,191 @@
+package routes_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/services"
+	"github.com/rahulmishra/go-crud-app/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func priceCounts(t *testing.T, page services.SearchPage) map[string]int64 {
+	t.Helper()
+	require.NotNil(t, page.Facets)
+	counts := map[string]int64{}
+	for _, bucket := range page.Facets.Price {
+		counts[bucket.Key] = bucket.Count
+	}
+	return counts
+}
+
+func createLamps(s *testutil.Server, owner testutil.User, prices ...float64) {
+	for _, price := range prices {
+		s.CreateItem(owner, "Lamp", price)
+	}
+}
+
+func TestSearchFacets_PriceBuckets(t *testing.T) {
+	s := testutil.NewServer(t)
+	createLamps(s, alice, 10, 49.99, 50, 120, 199.99, 200, 950)
+	createLamps(s, bob, 5)
+
+	assert.Nil(t, search(t, s, alice, "q=lamp").Facets, "facets are only returned on request")
+
+	page := search(t, s, alice, "q=lamp&facets=price")
+	assert.Equal(t, map[string]int64{"*-50": 2, "50-200": 3, "200-*": 2}, priceCounts(t, page), "bob's item is not counted")
+	first, last := page.Facets.Price[0], page.Facets.Price[2]
+	assert.Nil(t, first.Min)
+	assert.Equal(t, 50.0, *first.Max)
+	assert.Equal(t, 200.0, *last.Min)
+	assert.Nil(t, last.Max)
+
+	page = search(t, s, alice, "q=lamp&facets=price&price_buckets=100,500")
+	assert.Equal(t, map[string]int64{"*-100": 3, "100-500": 3, "500-*": 1}, priceCounts(t, page))
+
+	page = search(t, s, admin, "q=lamp&facets=price&price_buckets=1000")
+	assert.Equal(t, map[string]int64{"*-1000": 8, "1000-*": 0}, priceCounts(t, page))
+}
+
+func TestSearchFacets_PriceQuantiles(t *testing.T) {
+	s := testutil.NewServer(t)
+	createLamps(s, alice, 10, 49.99, 50, 120, 199.99, 200, 950)
+
+	page := search(t, s, alice, "q=lamp&facets=price&price_quantiles=2")
+	assert.Equal(t, map[string]int64{"*-120": 3, "120-*": 4}, priceCounts(t, page))
+
+	page = search(t, s, alice, "q=lamp&facets=price&price_quantiles=4")
+	assert.Equal(t, map[string]int64{"*-49.99": 1, "49.99-120": 2, "120-200": 2, "200-*": 2}, priceCounts(t, page))
+
+	// Equal prices give a single range rather than empty ones.
+	s2 := testutil.NewServer(t)
+	createLamps(s2, alice, 20, 20, 20)
+	page = search(t, s2, alice, "q=lamp&facets=price&price_quantiles=3")
+	assert.Equal(t, map[string]int64{"*-*": 3}, priceCounts(t, page))
+}
+
+// The price filter narrows both the results and the facet counts.
+func TestSearchFacets_PriceFilter(t *testing.T) {
+	s := testutil.NewServer(t)
+	createLamps(s, alice, 10, 49.99, 50, 120, 199.99, 200, 950)
+	s.CreateItem(alice, "Desk", 60)
+
+	page := search(t, s, alice, "q=lamp&facets=price&min_price=50&max_price=200")
+	assert.Equal(t, 3, page.Total)
+	for _, hit := range page.Results {
+		assert.GreaterOrEqual(t, hit.Item.Price, 50.0)
+		assert.Less(t, hit.Item.Price, 200.0)
+	}
+	assert.Equal(t, map[string]int64{"*-50": 0, "50-200": 3, "200-*": 0}, priceCounts(t, page))
+	page = search(t, s, alice, "q=lamp&facets=price&min_price=40&price_quantiles=2")
+	assert.Equal(t, map[string]int64{"*-199.99": 3, "199.99-*": 3}, priceCounts(t, page))
+
+	assert.Equal(t, 2, search(t, s, alice, "q=lamp&min_price=200").Total)
+	assert.Equal(t, 2, search(t, s, alice, "q=lamp&max_price=50").Total)
+}
+
+// Facets are counted by the database, so they cover every match the caller
+// may read, not only the most relevant ones loaded for the page.
+func TestSearchFacets_CountedInDatabase(t *testing.T) {
+	s := testutil.NewServer(t)
+	var lamps []models.Item
+	for i := 0; i < 1100; i++ {
+		price := 10.0
+		if i%11 >= 6 {
+			price = 300
+		}
+		lamps = append(lamps, models.Item{ID: uuid.New(), Name: "Lamp", Price: price, OwnerID: "alice"})
+	}
+	for i := 0; i < 200; i++ {
+		lamps = append(lamps, models.Item{ID: uuid.New(), Name: "Lamp", Price: 100, OwnerID: "bob"})
+	}
+	require.NoError(t, s.DB.CreateInBatches(lamps, 200).Error)
+
+	page := search(t, s, alice, "q=lamp&facets=price")
+	assert.True(t, page.Truncated)
+	assert.Equal(t, map[string]int64{"*-50": 600, "50-200": 0, "200-*": 500}, priceCounts(t, page), "bob's items are not counted")
+
+	page = search(t, s, alice, "q=lamp&facets=price&price_quantiles=4")
+	assert.Equal(t, map[string]int64{"*-300": 600, "300-*": 500}, priceCounts(t, page))
+
+	page = search(t, s, alice, "q=lamp&facets=price&max_price=50")
+	assert.Equal(t, map[string]int64{"*-50": 600, "50-200": 0, "200-*": 0}, priceCounts(t, page))
+}
+
+// Matches the read policy hides are not counted either.
+func TestSearchFacets_ReadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`rules:
+  - name: read-with-scope
+    effect: allow
+    actions: [read]
+    scopes: [items:read]
+  - name: write-with-scope
+    effect: allow
+    actions: [create, update, delete]
+    scopes: [items:write]
+  - name: hide-expensive-items
+    effect: deny
+    actions: [read]
+    conditions:
+      - field: resource.price
+        op: gt
+        value: 500
+`), 0o600))
+	s := testutil.NewServer(t, testutil.WithPolicyFile(path))
+	createLamps(s, alice, 10, 120, 950)
+
+	page := search(t, s, alice, "q=lamp&facets=price")
+	assert.Equal(t, 2, page.Total)
+	assert.Equal(t, map[string]int64{"*-50": 1, "50-200": 1, "200-*": 0}, priceCounts(t, page))
+}
+
+func TestSearchFacets_InvalidRequests(t *testing.T) {
+	s := testutil.NewServer(t)
+
+	for query, message := range map[string]string{
+		"q=lamp&facets=price,category":             "Unknown facet; supported facets: price",
+		"q=lamp&facets=price&price_buckets=200,50": "price_buckets must be at most 19 increasing, non-negative prices",
+		"q=lamp&facets=price&price_buckets=a":      "price_buckets must be at most 19 increasing, non-negative prices",
+		"q=lamp&facets=price&price_quantiles=1":    "price_quantiles must be between 2 and 10",
+		"q=lamp&facets=price&price_quantiles=many": "price_quantiles must be between 2 and 10",
+		"q=lamp&min_price=cheap":                   "min_price and max_price must be non-negative numbers with min_price below max_price",
+		"q=lamp&min_price=100&max_price=100":       "min_price and max_price must be non-negative numbers with min_price below max_price",
+		"q=lamp&max_price=-1":                      "min_price and max_price must be non-negative numbers with min_price below max_price",
+	} {
+		s.GET("/items/search?"+query).As(alice).Expect(t).Status(http.StatusBadRequest).Field("error", message)
+	}
+	s.GET("/items/search?q=lamp&facets=tags").As(alice).Header("Accept-Language", "es").Expect(t).
+		Status(http.StatusBadRequest).Field("error", "Faceta desconocida; facetas admitidas: price")
+}
+
+// Pages and their facets are cached per caller and dropped when an item or
+// a share changes.
+func TestSearchFacets_Cache(t *testing.T) {
+	s := testutil.NewServer(t)
+	createLamps(s, alice, 10, 120)
+	bobs := s.CreateItem(bob, "Lamp", 300)
+	query := "q=lamp&facets=price"
+
+	assert.Equal(t, map[string]int64{"*-50": 1, "50-200": 1, "200-*": 0}, priceCounts(t, search(t, s, alice, query)))
+	assert.True(t, hasKeyPrefix(s.Redis.Keys(), "query:search-page:"))
+	assert.Equal(t, map[string]int64{"*-50": 1, "50-200": 1, "200-*": 1}, priceCounts(t, search(t, s, admin, query)),
+		"other callers get their own page")
+
+	require.NoError(t, s.DB.Model(&models.Item{}).Where("price = ?", 10).Update("price", 20).Error)
+	page := search(t, s, alice, query)
+	assert.Equal(t, map[string]int64{"*-50": 1, "50-200": 1, "200-*": 0}, priceCounts(t, page))
+	assert.Contains(t, []float64{page.Results[0].Item.Price, page.Results[1].Item.Price}, 10.0, "served from the cache")
+
+	s.ShareItem(bob, bobs.ID, models.GranteeUser, "alice", models.PermissionRead)
+	assert.Equal(t, map[string]int64{"*-50": 1, "50-200": 1, "200-*": 1}, priceCounts(t, search(t, s, alice, query)))
+
+	s.PUT("/items/"+bobs.ID.String(), map[string]interface{}{"name": "Lamp", "price": 150}).As(bob).
+		Expect(t).Status(http.StatusOK)
+	assert.Equal(t, map[string]int64{"*-50": 1, "50-200": 2, "200-*": 0}, priceCounts(t, search(t, s, alice, query)))
+}
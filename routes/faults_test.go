@@ -0,0 +1,154 @@
+package routes_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rahulmishra/go-crud-app/faults"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addFault(t *testing.T, s *testutil.Server, rule string) faults.Rule {
+	t.Helper()
+	var added faults.Rule
+	s.POST("/admin/faults", rule).As(admin).Expect(t).Status(http.StatusCreated).JSON(&added)
+	return added
+}
+
+func TestFaults_AdminEndpoints(t *testing.T) {
+	s := testutil.NewServer(t, testutil.WithFaults())
+
+	s.GET("/admin/faults").As(carol).Expect(t).Status(http.StatusForbidden)
+	s.POST("/admin/faults", `{"target":"disk","error":"x"}`).As(admin).Expect(t).Status(http.StatusBadRequest)
+	s.POST("/admin/faults", `{"target":"db","latency":5}`).As(admin).Expect(t).Status(http.StatusBadRequest)
+	s.POST("/admin/faults", `{"target":"db","error":"x","probability":0}`).As(admin).Expect(t).Status(http.StatusBadRequest)
+
+	first := addFault(t, s, `{"target":"cache","operation":"get","key":"item:*","error":"boom","probability":0.5}`)
+	assert.NotEmpty(t, first.ID)
+	second := addFault(t, s, `{"target":"db","latency":"10ms"}`)
+	require.NotNil(t, second.Probability)
+	assert.Equal(t, 1.0, *second.Probability, "an omitted probability means always")
+
+	var rules []faults.Rule
+	s.GET("/admin/faults").As(admin).Expect(t).Status(http.StatusOK).JSON(&rules)
+	require.Len(t, rules, 2)
+	assert.Equal(t, first, rules[0])
+
+	s.DELETE("/admin/faults/" + first.ID).As(admin).Expect(t).Status(http.StatusNoContent)
+	s.DELETE("/admin/faults/" + first.ID).As(admin).Expect(t).Status(http.StatusNotFound)
+	s.DELETE("/admin/faults").As(admin).Expect(t).Status(http.StatusNoContent)
+	s.GET("/admin/faults").As(admin).Expect(t).Status(http.StatusOK).JSONEq(`[]`)
+}
+
+func TestFaults_NotServedByDefault(t *testing.T) {
+	s := testutil.NewServer(t)
+	s.GET("/admin/faults").As(admin).Expect(t).Status(http.StatusNotFound)
+}
+
+// With every Redis command failing, the API keeps working from the
+// database. Write-behind mode is covered separately: it buffers updates in
+// Redis.
+func TestFaults_CacheDownServesFromDatabase(t *testing.T) {
+	for _, mode := range cacheModes[:2] {
+		t.Run(mode, func(t *testing.T) {
+			s := testutil.NewServer(t, testutil.WithFaults(), testutil.WithCacheWriteMode(mode))
+			addFault(t, s, `{"target":"cache","error":"connection refused"}`)
+
+			var created models.Item
+			s.POST("/items/", map[string]interface{}{"name": "Lamp", "price": 20}).As(alice).
+				Expect(t).Status(http.StatusCreated).JSON(&created)
+			path := "/items/" + created.ID.String()
+			s.GET(path).As(alice).Expect(t).Status(http.StatusOK).Field("name", "Lamp")
+			s.PUT(path, map[string]interface{}{"name": "Desk Lamp", "price": 25}).As(alice).
+				Expect(t).Status(http.StatusOK)
+			s.GET(path).As(alice).Expect(t).Status(http.StatusOK).Field("name", "Desk Lamp")
+			s.GET("/items/").As(alice).Expect(t).Status(http.StatusOK).Contains("Desk Lamp")
+			s.DELETE(path).As(alice).Expect(t).Status(http.StatusOK)
+			s.GET(path).As(alice).Expect(t).Status(http.StatusNotFound)
+
+			assert.Empty(t, s.Redis.Keys(), "nothing reached Redis")
+			assert.Greater(t, faults.Rules()[0].Injected, 0)
+		})
+	}
+}
+
+// Write-behind updates are rejected while Redis is down instead of being
+// lost. Reads and the other writes still work.
+func TestFaults_WriteBehindRejectsUpdatesWithoutCache(t *testing.T) {
+	s := testutil.NewServer(t, testutil.WithFaults(), testutil.WithCacheWriteMode(cacheModes[2]))
+	item := s.CreateItem(alice, "Lamp", 20)
+	path := "/items/" + item.ID.String()
+	addFault(t, s, `{"target":"cache","error":"connection refused"}`)
+
+	s.PUT(path, map[string]interface{}{"name": "Desk Lamp", "price": 25}).As(alice).
+		Expect(t).Status(http.StatusInternalServerError)
+	s.GET(path).As(alice).Expect(t).Status(http.StatusOK).Field("name", "Lamp")
+	s.DELETE(path).As(alice).Expect(t).Status(http.StatusOK)
+}
+
+// A failed invalidation leaves the old entry in the cache until it expires.
+// The update itself succeeds.
+func TestFaults_FailedInvalidationIsBoundedByTTL(t *testing.T) {
+	s := testutil.NewServer(t, testutil.WithFaults())
+	item := s.CreateItem(alice, "Lamp", 20)
+	path := "/items/" + item.ID.String()
+	s.GET(path).As(alice).Expect(t).Status(http.StatusOK)
+	_, cached := s.CachedItem(item.ID)
+	require.True(t, cached)
+
+	addFault(t, s, `{"target":"cache","operation":"del","error":"timeout"}`)
+	s.PUT(path, map[string]interface{}{"name": "Desk Lamp", "price": 25}).As(alice).Expect(t).Status(http.StatusOK)
+	stored, _ := s.StoredItem(item.ID)
+	assert.Equal(t, "Desk Lamp", stored.Name)
+	s.GET(path).As(alice).Expect(t).Status(http.StatusOK).Field("name", "Lamp")
+
+	s.Redis.FastForward(5 * time.Minute)
+	s.GET(path).As(alice).Expect(t).Status(http.StatusOK).Field("name", "Desk Lamp")
+}
+
+func TestFaults_DatabaseErrors(t *testing.T) {
+	s := testutil.NewServer(t, testutil.WithFaults())
+	cached := s.CreateItem(alice, "Cached", 10)
+	uncached := s.CreateItem(alice, "Uncached", 20)
+	s.GET("/items/" + cached.ID.String()).As(alice).Expect(t).Status(http.StatusOK)
+
+	addFault(t, s, `{"target":"db","key":"items","error":"too many connections"}`)
+
+	s.GET("/items/"+cached.ID.String()).As(alice).Expect(t).Status(http.StatusOK).Field("name", "Cached")
+	s.GET("/items/" + uncached.ID.String()).As(alice).Expect(t).
+		Status(http.StatusInternalServerError).Contains("too many connections")
+	s.GET("/items/").As(alice).Expect(t).Status(http.StatusInternalServerError)
+	s.POST("/items/", map[string]interface{}{"name": "New", "price": 1}).As(alice).
+		Expect(t).Status(http.StatusInternalServerError)
+	s.DELETE("/items/" + cached.ID.String()).As(alice).Expect(t).Status(http.StatusInternalServerError)
+
+	s.DELETE("/admin/faults").As(admin).Expect(t).Status(http.StatusNoContent)
+	var items []models.Item
+	s.GET("/items/").As(alice).Expect(t).Status(http.StatusOK).JSON(&items)
+	assert.Len(t, items, 2, "failed writes were rolled back")
+}
+
+func TestFaults_DatabaseTimeout(t *testing.T) {
+	s := testutil.NewServer(t, testutil.WithFaults())
+	item := s.CreateItem(alice, "Lamp", 20)
+	addFault(t, s, `{"target":"db","operation":"query","timeout":true,"latency":"20ms","limit":2}`)
+
+	s.GET("/items/").As(alice).Header("Accept-Language", "de").Expect(t).
+		Status(http.StatusServiceUnavailable).Field("error", "Der Dienst ist vorübergehend nicht verfügbar")
+	s.GET("/items/" + item.ID.String()).As(alice).Expect(t).Status(http.StatusServiceUnavailable)
+	s.GET("/items/" + item.ID.String()).As(alice).Expect(t).Status(http.StatusOK)
+}
+
+func TestFaults_Latency(t *testing.T) {
+	s := testutil.NewServer(t, testutil.WithFaults())
+	item := s.CreateItem(alice, "Lamp", 20)
+	addFault(t, s, `{"target":"db","operation":"query","latency":"50ms"}`)
+
+	start := time.Now()
+	s.GET("/items/" + item.ID.String()).As(alice).Expect(t).Status(http.StatusOK)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
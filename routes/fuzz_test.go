@@ -0,0 +1,153 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/testutil"
+	"github.com/rahulmishra/go-crud-app/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// itemBodies seeds the body fuzz targets with the edge cases binding has to
+// cope with.
+var itemBodies = []string{
+	`{"name":"Laptop","price":1200.5}`,
+	`{"name":"Laptop","price":1200.555}`,
+	`{"name":"Laptop","price":NaN}`,
+	`{"name":"Laptop","price":1e400}`,
+	`{"name":"Laptop","price":-0}`,
+	`{"name":"Laptop","price":1000000000}`,
+	`{"name":"Laptop","price":1000000000.01}`,
+	`{"name":"Laptop","price":"12"}`,
+	`{"name":"名前 😀 ünïcödé","price":3}`,
+	`{"name":"\u0000","price":3}`,
+	`{"name":"   ","price":3}`,
+	`{"name":"a","name":"b","price":1,"price":2}`,
+	`{"name":"x","price":1,"owner_id":"mallory","ID":"00000000-0000-0000-0000-000000000001"}`,
+	`{"name":["x"],"price":{}}`,
+	`[]`,
+	`null`,
+	`{"name":"` + string([]byte{0xff, 0xfe}) + `","price":1}`,
+	``,
+}
+
+func assertNoServerError(t *testing.T, resp *testutil.Response) {
+	t.Helper()
+	assert.Less(t, resp.StatusCode, http.StatusInternalServerError, "body: %s", resp.Body)
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		assert.True(t, json.Valid(resp.Body), "malformed JSON response %q", resp.Body)
+	}
+}
+
+func FuzzCreateItem(f *testing.F) {
+	for _, body := range itemBodies {
+		f.Add([]byte(body))
+	}
+	s := testutil.NewServer(f)
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		resp := s.POST("/items/", body).As(alice).Expect(t)
+		assertNoServerError(t, resp)
+		if resp.StatusCode != http.StatusCreated {
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "body: %s", resp.Body)
+			return
+		}
+
+		var created models.Item
+		resp.JSON(&created)
+		assert.NoError(t, validation.Struct(&created), "only valid items are stored")
+		assert.Equal(t, alice.ID, created.OwnerID, "the owner cannot be chosen by the client")
+		assert.NotEqual(t, "00000000-0000-0000-0000-000000000001", created.ID.String(), "nor can the ID")
+		stored, ok := s.StoredItem(created.ID)
+		require.True(t, ok)
+		assert.Equal(t, created, stored)
+	})
+}
+
+func FuzzUpdateItem(f *testing.F) {
+	for _, body := range itemBodies {
+		f.Add([]byte(body))
+	}
+	s := testutil.NewServer(f)
+	item := s.CreateItem(alice, "Original", 10)
+	path := "/items/" + item.ID.String()
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		before, _ := s.StoredItem(item.ID)
+		resp := s.PUT(path, body).As(alice).Expect(t)
+		assertNoServerError(t, resp)
+
+		stored, ok := s.StoredItem(item.ID)
+		require.True(t, ok)
+		assert.Equal(t, item.ID, stored.ID, "the ID cannot be changed")
+		assert.Equal(t, alice.ID, stored.OwnerID, "the owner cannot be changed")
+		if resp.StatusCode != http.StatusOK {
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "body: %s", resp.Body)
+			assert.Equal(t, before, stored, "rejected updates change nothing")
+			return
+		}
+
+		var sent models.Item
+		require.NoError(t, json.Unmarshal(body, &sent))
+		assert.Equal(t, sent.Name, stored.Name)
+		assert.Equal(t, sent.Price, stored.Price)
+		assert.NoError(t, validation.Struct(&stored))
+		_, cached := s.CachedItem(item.ID)
+		assert.False(t, cached, "updates invalidate the cached copy")
+	})
+}
+
+// FuzzItemByID sends arbitrary path parameters to the read and delete
+// handlers.
+func FuzzItemByID(f *testing.F) {
+	for _, id := range []string{"not-a-uuid", uuid.NewString(), uuid.Nil.String(), "", "../audit", "%00", "名前", "{" + uuid.NewString() + "}"} {
+		f.Add(id)
+	}
+	s := testutil.NewServer(f)
+	item := s.CreateItem(alice, "Keep", 5)
+
+	f.Fuzz(func(t *testing.T, id string) {
+		if parsed, err := uuid.Parse(id); err == nil && parsed == item.ID {
+			t.Skip("deleting the fixture is covered by the lifecycle test")
+		}
+		path := "/items/" + url.PathEscape(id)
+		for _, method := range []string{http.MethodGet, http.MethodDelete} {
+			resp := s.Request(method, path).As(alice).Expect(t)
+			assertNoServerError(t, resp)
+		}
+		_, ok := s.StoredItem(item.ID)
+		assert.True(t, ok, "other items are untouched")
+	})
+}
+
+func FuzzGetAllItems(f *testing.F) {
+	for _, owner := range []string{"", "me", "alice", "bob", "' OR 1=1 --", "%", "名前"} {
+		f.Add(owner)
+	}
+	s := testutil.NewServer(f)
+	s.CreateItem(alice, "Desk", 300)
+	s.CreateItem(bob, "Chair", 50)
+
+	f.Fuzz(func(t *testing.T, owner string) {
+		resp := s.GET("/items/?owner=" + url.QueryEscape(owner)).As(admin).Expect(t).Status(http.StatusOK)
+		var items []models.Item
+		resp.JSON(&items)
+		if owner == "" {
+			assert.Len(t, items, 2)
+			return
+		}
+		if owner == "me" {
+			owner = admin.ID
+		}
+		for _, item := range items {
+			assert.Equal(t, owner, item.OwnerID)
+		}
+	})
+}
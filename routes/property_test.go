@@ -0,0 +1,223 @@
+package routes_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/cache"
+	"github.com/rahulmishra/go-crud-app/config"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/services"
+	"github.com/rahulmishra/go-crud-app/testutil"
+	"github.com/rahulmishra/go-crud-app/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+var cacheModes = []string{config.CacheWriteInvalidate, config.CacheWriteThrough, config.CacheWriteBehind}
+
+// Names and prices the state machine picks from, valid and invalid alike.
+var (
+	modelNames  = []string{"Lamp", "名前 😀", "  padded  ", "", "   ", "tab\there", strings.Repeat("x", 200), strings.Repeat("x", 201), "O'Reilly; DROP TABLE items"}
+	modelPrices = []float64{0.01, 1, 19.99, 1e9, 0, -5, 1.005, 1e9 + 0.01, 123456.78}
+)
+
+const maxStateMachineSteps = 200
+
+// itemModel is the reference implementation the API is checked against.
+// latest is what reads must return and stored what the database must hold;
+// they only differ in write-behind mode, between an update and the next
+// flush.
+type itemModel struct {
+	latest map[uuid.UUID]models.Item
+	stored map[uuid.UUID]models.Item
+	// ids holds every ID ever created, so deleted items are targeted too.
+	ids []uuid.UUID
+}
+
+// script turns fuzz input into operations, one byte at a time. Reading past
+// the end yields zeros.
+type script []byte
+
+func (s *script) next() int {
+	if len(*s) == 0 {
+		return 0
+	}
+	b := (*s)[0]
+	*s = (*s)[1:]
+	return int(b)
+}
+
+func (m *itemModel) target(s *script) uuid.UUID {
+	n := s.next()
+	if len(m.ids) == 0 || n%4 == 0 {
+		return uuid.New()
+	}
+	return m.ids[n%len(m.ids)]
+}
+
+func pickItem(s *script) models.Item {
+	return models.Item{Name: modelNames[s.next()%len(modelNames)], Price: modelPrices[s.next()%len(modelPrices)]}
+}
+
+// runItemStateMachine applies the operations encoded in ops to a fresh
+// server and to the model, checking responses after every step and that
+// the cache and database agree with the model.
+func runItemStateMachine(t *testing.T, mode string, ops script) {
+	s := testutil.NewServer(t, testutil.WithCacheWriteMode(mode))
+	m := &itemModel{latest: map[uuid.UUID]models.Item{}, stored: map[uuid.UUID]models.Item{}}
+	var history []string
+	defer func() {
+		if t.Failed() {
+			t.Logf("mode %s, operations:\n%s", mode, strings.Join(history, "\n"))
+		}
+	}()
+
+	for step := 0; len(ops) > 0 && step < maxStateMachineSteps && !t.Failed(); step++ {
+		switch ops.next() % 6 {
+		case 0:
+			item := pickItem(&ops)
+			history = append(history, fmt.Sprintf("create %q %v", item.Name, item.Price))
+			resp := s.POST("/items/", item).As(alice).Expect(t)
+			if validation.Struct(&item) != nil {
+				resp.Status(http.StatusBadRequest)
+				continue
+			}
+			var created models.Item
+			resp.Status(http.StatusCreated).JSON(&created)
+			m.latest[created.ID] = created
+			m.stored[created.ID] = created
+			m.ids = append(m.ids, created.ID)
+
+		case 1:
+			id, item := m.target(&ops), pickItem(&ops)
+			history = append(history, fmt.Sprintf("update %s %q %v", id, item.Name, item.Price))
+			resp := s.PUT("/items/"+id.String(), item).As(alice).Expect(t)
+			current, exists := m.latest[id]
+			switch {
+			case validation.Struct(&item) != nil:
+				resp.Status(http.StatusBadRequest)
+			case !exists:
+				resp.Status(http.StatusNotFound)
+			default:
+				resp.Status(http.StatusOK)
+				current.Name, current.Price = item.Name, item.Price
+				m.latest[id] = current
+				if mode != config.CacheWriteBehind {
+					m.stored[id] = current
+				}
+			}
+
+		case 2:
+			id := m.target(&ops)
+			history = append(history, fmt.Sprintf("delete %s", id))
+			resp := s.DELETE("/items/" + id.String()).As(alice).Expect(t)
+			if _, exists := m.latest[id]; !exists {
+				resp.Status(http.StatusNotFound)
+				continue
+			}
+			resp.Status(http.StatusOK)
+			delete(m.latest, id)
+			delete(m.stored, id)
+
+		case 3:
+			id := m.target(&ops)
+			history = append(history, fmt.Sprintf("get %s", id))
+			resp := s.GET("/items/" + id.String()).As(alice).Expect(t)
+			want, exists := m.latest[id]
+			if !exists {
+				resp.Status(http.StatusNotFound)
+				continue
+			}
+			var got models.Item
+			resp.Status(http.StatusOK).JSON(&got)
+			assert.Equal(t, want, got)
+
+		case 4:
+			history = append(history, "list")
+			var got []models.Item
+			s.GET("/items/").As(alice).Expect(t).Status(http.StatusOK).JSON(&got)
+			// Lists are read from the database, so buffered updates are not
+			// visible until they are flushed.
+			assert.ElementsMatch(t, values(m.stored), got)
+
+		case 5:
+			history = append(history, "flush")
+			_, err := services.FlushWriteBehind(s.Context(admin), 2)
+			assert.NoError(t, err)
+			for id, item := range m.latest {
+				m.stored[id] = item
+			}
+		}
+		m.check(t, s)
+	}
+}
+
+// check compares the database and every cache entry with the model.
+func (m *itemModel) check(t *testing.T, s *testutil.Server) {
+	t.Helper()
+	var rows []models.Item
+	assert.NoError(t, s.DB.Find(&rows).Error)
+	assert.ElementsMatch(t, values(m.stored), rows, "database")
+
+	for _, id := range m.ids {
+		cached, ok := s.CachedItem(id)
+		if !ok {
+			continue
+		}
+		want, exists := m.latest[id]
+		if assert.True(t, exists, "deleted item %s is still cached", id) {
+			assert.Equal(t, want, cached, "cached item %s", id)
+		}
+	}
+
+	if data, ok := s.Redis.Get("all_items"); ok {
+		var list []models.Item
+		if assert.NoError(t, cache.Unmarshal(data, &list)) {
+			assert.ElementsMatch(t, values(m.stored), list, "cached item list")
+		}
+	}
+}
+
+func values(items map[uuid.UUID]models.Item) []models.Item {
+	list := make([]models.Item, 0, len(items))
+	for _, item := range items {
+		list = append(list, item)
+	}
+	return list
+}
+
+func TestItemStateMachine(t *testing.T) {
+	for _, mode := range cacheModes {
+		t.Run(mode, func(t *testing.T) {
+			for seed := uint64(1); seed <= 10; seed++ {
+				rng := rand.New(rand.NewPCG(seed, 0))
+				ops := make(script, 3*maxStateMachineSteps)
+				for i := range ops {
+					ops[i] = byte(rng.UintN(256))
+				}
+				t.Run(fmt.Sprint("seed", seed), func(t *testing.T) {
+					runItemStateMachine(t, mode, ops)
+				})
+			}
+		})
+	}
+}
+
+// FuzzItemStateMachine lets the fuzzer search for operation sequences that
+// make the cache and database diverge. The first byte selects the cache
+// write mode.
+func FuzzItemStateMachine(f *testing.F) {
+	f.Add([]byte{0, 0, 0, 0, 3, 1, 1, 1, 1, 3, 1, 4, 2, 1, 3, 1})
+	f.Add([]byte{1, 0, 0, 0, 4, 1, 1, 2, 2, 4, 3, 1, 2, 1, 4})
+	f.Add([]byte{2, 0, 0, 0, 4, 1, 1, 2, 2, 4, 3, 1, 5, 4, 2, 1, 5, 3, 1})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) == 0 {
+			return
+		}
+		runItemStateMachine(t, cacheModes[int(data[0])%len(cacheModes)], script(data[1:]))
+	})
+}
@@ -3,15 +3,94 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/rahulmishra/go-crud-app/controllers"
+	"github.com/rahulmishra/go-crud-app/metrics"
+	"github.com/rahulmishra/go-crud-app/middleware"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/validation"
 )
 
+// NewEngine returns an engine without middleware that only trusts the
+// forwarding headers of trustedProxies when determining client IPs.
+func NewEngine(trustedProxies []string) (*gin.Engine, error) {
+	router := gin.New()
+	// gin.New trusts every proxy until told otherwise; nil trusts none.
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
+	return router, nil
+}
+
+// SetupItemRoutes registers the item API. Access to individual operations is
+// decided by the policy engine in the controllers.
 func SetupItemRoutes(router *gin.Engine) {
-	itemRoutes := router.Group("/items")
+	validation.Setup()
+	itemRoutes := router.Group("/items", middleware.RateLimitIP(), middleware.Authenticate(), middleware.RateLimit("items"))
 	{
 		itemRoutes.POST("/", controllers.CreateItem)
 		itemRoutes.GET("/", controllers.GetAllItems)
+		itemRoutes.GET("/search", controllers.SearchItems)
+		itemRoutes.GET("/suggest", controllers.SuggestItems)
 		itemRoutes.GET("/:id", controllers.GetItemByID)
 		itemRoutes.PUT("/:id", controllers.UpdateItem)
 		itemRoutes.DELETE("/:id", controllers.DeleteItem)
+
+		itemRoutes.POST("/:id/shares", controllers.ShareItem)
+		itemRoutes.GET("/:id/shares", controllers.ListItemShares)
+		itemRoutes.DELETE("/:id/shares/:shareId", controllers.RevokeItemShare)
+	}
+}
+
+func SetupAuditRoutes(router *gin.Engine) {
+	auditRoutes := router.Group("/audit", middleware.RateLimitIP(), middleware.Authenticate(), middleware.RateLimit("audit"), middleware.RequireScope(models.ScopeAdmin))
+	{
+		auditRoutes.GET("", controllers.ListAuditRecords)
+		auditRoutes.GET("/verify", controllers.VerifyAuditChain)
+	}
+}
+
+func SetupAdminRoutes(router *gin.Engine) {
+	adminRoutes := router.Group("/admin", middleware.RateLimitIP(), middleware.Authenticate(), middleware.RateLimit("admin"), middleware.RequireScope(models.ScopeAdmin))
+	{
+		adminRoutes.POST("/api-keys", controllers.CreateAPIKey)
+		adminRoutes.GET("/api-keys", controllers.ListAPIKeys)
+		adminRoutes.DELETE("/api-keys/:id", controllers.RevokeAPIKey)
+		adminRoutes.POST("/policies/reload", controllers.ReloadPolicy)
+		adminRoutes.GET("/slow-queries", controllers.ListSlowQueries)
+		adminRoutes.DELETE("/slow-queries", controllers.ResetSlowQueries)
+	}
+}
+
+// SetupMetricsRoutes serves /metrics on the API port, for deployments without
+// a separate metrics listener. The metrics describe traffic and internals,
+// so only admins may read them there.
+func SetupMetricsRoutes(router *gin.Engine) {
+	router.GET("/metrics", middleware.RateLimitIP(), middleware.Authenticate(), middleware.RequireScope(models.ScopeAdmin),
+		gin.WrapH(metrics.Handler()))
+}
+
+// SetupFaultRoutes registers the fault injection endpoints. They are only
+// meant for servers started with fault injection enabled.
+func SetupFaultRoutes(router *gin.Engine) {
+	faultRoutes := router.Group("/admin/faults", middleware.RateLimitIP(), middleware.Authenticate(), middleware.RateLimit("admin"), middleware.RequireScope(models.ScopeAdmin))
+	{
+		faultRoutes.GET("", controllers.ListFaults)
+		faultRoutes.POST("", controllers.AddFault)
+		faultRoutes.DELETE("", controllers.ResetFaults)
+		faultRoutes.DELETE("/:id", controllers.RemoveFault)
+	}
+}
+
+// SetupDiagnosticsRoutes registers profiling and runtime diagnostics. They are
+// meant for a separate admin listener, not the public API port.
+func SetupDiagnosticsRoutes(router *gin.Engine) {
+	debugRoutes := router.Group("/debug", middleware.Authenticate(), middleware.RequireScope(models.ScopeAdmin))
+	{
+		debugRoutes.GET("/pprof/*profile", controllers.Pprof)
+		debugRoutes.POST("/pprof/*profile", controllers.Pprof)
+		debugRoutes.GET("/goroutines", controllers.DumpGoroutines)
+		debugRoutes.GET("/runtime", controllers.RuntimeStats)
+		debugRoutes.GET("/build", controllers.BuildInfo)
+		debugRoutes.GET("/config", controllers.EffectiveConfig)
+		debugRoutes.GET("/pools", controllers.PoolStats)
 	}
 }
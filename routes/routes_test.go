@@ -0,0 +1,359 @@
+package routes_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/config"
+	"github.com/rahulmishra/go-crud-app/middleware"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/services"
+	"github.com/rahulmishra/go-crud-app/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	alice = testutil.Editor("alice")
+	bob   = testutil.Editor("bob", "buyers")
+	carol = testutil.Reader("carol")
+	admin = testutil.Admin("root")
+)
+
+func TestItemLifecycle(t *testing.T) {
+	s := testutil.NewServer(t)
+
+	var created models.Item
+	s.POST("/items/", map[string]interface{}{"name": "Laptop", "price": 1200.5}).As(alice).
+		Expect(t).Status(http.StatusCreated).JSON(&created)
+	assert.Equal(t, "alice", created.OwnerID)
+	assert.NotEqual(t, uuid.Nil, created.ID)
+
+	var fetched models.Item
+	s.GET("/items/" + created.ID.String()).As(alice).Expect(t).Status(http.StatusOK).JSON(&fetched)
+	assert.Equal(t, "Laptop", fetched.Name)
+
+	var items []models.Item
+	s.GET("/items/").As(alice).Expect(t).Status(http.StatusOK).JSON(&items)
+	assert.Len(t, items, 1)
+
+	s.PUT("/items/"+created.ID.String(), map[string]interface{}{"name": "Gaming Laptop", "price": 1500}).As(alice).
+		Expect(t).Status(http.StatusOK).Field("message", "Item updated successfully")
+	stored, ok := s.StoredItem(created.ID)
+	require.True(t, ok)
+	assert.Equal(t, "Gaming Laptop", stored.Name)
+
+	s.DELETE("/items/" + created.ID.String()).As(alice).Expect(t).Status(http.StatusOK)
+	_, ok = s.StoredItem(created.ID)
+	assert.False(t, ok)
+	s.GET("/items/" + created.ID.String()).As(alice).Expect(t).Status(http.StatusNotFound)
+}
+
+func TestItemErrors(t *testing.T) {
+	s := testutil.NewServer(t)
+
+	s.GET("/items/").Expect(t).Status(http.StatusUnauthorized)
+	s.GET("/items/not-a-uuid").As(alice).Expect(t).Status(http.StatusBadRequest).Field("error", "Invalid UUID format")
+	s.GET("/items/" + uuid.NewString()).As(alice).Expect(t).Status(http.StatusNotFound)
+	s.POST("/items/", map[string]interface{}{"name": "Pen", "price": 1}).As(carol).Expect(t).Status(http.StatusForbidden)
+	s.POST("/items/", `{"name":`).As(alice).Expect(t).Status(http.StatusBadRequest)
+
+	s.POST("/items/", map[string]interface{}{"name": "Pen", "price": -1}).As(alice).
+		Header("Accept-Language", "de-DE").
+		Expect(t).Status(http.StatusBadRequest).
+		HasHeader("Content-Language", "de").
+		Field("error", "Validierung fehlgeschlagen").
+		Contains("muss größer als 0 sein")
+}
+
+func TestClientErrorsAreLocalized(t *testing.T) {
+	s := testutil.NewServer(t, testutil.WithFaults())
+	item := s.CreateItem(alice, "Desk", 300)
+
+	s.POST("/items/", `{"name":`).As(alice).Header("Accept-Language", "de").Expect(t).
+		Status(http.StatusBadRequest).Field("error", "Der Anfragetext ist kein gültiges JSON oder enthält ein Feld mit falschem Typ")
+	s.POST("/items/"+item.ID.String()+"/shares", map[string]string{"grantee_type": "team", "grantee_id": "x", "permission": "read"}).
+		As(alice).Header("Accept-Language", "fr").Expect(t).
+		Status(http.StatusBadRequest).Contains("grantee_type doit valoir user ou group")
+	s.POST("/admin/faults", `{"target":"disk","error":"x"}`).As(admin).Header("Accept-Language", "es").Expect(t).
+		Status(http.StatusBadRequest).Contains("Regla de fallo no válida")
+	s.POST("/admin/policies/reload", nil).As(admin).Header("Accept-Language", "de").Expect(t).
+		Status(http.StatusBadRequest).Field("error", "Es ist keine Richtliniendatei konfiguriert")
+	s.POST("/admin/api-keys", map[string]interface{}{"name": " ", "scopes": []string{"items:read"}}).As(admin).
+		Header("Accept-Language", "fr").Expect(t).
+		Status(http.StatusBadRequest).Field("error", "name est obligatoire")
+	s.POST("/admin/api-keys", map[string]interface{}{"name": "ci", "scopes": []string{}}).As(admin).
+		Header("Accept-Language", "es").Expect(t).
+		Status(http.StatusBadRequest).Field("error", "Se requiere al menos un ámbito")
+	s.POST("/admin/api-keys", map[string]interface{}{"name": "ci", "scopes": []string{"items:admin"}}).As(admin).
+		Header("Accept-Language", "de").Expect(t).
+		Status(http.StatusBadRequest).Contains("Unbekannter Scope; unterstützte Scopes: ")
+}
+
+func TestOwnershipAndSharing(t *testing.T) {
+	s := testutil.NewServer(t)
+	item := s.CreateItem(alice, "Desk", 300)
+	path := "/items/" + item.ID.String()
+
+	s.GET(path).As(bob).Expect(t).Status(http.StatusForbidden)
+	s.PUT(path, map[string]interface{}{"name": "Desk", "price": 1}).As(bob).Expect(t).Status(http.StatusForbidden)
+
+	var share models.ItemShare
+	s.POST(path+"/shares", map[string]string{"grantee_type": "group", "grantee_id": "buyers", "permission": "read"}).As(alice).
+		Expect(t).Status(http.StatusCreated).JSON(&share)
+	s.GET(path).As(bob).Expect(t).Status(http.StatusOK)
+	s.PUT(path, map[string]interface{}{"name": "Desk", "price": 1}).As(bob).Expect(t).Status(http.StatusForbidden)
+	s.POST(path+"/shares", map[string]string{"grantee_type": "user", "grantee_id": "carol", "permission": "read"}).As(bob).
+		Expect(t).Status(http.StatusForbidden)
+
+	var shares []models.ItemShare
+	s.GET(path + "/shares").As(alice).Expect(t).Status(http.StatusOK).JSON(&shares)
+	assert.Len(t, shares, 1)
+
+	s.DELETE(path + "/shares/" + share.ID.String()).As(alice).Expect(t).Status(http.StatusOK)
+	s.GET(path).As(bob).Expect(t).Status(http.StatusForbidden)
+
+	var mine []models.Item
+	s.CreateItem(bob, "Chair", 50)
+	s.GET("/items/?owner=me").As(bob).Expect(t).Status(http.StatusOK).JSON(&mine)
+	require.Len(t, mine, 1)
+	assert.Equal(t, "Chair", mine[0].Name)
+	s.GET(path).As(admin).Expect(t).Status(http.StatusOK)
+}
+
+// Items from before ownership was tracked can be read by anyone but only
+// changed by admins.
+func TestOwnership_UnownedItems(t *testing.T) {
+	s := testutil.NewServer(t)
+	item := models.Item{ID: uuid.New(), Name: "Legacy Lamp", Price: 20}
+	require.NoError(t, s.DB.Create(&item).Error)
+	path := "/items/" + item.ID.String()
+
+	s.GET(path).As(alice).Expect(t).Status(http.StatusOK)
+	s.PUT(path, map[string]interface{}{"name": "Mine Now", "price": 1}).As(alice).Expect(t).Status(http.StatusForbidden)
+	s.DELETE(path).As(alice).Expect(t).Status(http.StatusForbidden)
+	s.POST(path+"/shares", map[string]string{"grantee_type": "user", "grantee_id": "alice", "permission": "write"}).As(alice).
+		Expect(t).Status(http.StatusForbidden)
+
+	s.POST(path+"/shares", map[string]string{"grantee_type": "user", "grantee_id": "bob", "permission": "write"}).As(admin).
+		Expect(t).Status(http.StatusCreated)
+	s.PUT(path, map[string]interface{}{"name": "Legacy Lamp", "price": 25}).As(bob).Expect(t).Status(http.StatusOK)
+	s.DELETE(path).As(admin).Expect(t).Status(http.StatusOK)
+}
+
+// The server assigns item IDs, so a client cannot name an existing item,
+// or pick the ID of one it expects someone else to create.
+func TestOwnership_ServerAssignsIDs(t *testing.T) {
+	s := testutil.NewServer(t)
+	bobs := s.CreateItem(bob, "Chair", 50)
+
+	var created models.Item
+	s.POST("/items/", map[string]interface{}{"id": bobs.ID, "name": "Stolen Chair", "price": 1}).As(alice).
+		Expect(t).Status(http.StatusCreated).JSON(&created)
+	assert.NotEqual(t, bobs.ID, created.ID)
+	assert.Equal(t, "alice", created.OwnerID)
+	stored, ok := s.StoredItem(bobs.ID)
+	require.True(t, ok)
+	assert.Equal(t, "Chair", stored.Name)
+	assert.Equal(t, "bob", stored.OwnerID)
+}
+
+func TestCaching_Invalidate(t *testing.T) {
+	s := testutil.NewServer(t)
+	item := s.CreateItem(alice, "Lamp", 20)
+	path := "/items/" + item.ID.String()
+
+	assert.False(t, s.Redis.Has("item:"+item.ID.String()), "invalidate mode does not populate the cache on create")
+	s.GET(path).As(alice).Expect(t).Status(http.StatusOK)
+	cached, ok := s.CachedItem(item.ID)
+	require.True(t, ok, "a read populates the cache")
+	assert.Equal(t, "Lamp", cached.Name)
+
+	s.GET("/items/").As(alice).Expect(t).Status(http.StatusOK)
+	assert.True(t, s.Redis.Has("all_items"))
+
+	s.PUT(path, map[string]interface{}{"name": "Desk Lamp", "price": 25}).As(alice).Expect(t).Status(http.StatusOK)
+	assert.False(t, s.Redis.Has("item:"+item.ID.String()))
+	assert.False(t, s.Redis.Has("all_items"))
+
+	s.Redis.Set("item:"+item.ID.String(), []byte("garbage"))
+	var fetched models.Item
+	s.GET(path).As(alice).Expect(t).Status(http.StatusOK).JSON(&fetched)
+	assert.Equal(t, "Desk Lamp", fetched.Name, "undecodable entries are treated as misses")
+}
+
+func TestCaching_WriteThrough(t *testing.T) {
+	s := testutil.NewServer(t, testutil.WithCacheWriteMode(config.CacheWriteThrough))
+	item := s.CreateItem(alice, "Mug", 8)
+
+	cached, ok := s.CachedItem(item.ID)
+	require.True(t, ok)
+	assert.Equal(t, "Mug", cached.Name)
+
+	s.PUT("/items/"+item.ID.String(), map[string]interface{}{"name": "Big Mug", "price": 9}).As(alice).Expect(t).Status(http.StatusOK)
+	cached, _ = s.CachedItem(item.ID)
+	assert.Equal(t, "Big Mug", cached.Name)
+}
+
+func TestCaching_WriteBehind(t *testing.T) {
+	s := testutil.NewServer(t, testutil.WithCacheWriteMode(config.CacheWriteBehind))
+	item := s.CreateItem(alice, "Pen", 2)
+	s.GET("/items/search?q=pen").As(alice).Expect(t).Status(http.StatusOK)
+	require.True(t, s.Redis.Has("tag:items"))
+
+	s.PUT("/items/"+item.ID.String(), map[string]interface{}{"name": "Fountain Pen", "price": 40}).As(alice).Expect(t).Status(http.StatusOK)
+	stored, _ := s.StoredItem(item.ID)
+	assert.Equal(t, "Pen", stored.Name, "the update is buffered")
+	assert.False(t, s.Redis.Has("tag:items"), "cached query results are dropped")
+	var fetched models.Item
+	s.GET("/items/" + item.ID.String()).As(alice).Expect(t).Status(http.StatusOK).JSON(&fetched)
+	assert.Equal(t, "Fountain Pen", fetched.Name, "reads see the buffered value")
+
+	n, err := services.FlushWriteBehind(s.Context(admin), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	stored, _ = s.StoredItem(item.ID)
+	assert.Equal(t, "Fountain Pen", stored.Name)
+}
+
+// Buffered updates are audited when they are flushed, in the same
+// transaction, and not at all if the item is deleted first.
+func TestCaching_WriteBehindAudit(t *testing.T) {
+	s := testutil.NewServer(t, testutil.WithCacheWriteMode(config.CacheWriteBehind))
+	pen := s.CreateItem(alice, "Pen", 2)
+	mug := s.CreateItem(alice, "Mug", 5)
+	audit := func(id uuid.UUID) []string {
+		var page services.AuditPage
+		s.GET("/audit?item_id=" + id.String()).As(admin).Expect(t).Status(http.StatusOK).JSON(&page)
+		operations := make([]string, len(page.Records))
+		for i, record := range page.Records {
+			operations[i] = record.Operation
+		}
+		return operations
+	}
+
+	s.PUT("/items/"+pen.ID.String(), map[string]interface{}{"name": "Fountain Pen", "price": 40}).As(alice).Expect(t).Status(http.StatusOK)
+	s.PUT("/items/"+pen.ID.String(), map[string]interface{}{"name": "Fountain Pen", "price": 45}).As(alice).Expect(t).Status(http.StatusOK)
+	s.PUT("/items/"+mug.ID.String(), map[string]interface{}{"name": "Big Mug", "price": 6}).As(alice).Expect(t).Status(http.StatusOK)
+	s.DELETE("/items/" + mug.ID.String()).As(alice).Expect(t).Status(http.StatusOK)
+	assert.Equal(t, []string{"create"}, audit(pen.ID), "nothing is audited before the flush")
+
+	_, err := services.FlushWriteBehind(s.Context(admin), 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"update", "update", "create"}, audit(pen.ID), "every buffered update is audited")
+	assert.Equal(t, []string{"delete", "create"}, audit(mug.ID), "the update of a deleted item never happened")
+	s.GET("/audit/verify").As(admin).Expect(t).Status(http.StatusOK).Field("valid", true)
+
+	_, err = services.FlushWriteBehind(s.Context(admin), 1)
+	require.NoError(t, err)
+	assert.Len(t, audit(pen.ID), 3, "flushed records are not replayed")
+}
+
+// A flush must not use multi-key commands across cluster slots, and it must
+// drop the list cached from the database before the flush.
+func TestCaching_WriteBehindCluster(t *testing.T) {
+	s := testutil.NewServer(t, testutil.WithCacheWriteMode(config.CacheWriteBehind))
+	s.Redis.EnforceSlots(true)
+	item := s.CreateItem(alice, "Pen", 2)
+
+	s.PUT("/items/"+item.ID.String(), map[string]interface{}{"name": "Fountain Pen", "price": 40}).As(alice).Expect(t).Status(http.StatusOK)
+	var items []models.Item
+	s.GET("/items/").As(alice).Expect(t).Status(http.StatusOK).JSON(&items)
+	require.Len(t, items, 1)
+	assert.Equal(t, "Pen", items[0].Name, "the list is read from the database")
+
+	n, err := services.FlushWriteBehind(s.Context(admin), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.False(t, s.Redis.Has("{item:writebehind}:inflight"))
+	s.GET("/items/").As(alice).Expect(t).Status(http.StatusOK).JSON(&items)
+	assert.Equal(t, "Fountain Pen", items[0].Name, "the cached list was dropped")
+
+	n, err = services.FlushWriteBehind(s.Context(admin), 10)
+	require.NoError(t, err)
+	assert.Zero(t, n, "the batch is not replayed")
+}
+
+func TestAuditEndpoints(t *testing.T) {
+	s := testutil.NewServer(t)
+	item := s.CreateItem(alice, "Stapler", 12)
+	s.DELETE("/items/"+item.ID.String()).As(alice).Header("X-Request-ID", "req-audit").Expect(t).
+		Status(http.StatusOK).HasHeader("X-Request-ID", "req-audit")
+
+	s.GET("/audit").As(alice).Expect(t).Status(http.StatusForbidden)
+	var page services.AuditPage
+	s.GET("/audit?item_id=" + item.ID.String()).As(admin).Expect(t).Status(http.StatusOK).JSON(&page)
+	require.Len(t, page.Records, 2)
+	assert.Equal(t, models.AuditOperationDelete, page.Records[0].Operation)
+	assert.Equal(t, "req-audit", page.Records[0].RequestID)
+
+	s.GET("/audit/verify").As(admin).Expect(t).Status(http.StatusOK).Field("valid", true)
+}
+
+// Without trusted proxies the client IP comes from the connection, so
+// rotating X-Forwarded-For does not reset the per-IP quota.
+func TestRateLimit_IgnoresSpoofedForwardedFor(t *testing.T) {
+	s := testutil.NewServer(t)
+	require.NoError(t, middleware.ConfigureRateLimit(config.RateLimitConfig{Enabled: true, PerIP: "2/1m", PerAPIKey: "100/1m"}, nil))
+	t.Cleanup(func() { middleware.ConfigureRateLimit(config.RateLimitConfig{}, nil) })
+
+	for i, status := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		s.GET("/items/").As(alice).Header("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i+1)).
+			Expect(t).Status(status)
+	}
+}
+
+// A valid API key is only held to the API key quota, however many requests
+// come from its address. Invalid keys count against the IP quota.
+func TestRateLimit_APIKeysSkipIPQuota(t *testing.T) {
+	s := testutil.NewServer(t)
+	require.NoError(t, middleware.ConfigureRateLimit(config.RateLimitConfig{Enabled: true, PerIP: "2/1m", PerAPIKey: "100/1m"}, nil))
+	t.Cleanup(func() { middleware.ConfigureRateLimit(config.RateLimitConfig{}, nil) })
+	_, key, err := services.CreateAPIKey(context.Background(), "batch", []string{models.ScopeItemsRead}, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		s.GET("/items/").Header("Authorization", "ApiKey "+key).Expect(t).Status(http.StatusOK)
+	}
+	s.GET("/items/").Header("Authorization", "ApiKey "+key+"x").Expect(t).Status(http.StatusUnauthorized)
+	s.GET("/items/").Header("Authorization", "ApiKey "+key+"x").Expect(t).Status(http.StatusUnauthorized)
+	s.GET("/items/").Header("Authorization", "ApiKey "+key+"x").Expect(t).Status(http.StatusTooManyRequests)
+	s.GET("/items/").As(alice).Expect(t).Status(http.StatusTooManyRequests)
+}
+
+func TestMetricsEndpoint_RequiresAdmin(t *testing.T) {
+	s := testutil.NewServer(t)
+
+	s.GET("/metrics").Expect(t).Status(http.StatusUnauthorized)
+	s.GET("/metrics").As(alice).Expect(t).Status(http.StatusForbidden)
+	s.GET("/metrics").As(admin).Expect(t).Status(http.StatusOK)
+}
+
+func TestAdminEndpoints(t *testing.T) {
+	s := testutil.NewServer(t)
+
+	var created struct {
+		models.APIKey
+		Key string `json:"key"`
+	}
+	s.POST("/admin/api-keys", map[string]interface{}{"name": "ci", "scopes": []string{models.ScopeItemsRead}}).As(admin).
+		Expect(t).Status(http.StatusCreated).JSON(&created)
+	require.NotEmpty(t, created.Key)
+
+	s.GET("/items/").Header("Authorization", "ApiKey "+created.Key).Expect(t).Status(http.StatusOK)
+	s.POST("/items/", map[string]interface{}{"name": "Pen", "price": 1}).Header("Authorization", "ApiKey "+created.Key).
+		Expect(t).Status(http.StatusForbidden)
+
+	var keys []models.APIKey
+	s.GET("/admin/api-keys").As(admin).Expect(t).Status(http.StatusOK).JSON(&keys)
+	assert.Len(t, keys, 1)
+	s.DELETE("/admin/api-keys/" + created.ID.String()).As(admin).Expect(t).Status(http.StatusOK)
+	s.GET("/items/").Header("Authorization", "ApiKey "+created.Key).Expect(t).Status(http.StatusUnauthorized)
+
+	s.GET("/admin/api-keys").As(alice).Expect(t).Status(http.StatusForbidden)
+	s.POST("/admin/policies/reload", nil).As(admin).Expect(t).Status(http.StatusBadRequest)
+	s.GET("/admin/slow-queries").As(admin).Expect(t).Status(http.StatusOK)
+}
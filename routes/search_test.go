@@ -0,0 +1,176 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/services"
+	"github.com/rahulmishra/go-crud-app/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func search(t *testing.T, s *testutil.Server, u testutil.User, query string) services.SearchPage {
+	t.Helper()
+	var page services.SearchPage
+	s.GET("/items/search?" + query).As(u).Expect(t).Status(http.StatusOK).JSON(&page)
+	return page
+}
+
+func resultNames(page services.SearchPage) []string {
+	names := make([]string, len(page.Results))
+	for i, hit := range page.Results {
+		names[i] = hit.Item.Name
+	}
+	return names
+}
+
+func TestSearchItems(t *testing.T) {
+	s := testutil.NewServer(t)
+	for _, name := range []string{"Floor Lamp with Linen Shade", "Lamp", "Desk Lamp", "Lampshade", "Desk Organizer", "Clamp"} {
+		s.CreateItem(alice, name, 10)
+	}
+
+	page := search(t, s, alice, "q=lamp")
+	assert.Equal(t, 4, page.Total)
+	require.Len(t, page.Results, 4)
+	assert.Equal(t, "Lamp", page.Results[0].Item.Name, "the closest match ranks first")
+	assert.ElementsMatch(t, []string{"Lamp", "Desk Lamp", "Lampshade", "Floor Lamp with Linen Shade"}, resultNames(page))
+	for i := 1; i < len(page.Results); i++ {
+		assert.GreaterOrEqual(t, page.Results[i-1].Rank, page.Results[i].Rank)
+	}
+
+	page = search(t, s, alice, "q=DESK+la")
+	assert.Equal(t, []string{"Desk Lamp"}, resultNames(page), "every word must match")
+	assert.Equal(t, "<mark>Desk</mark> <mark>Lamp</mark>", page.Results[0].Snippet)
+
+	page = search(t, s, alice, "q=shade")
+	assert.Equal(t, []string{"Floor Lamp with Linen Shade"}, resultNames(page), "words match by prefix only")
+	assert.Equal(t, "Floor Lamp with Linen <mark>Shade</mark>", page.Results[0].Snippet)
+
+	assert.Empty(t, search(t, s, alice, "q=sofa").Results)
+}
+
+func TestSearchItems_EscapesSnippets(t *testing.T) {
+	s := testutil.NewServer(t)
+	s.CreateItem(alice, `Lamp <b>"Bright"</b>`, 10)
+
+	page := search(t, s, alice, "q=bright")
+	require.Len(t, page.Results, 1)
+	assert.Equal(t, `Lamp &lt;b&gt;&#34;<mark>Bright</mark>&#34;&lt;/b&gt;`, page.Results[0].Snippet)
+}
+
+func TestSearchItems_InvalidQuery(t *testing.T) {
+	s := testutil.NewServer(t)
+
+	s.GET("/items/search").As(alice).Expect(t).Status(http.StatusBadRequest).
+		Field("error", "q must contain at least one word")
+	s.GET("/items/search?q=%22*%3A%26").As(alice).Header("Accept-Language", "de").Expect(t).
+		Status(http.StatusBadRequest).Field("error", "q muss mindestens ein Wort enthalten")
+	s.GET("/items/search?q=lamp").Expect(t).Status(http.StatusUnauthorized)
+}
+
+func TestSearchItems_Pagination(t *testing.T) {
+	s := testutil.NewServer(t)
+	for i := 1; i <= 5; i++ {
+		s.CreateItem(alice, fmt.Sprintf("Lamp %d", i), 10)
+	}
+
+	first := search(t, s, alice, "q=lamp&page_size=2")
+	assert.Equal(t, 1, first.Page)
+	assert.Equal(t, 2, first.PageSize)
+	assert.Equal(t, 5, first.Total)
+	assert.Len(t, first.Results, 2)
+
+	var seen []string
+	for p := 1; p <= 3; p++ {
+		seen = append(seen, resultNames(search(t, s, alice, fmt.Sprintf("q=lamp&page_size=2&page=%d", p)))...)
+	}
+	assert.ElementsMatch(t, []string{"Lamp 1", "Lamp 2", "Lamp 3", "Lamp 4", "Lamp 5"}, seen)
+
+	last := search(t, s, alice, "q=lamp&page_size=2&page=4")
+	assert.Empty(t, last.Results)
+	assert.Equal(t, 5, last.Total)
+}
+
+// Matches are only fetched and cached for what the caller may read, so the
+// count and pages reflect it.
+func TestSearchItems_Visibility(t *testing.T) {
+	s := testutil.NewServer(t)
+	s.CreateItem(alice, "Alice Lamp", 10)
+	bobs := s.CreateItem(bob, "Bob Lamp", 10)
+
+	assert.Equal(t, []string{"Alice Lamp"}, resultNames(search(t, s, alice, "q=lamp")))
+	assert.Equal(t, 2, search(t, s, admin, "q=lamp").Total)
+
+	s.ShareItem(bob, bobs.ID, models.GranteeUser, "alice", models.PermissionRead)
+	assert.ElementsMatch(t, []string{"Alice Lamp", "Bob Lamp"}, resultNames(search(t, s, alice, "q=lamp")))
+}
+
+// The match cap applies to the items the caller may read, so other
+// people's matches cannot crowd theirs out.
+func TestSearchItems_CapAppliesAfterVisibility(t *testing.T) {
+	s := testutil.NewServer(t)
+	others := make([]models.Item, 1001)
+	for i := range others {
+		others[i] = models.Item{ID: uuid.New(), Name: "Widget", Price: 1, OwnerID: "bob"}
+	}
+	require.NoError(t, s.DB.CreateInBatches(others, 200).Error)
+	legacy := models.Item{ID: uuid.New(), Name: "Legacy Widget", Price: 1}
+	require.NoError(t, s.DB.Create(&legacy).Error)
+	for i := 0; i < 3; i++ {
+		s.CreateItem(alice, "Widget", 1)
+	}
+
+	page := search(t, s, alice, "q=widget")
+	assert.Equal(t, 4, page.Total, "alice's items and the unowned one")
+	assert.False(t, page.Truncated)
+
+	page = search(t, s, admin, "q=widget")
+	assert.Equal(t, 1000, page.Total)
+	assert.True(t, page.Truncated, "the response says more items matched")
+}
+
+func TestSearchItems_Cache(t *testing.T) {
+	for _, mode := range cacheModes {
+		t.Run(mode, func(t *testing.T) {
+			s := testutil.NewServer(t, testutil.WithCacheWriteMode(mode))
+			item := s.CreateItem(alice, "Desk Lamp", 10)
+
+			assert.Equal(t, 1, search(t, s, alice, "q=lamp").Total)
+			assert.True(t, hasKeyPrefix(s.Redis.Keys(), "query:search:"))
+
+			// Changes made behind the service's back are not seen until the
+			// cached result is invalidated.
+			require.NoError(t, s.DB.Model(&models.Item{}).Where("id = ?", item.ID).Update("name", "Desk Light").Error)
+			assert.Equal(t, 1, search(t, s, alice, "q=LAMP").Total, "served from the cache")
+
+			s.PUT("/items/"+item.ID.String(), map[string]interface{}{"name": "Desk Light", "price": 12}).As(alice).
+				Expect(t).Status(http.StatusOK)
+			if mode == cacheModes[2] {
+				_, err := services.FlushWriteBehind(s.Context(admin), 0)
+				require.NoError(t, err)
+			}
+			assert.Zero(t, search(t, s, alice, "q=lamp").Total)
+			assert.Equal(t, []string{"Desk Light"}, resultNames(search(t, s, alice, "q=light")))
+
+			s.CreateItem(alice, "Reading Light", 10)
+			assert.Equal(t, 2, search(t, s, alice, "q=light").Total)
+			s.DELETE("/items/" + item.ID.String()).As(alice).Expect(t).Status(http.StatusOK)
+			assert.Equal(t, []string{"Reading Light"}, resultNames(search(t, s, alice, "q=light")))
+		})
+	}
+}
+
+func hasKeyPrefix(keys []string, prefix string) bool {
+	for _, key := range keys {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
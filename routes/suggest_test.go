@@ -0,0 +1,157 @@
+package routes_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rahulmishra/go-crud-app/gormhooks"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/seed"
+	"github.com/rahulmishra/go-crud-app/services"
+	"github.com/rahulmishra/go-crud-app/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func suggest(t *testing.T, s *testutil.Server, u testutil.User, query string) []string {
+	t.Helper()
+	var suggestions []services.Suggestion
+	s.GET("/items/suggest?" + query).As(u).Expect(t).Status(http.StatusOK).JSON(&suggestions)
+	names := make([]string, len(suggestions))
+	for i, suggestion := range suggestions {
+		names[i] = suggestion.Name
+	}
+	return names
+}
+
+func TestSuggestItems(t *testing.T) {
+	s := testutil.NewServer(t)
+	for _, name := range []string{"lamp shade", "Desk Lamp", "Lamp", "LAMPION", "Lantern", "Ölkanne"} {
+		s.CreateItem(alice, name, 10)
+	}
+
+	assert.Equal(t, []string{"Lamp", "lamp shade", "LAMPION"}, suggest(t, s, alice, "prefix=LAMP"))
+	assert.Equal(t, []string{"Lamp", "lamp shade"}, suggest(t, s, alice, "prefix=lamp&limit=2"))
+	assert.Equal(t, []string{"Lamp", "lamp shade", "LAMPION", "Lantern"}, suggest(t, s, alice, "prefix=la"))
+	assert.Equal(t, []string{"Ölkanne"}, suggest(t, s, alice, "prefix=öl"))
+	assert.Empty(t, suggest(t, s, alice, "prefix=sofa"))
+
+	s.GET("/items/suggest").As(alice).Expect(t).Status(http.StatusBadRequest).Field("error", "prefix must not be empty")
+	s.GET("/items/suggest?prefix=+").As(alice).Header("Accept-Language", "fr").Expect(t).
+		Status(http.StatusBadRequest).Field("error", "prefix ne doit pas être vide")
+}
+
+func TestSuggestItems_FollowsMutations(t *testing.T) {
+	for _, mode := range cacheModes {
+		t.Run(mode, func(t *testing.T) {
+			s := testutil.NewServer(t, testutil.WithCacheWriteMode(mode))
+			item := s.CreateItem(alice, "Desk Lamp", 10)
+			assert.Equal(t, []string{"Desk Lamp"}, suggest(t, s, alice, "prefix=desk"))
+
+			s.PUT("/items/"+item.ID.String(), map[string]interface{}{"name": "Reading Lamp", "price": 12}).As(alice).
+				Expect(t).Status(http.StatusOK)
+			assert.Empty(t, suggest(t, s, alice, "prefix=desk"))
+			assert.Equal(t, []string{"Reading Lamp"}, suggest(t, s, alice, "prefix=read"))
+
+			s.PUT("/items/"+item.ID.String(), map[string]interface{}{"name": "Reading Lamp", "price": 15}).As(alice).
+				Expect(t).Status(http.StatusOK)
+			assert.Equal(t, []string{"Reading Lamp"}, suggest(t, s, alice, "prefix=read"), "a price change keeps one entry")
+
+			s.DELETE("/items/" + item.ID.String()).As(alice).Expect(t).Status(http.StatusOK)
+			assert.Empty(t, suggest(t, s, alice, "prefix=read"))
+			assert.NotContains(t, s.Redis.Keys(), "{suggest:items}", "the last entry removes the index")
+		})
+	}
+}
+
+// Items the caller may not read are skipped, even when they fill whole
+// batches of the index ahead of a readable one.
+func TestSuggestItems_Visibility(t *testing.T) {
+	s := testutil.NewServer(t)
+	for i := 0; i < 60; i++ {
+		s.CreateItem(bob, "Lamp "+strings.Repeat("a", i+1), 10)
+	}
+	s.CreateItem(alice, "Lamp z", 10)
+	shared := s.CreateItem(bob, "Lamp zz", 10)
+
+	shareLookups := 0
+	require.NoError(t, gormhooks.Register(s.DB, "test_share_lookups", nil, func(string) func(*gorm.DB) {
+		return func(db *gorm.DB) {
+			if db.Statement.Table == "item_shares" {
+				shareLookups++
+			}
+		}
+	}))
+	assert.Equal(t, []string{"Lamp z"}, suggest(t, s, alice, "prefix=lamp"))
+	assert.Equal(t, 1, shareLookups, "the shares are loaded once, not per batch")
+	assert.Equal(t, []string{"Lamp z"}, suggest(t, s, alice, "prefix=lamp"))
+	assert.Equal(t, 1, shareLookups, "the caller's shares are cached")
+	s.ShareItem(bob, shared.ID, models.GranteeGroup, "buyers", models.PermissionRead)
+	s.ShareItem(bob, shared.ID, models.GranteeUser, "alice", models.PermissionRead)
+	shareLookups = 0
+	assert.Equal(t, []string{"Lamp z", "Lamp zz"}, suggest(t, s, alice, "prefix=lamp"), "sharing drops the cached shares")
+	assert.Equal(t, 1, shareLookups)
+	assert.Len(t, suggest(t, s, admin, "prefix=lamp&limit=100"), 50, "the limit is capped")
+}
+
+// Policy rules can refer to any item attribute, so the index keeps the
+// price for rules such as this one.
+func TestSuggestItems_PolicyReadsPrice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`rules:
+  - name: read-with-scope
+    effect: allow
+    actions: [read]
+    scopes: [items:read]
+  - name: write-with-scope
+    effect: allow
+    actions: [create, update, delete]
+    scopes: [items:write]
+  - name: hide-expensive-items
+    effect: deny
+    actions: [read]
+    conditions:
+      - field: resource.price
+        op: gt
+        value: 100
+`), 0o600))
+	s := testutil.NewServer(t, testutil.WithPolicyFile(path))
+	s.CreateItem(alice, "Lamp", 20)
+	s.CreateItem(alice, "Lamp Deluxe", 500)
+
+	assert.Equal(t, []string{"Lamp"}, suggest(t, s, alice, "prefix=lamp"))
+}
+
+func TestRebuildSuggestIndex(t *testing.T) {
+	s := testutil.NewServer(t)
+	data := s.Seed(seed.Options{Count: 120, Seed: 5})
+	item := data.Items[0]
+
+	// Rename behind the service's back and leave a stray entry.
+	require.NoError(t, s.DB.Model(&models.Item{}).Where("id = ?", item.ID).Update("name", "Zeppelin Model").Error)
+	assert.Empty(t, suggest(t, s, admin, "prefix=zeppelin"))
+
+	indexed, err := services.RebuildSuggestIndex(context.Background(), 50)
+	require.NoError(t, err)
+	assert.Equal(t, 120, indexed)
+	assert.Equal(t, []string{"Zeppelin Model"}, suggest(t, s, admin, "prefix=zeppelin"))
+	for _, name := range suggest(t, s, admin, "prefix="+strings.Fields(item.Name)[0]+"&limit=50") {
+		assert.NotEqual(t, item.Name, name, "the old name was dropped")
+	}
+
+	s.Redis.EnforceSlots(true)
+	_, err = services.RebuildSuggestIndex(context.Background(), 50)
+	require.NoError(t, err, "the rebuild works in cluster mode")
+	s.Redis.EnforceSlots(false)
+
+	require.NoError(t, s.DB.Where("1 = 1").Delete(&models.Item{}).Error)
+	indexed, err = services.RebuildSuggestIndex(context.Background(), 50)
+	require.NoError(t, err)
+	assert.Zero(t, indexed)
+	assert.Empty(t, suggest(t, s, admin, "prefix=a"))
+}
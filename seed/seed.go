@@ -0,0 +1,132 @@
+// Package seed generates a deterministic catalog of items, and optionally
+// shares, for demos, benchmarks and tests.
+//
+// The same Options always produce the same data, including IDs, so seeding
+// is idempotent: running it again inserts only what is missing.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/services"
+)
+
+// namespace scopes the name-based UUIDs of generated rows.
+var namespace = uuid.MustParse("8f0c3b7e-5d1a-4e59-9a8e-2f6c1d4b7a90")
+
+type Options struct {
+	// Count is the number of items to generate.
+	Count int
+	// Seed selects the data set. Different seeds generate different items
+	// with different IDs.
+	Seed uint64
+	// BatchSize is the number of rows per INSERT. The default is 500.
+	BatchSize int
+	// Owners are assigned to items at random. When it is empty the items
+	// are unowned, so only admins can change them.
+	Owners []string
+	// Groups, when set, receive read or write shares on about one item in
+	// five.
+	Groups []string
+}
+
+// Dataset is the generated data, in insertion order.
+type Dataset struct {
+	Items  []models.Item
+	Shares []models.ItemShare
+}
+
+// product is a kind of item and its typical price.
+type product struct {
+	noun string
+	base float64
+}
+
+var (
+	products = []product{
+		{"Desk Lamp", 39}, {"Standing Desk", 349}, {"Office Chair", 189}, {"Bookshelf", 149},
+		{"Area Rug", 129}, {"Coffee Mug", 14}, {"Notebook", 9}, {"Backpack", 69},
+		{"Headphones", 119}, {"Mechanical Keyboard", 99}, {"Monitor", 279}, {"Water Bottle", 24},
+		{"Blender", 89}, {"Kettle", 45}, {"Frying Pan", 49}, {"Sneakers", 95},
+		{"Rain Jacket", 139}, {"Wristwatch", 219}, {"Sunglasses", 79}, {"Camping Tent", 249},
+		{"Throw Pillow", 29}, {"Wall Clock", 35}, {"Desk Organizer", 19}, {"Bluetooth Speaker", 59},
+	}
+	adjectives = []string{
+		"Classic", "Compact", "Deluxe", "Ergonomic", "Vintage", "Modern", "Portable",
+		"Premium", "Rustic", "Minimalist", "Lightweight", "Heritage", "Everyday", "Studio",
+	}
+	finishes = []string{
+		"Oak", "Walnut", "Steel", "Bamboo", "Leather", "Linen", "Ceramic", "Graphite",
+		"Navy", "Sage", "Copper", "Matte Black", "Ivory", "Terracotta",
+	}
+)
+
+// Generate builds the data set described by opts without touching the
+// database.
+func Generate(opts Options) Dataset {
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x5eed))
+	data := Dataset{Items: make([]models.Item, 0, opts.Count)}
+	for i := 0; i < opts.Count; i++ {
+		p := products[rng.IntN(len(products))]
+		item := models.Item{
+			ID:    rowID("item", opts.Seed, i),
+			Name:  fmt.Sprintf("%s %s %s", adjectives[rng.IntN(len(adjectives))], finishes[rng.IntN(len(finishes))], p.noun),
+			Price: price(rng, p.base),
+		}
+		if len(opts.Owners) > 0 {
+			item.OwnerID = opts.Owners[rng.IntN(len(opts.Owners))]
+		}
+		data.Items = append(data.Items, item)
+
+		if len(opts.Groups) > 0 && rng.IntN(5) == 0 {
+			permission := models.PermissionRead
+			if rng.IntN(4) == 0 {
+				permission = models.PermissionWrite
+			}
+			data.Shares = append(data.Shares, models.ItemShare{
+				ID:          rowID("share", opts.Seed, i),
+				ItemID:      item.ID,
+				GranteeType: models.GranteeGroup,
+				GranteeID:   opts.Groups[rng.IntN(len(opts.Groups))],
+				Permission:  permission,
+				CreatedBy:   item.OwnerID,
+			})
+		}
+	}
+	return data
+}
+
+func rowID(kind string, seed uint64, i int) uuid.UUID {
+	return uuid.NewSHA1(namespace, []byte(fmt.Sprintf("%s/%d/%d", kind, seed, i)))
+}
+
+// price draws from a log-normal distribution around base, so most prices
+// are near the typical one with a long tail of expensive variants, and
+// rounds to a retail price: x.99 below 100 and x4.99 or x9.99 above.
+func price(rng *rand.Rand, base float64) float64 {
+	p := base * math.Exp(0.35*rng.NormFloat64())
+	if p < 100 {
+		p = math.Max(1, math.Round(p))
+	} else {
+		p = math.Round(p/5) * 5
+	}
+	return math.Round(p*100-1) / 100
+}
+
+// Insert stores data through the service layer in batches, skipping rows
+// that already exist.
+func Insert(ctx context.Context, data Dataset, batchSize int) (services.ImportResult, error) {
+	return services.ImportItems(ctx, data.Items, data.Shares, batchSize)
+}
+
+// Run generates the data set described by opts and inserts it.
+func Run(ctx context.Context, opts Options) (Dataset, services.ImportResult, error) {
+	data := Generate(opts)
+	result, err := Insert(ctx, data, opts.BatchSize)
+	return data, result, err
+}
@@ -0,0 +1,130 @@
+package seed_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/seed"
+	"github.com/rahulmishra/go-crud-app/testutil"
+	"github.com/rahulmishra/go-crud-app/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_IsDeterministic(t *testing.T) {
+	opts := seed.Options{Count: 200, Seed: 42, Owners: []string{"alice", "bob"}, Groups: []string{"buyers"}}
+	first := seed.Generate(opts)
+	assert.Equal(t, first, seed.Generate(opts))
+
+	opts.Count = 50
+	assert.Equal(t, first.Items[:50], seed.Generate(opts).Items, "a smaller count is a prefix")
+
+	other := seed.Generate(seed.Options{Count: 200, Seed: 43})
+	assert.NotEqual(t, first.Items[0].ID, other.Items[0].ID)
+	assert.NotEqual(t, first.Items[0].Name, other.Items[0].Name)
+}
+
+func TestGenerate_RealisticItems(t *testing.T) {
+	validation.Setup()
+	data := seed.Generate(seed.Options{Count: 1000, Seed: 1, Owners: []string{"alice", "bob"}, Groups: []string{"buyers", "support"}})
+	require.Len(t, data.Items, 1000)
+
+	prices := make([]float64, 0, len(data.Items))
+	ids := map[string]bool{}
+	for _, item := range data.Items {
+		require.NoError(t, validation.Struct(&item), "%+v", item)
+		assert.GreaterOrEqual(t, len(strings.Fields(item.Name)), 3, "adjective, finish and product: %q", item.Name)
+		assert.Contains(t, []string{"alice", "bob"}, item.OwnerID)
+		ids[item.ID.String()] = true
+		prices = append(prices, item.Price)
+	}
+	assert.Len(t, ids, 1000, "IDs are unique")
+
+	sort.Float64s(prices)
+	assert.GreaterOrEqual(t, prices[0], 0.99)
+	assert.InDelta(t, 70, prices[len(prices)/2], 50, "median price")
+	assert.Greater(t, prices[len(prices)-1], 300.0, "there is a tail of expensive items")
+
+	assert.InDelta(t, 200, len(data.Shares), 60, "about one item in five is shared")
+	for _, share := range data.Shares {
+		assert.Equal(t, models.GranteeGroup, share.GranteeType)
+		assert.Contains(t, []string{models.PermissionRead, models.PermissionWrite}, share.Permission)
+	}
+}
+
+func TestRun_IsIdempotent(t *testing.T) {
+	s := testutil.NewServer(t)
+	opts := seed.Options{Count: 120, Seed: 7, BatchSize: 50, Owners: []string{"alice"}, Groups: []string{"buyers"}}
+
+	data, result, err := seed.Run(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, int64(120), result.Items)
+	assert.Equal(t, int64(len(data.Shares)), result.Shares)
+
+	_, result, err = seed.Run(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Zero(t, result.Items)
+	assert.Zero(t, result.Shares)
+
+	opts.Count = 150
+	_, result, err = seed.Run(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, int64(30), result.Items, "only the missing items are inserted")
+	extended := result
+
+	var count int64
+	require.NoError(t, s.DB.Model(&models.Item{}).Count(&count).Error)
+	assert.Equal(t, int64(150), count)
+	stored, ok := s.StoredItem(data.Items[0].ID)
+	require.True(t, ok)
+	assert.Equal(t, data.Items[0], stored)
+
+	var audits []models.AuditRecord
+	require.NoError(t, s.DB.Order("id").Find(&audits).Error)
+	require.Len(t, audits, 2, "one record per import that inserted rows")
+	assert.Equal(t, models.AuditOperationImport, audits[0].Operation)
+	assert.Equal(t, uuid.Nil, audits[0].ItemID)
+	assert.JSONEq(t, fmt.Sprintf(`{"items":120,"shares":%d}`, len(data.Shares)), string(audits[0].After))
+	assert.JSONEq(t, fmt.Sprintf(`{"items":30,"shares":%d}`, extended.Shares), string(audits[1].After))
+}
+
+func TestInsert_ValidatesItems(t *testing.T) {
+	s := testutil.NewServer(t)
+	data := seed.Generate(seed.Options{Count: 3, Seed: 1, Owners: []string{"alice"}})
+	data.Items[1].Price = -5
+
+	_, err := seed.Insert(context.Background(), data, 10)
+	var fields validation.Errors
+	require.ErrorAs(t, err, &fields)
+	assert.Contains(t, err.Error(), data.Items[1].ID.String())
+
+	var count int64
+	require.NoError(t, s.DB.Model(&models.Item{}).Count(&count).Error)
+	assert.Zero(t, count, "nothing is stored when any item is invalid")
+}
+
+func TestServerSeed(t *testing.T) {
+	s := testutil.NewServer(t)
+	var before []models.Item
+	s.GET("/items/").As(testutil.Admin("root")).Expect(t).JSON(&before)
+	assert.Empty(t, before)
+
+	data := s.Seed(seed.Options{Count: 30, Seed: 1, Groups: []string{"buyers"}})
+
+	var items []models.Item
+	s.GET("/items/").As(testutil.Admin("root")).Expect(t).JSON(&items)
+	assert.ElementsMatch(t, data.Items, items, "the cached list was invalidated")
+
+	var mine []models.Item
+	s.GET("/items/?owner=me").As(testutil.Editor("alice")).Expect(t).JSON(&mine)
+	assert.NotEmpty(t, mine, "owners default to the fixture users")
+
+	var shares int64
+	require.NoError(t, s.DB.Model(&models.ItemShare{}).Count(&shares).Error)
+	assert.Equal(t, int64(len(data.Shares)), shares)
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/repository"
+)
+
+var (
+	ErrItemNotFound = errors.New("item not found")
+	ErrForbidden    = errors.New("access to item denied")
+)
+
+// Actor is the caller on whose behalf a service function runs.
+type Actor struct {
+	ID     string
+	Groups []string
+	// Admin bypasses ownership checks.
+	Admin bool
+}
+
+type actorKey struct{}
+
+// WithActor attaches actor to ctx. Service calls made without an actor, such
+// as from CLI commands and background workers, are not subject to ownership
+// checks.
+func WithActor(ctx context.Context, actor *Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFrom(ctx context.Context) *Actor {
+	actor, _ := ctx.Value(actorKey{}).(*Actor)
+	return actor
+}
+
+// checkItemAccess returns ErrForbidden unless the actor in ctx owns item, is
+// an admin, or was granted permission through a share. Items without an
+// owner, such as rows from before ownership was tracked, can be read by
+// everyone but only changed by admins or through a share.
+func checkItemAccess(ctx context.Context, item *models.Item, permission string) error {
+	actor := ActorFrom(ctx)
+	if actor == nil || actor.Admin || (item.OwnerID != "" && item.OwnerID == actor.ID) {
+		return nil
+	}
+	if item.OwnerID == "" && permission == models.PermissionRead {
+		return nil
+	}
+
+	var shares []models.ItemShare
+	if err := repository.GetItemShares(ctx, item.ID, &shares); err != nil {
+		return err
+	}
+	for _, share := range shares {
+		if actor.isGrantee(share) && grants(share.Permission, permission) {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
+
+// filterVisible drops the items the actor in ctx may not read.
+func filterVisible(ctx context.Context, items []models.Item) ([]models.Item, error) {
+	canRead, err := readableBy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	visible := make([]models.Item, 0, len(items))
+	for _, item := range items {
+		if canRead(&item) {
+			visible = append(visible, item)
+		}
+	}
+	return visible, nil
+}
+
+// readableBy loads the shares of the actor in ctx once and returns a check
+// of whether they may read an item, for callers that filter several batches.
+func readableBy(ctx context.Context) (func(*models.Item) bool, error) {
+	actor := ActorFrom(ctx)
+	if actor == nil || actor.Admin {
+		return func(*models.Item) bool { return true }, nil
+	}
+
+	ids, err := sharedItemIDs(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	shared := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		shared[id] = true
+	}
+	return func(item *models.Item) bool {
+		return item.OwnerID == "" || item.OwnerID == actor.ID || shared[item.ID]
+	}, nil
+}
+
+// sharedItemIDs returns the IDs of the items shared with actor or their
+// groups. The result is cached per grantee under sharesTag, so warm callers
+// do not query item_shares.
+func sharedItemIDs(ctx context.Context, actor *Actor) ([]uuid.UUID, error) {
+	groups := slices.Sorted(slices.Values(actor.Groups))
+	redisKey, cacheable := queryCacheKey(ctx, "shared", []string{sharesTag}, actor.ID, strings.Join(groups, ","))
+	var ids []uuid.UUID
+	if cacheable && readCache(ctx, redisKey, &ids) {
+		return ids, nil
+	}
+
+	var shares []models.ItemShare
+	if err := repository.GetSharesForGrantee(ctx, actor.ID, actor.Groups, &shares); err != nil {
+		return nil, err
+	}
+	ids = make([]uuid.UUID, len(shares))
+	for i, share := range shares {
+		ids[i] = share.ItemID
+	}
+	if cacheable {
+		writeCache(ctx, redisKey, ids)
+	}
+	return ids, nil
+}
+
+// searchViewer returns the visibility filter for searches by the actor in
+// ctx, or nil when they may read every item.
+func searchViewer(ctx context.Context) *repository.SearchViewer {
+	actor := ActorFrom(ctx)
+	if actor == nil || actor.Admin {
+		return nil
+	}
+	return &repository.SearchViewer{UserID: actor.ID, Groups: actor.Groups}
+}
+
+func (actor *Actor) isGrantee(share models.ItemShare) bool {
+	switch share.GranteeType {
+	case models.GranteeUser:
+		return share.GranteeID == actor.ID
+	case models.GranteeGroup:
+		return slices.Contains(actor.Groups, share.GranteeID)
+	default:
+		return false
+	}
+}
+
+func grants(granted, wanted string) bool {
+	return granted == wanted || granted == models.PermissionWrite
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/repository"
+	"github.com/rahulmishra/go-crud-app/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/crypto/argon2"
+)
+
+var (
+	ErrInvalidAPIKey        = errors.New("invalid API key")
+	ErrAPIKeyNameRequired   = errors.New("name is required")
+	ErrAPIKeyScopesRequired = errors.New("at least one scope is required")
+	ErrUnknownScope         = errors.New("unknown scope")
+)
+
+// Argon2id parameters for API key hashes. Keys carry 256 bits of entropy, so
+// these are tuned for per-request verification rather than password storage.
+const (
+	argonTime    = 1
+	argonMemory  = 8 * 1024
+	argonThreads = 1
+	argonKeyLen  = 32
+)
+
+// lastUsedResolution limits how often a key's last_used_at is written.
+const lastUsedResolution = time.Minute
+
+// CreateAPIKey issues a new key and returns it with the plaintext secret,
+// which is never stored and cannot be recovered later.
+func CreateAPIKey(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (key *models.APIKey, plaintext string, err error) {
+	ctx, span := tracing.Start(ctx, "services.CreateAPIKey")
+	defer func() { tracing.End(span, err) }()
+
+	if strings.TrimSpace(name) == "" {
+		return nil, "", ErrAPIKeyNameRequired
+	}
+	if len(scopes) == 0 {
+		return nil, "", ErrAPIKeyScopesRequired
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(models.KnownScopes, scope) {
+			return nil, "", fmt.Errorf("%w %q", ErrUnknownScope, scope)
+		}
+	}
+
+	prefix, err := randomBytes(6)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomBytes(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key = &models.APIKey{
+		Name:      name,
+		Prefix:    hex.EncodeToString(prefix),
+		Hash:      hashAPIKeySecret(secret),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := repository.CreateAPIKey(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, key.Prefix + "." + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func ListAPIKeys(ctx context.Context) (keys []models.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "services.ListAPIKeys")
+	defer func() { tracing.End(span, err) }()
+
+	err = repository.ListAPIKeys(ctx, &keys)
+	return keys, err
+}
+
+func RevokeAPIKey(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "services.RevokeAPIKey", attribute.String("api_key.id", id.String()))
+	defer func() { tracing.End(span, err) }()
+
+	return repository.RevokeAPIKey(ctx, id, time.Now().UTC())
+}
+
+// AuthenticateAPIKey resolves a plaintext key to its record. Unknown,
+// revoked, expired and mismatching keys all return ErrInvalidAPIKey.
+func AuthenticateAPIKey(ctx context.Context, plaintext string) (authenticated *models.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "services.AuthenticateAPIKey")
+	defer func() { tracing.End(span, err) }()
+
+	prefix, encodedSecret, ok := strings.Cut(plaintext, ".")
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(encodedSecret)
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var key models.APIKey
+	if err := repository.GetAPIKeyByPrefix(ctx, prefix, &key); err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if !verifyAPIKeySecret(key.Hash, secret) {
+		return nil, ErrInvalidAPIKey
+	}
+	now := time.Now().UTC()
+	if !key.Active(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := repository.TouchAPIKey(ctx, key.ID, now); err != nil {
+			slog.WarnContext(ctx, "Failed to record API key usage", "api_key_id", key.ID, "error", err)
+		}
+		key.LastUsedAt = &now
+	}
+	return &key, nil
+}
+
+func hashAPIKeySecret(secret []byte) string {
+	salt, _ := randomBytes(16)
+	hash := argon2.IDKey(secret, salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	return "argon2id$" + base64.RawStdEncoding.EncodeToString(salt) + "$" + base64.RawStdEncoding.EncodeToString(hash)
+}
+
+func verifyAPIKeySecret(stored string, secret []byte) bool {
+	parts := strings.Split(stored, "$")
+	if len(parts) != 3 || parts[0] != "argon2id" {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	got := argon2.IDKey(secret, salt, argonTime, argonMemory, argonThreads, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	return b, err
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rahulmishra/go-crud-app/config"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupAPIKeyDB(t *testing.T) *gorm.DB {
+	mockDB, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	config.SetDB(mockDB)
+
+	err = mockDB.AutoMigrate(&models.APIKey{})
+	if err != nil {
+		t.Fatalf("Failed to migrate database schema: %v", err)
+	}
+	return mockDB
+}
+
+func TestCreateAndAuthenticateAPIKey(t *testing.T) {
+	mockDB := setupAPIKeyDB(t)
+
+	key, plaintext, err := CreateAPIKey(context.Background(), "partner", []string{models.ScopeItemsRead}, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(plaintext, key.Prefix+"."))
+
+	var stored models.APIKey
+	mockDB.First(&stored, "id = ?", key.ID)
+	assert.NotContains(t, stored.Hash, strings.SplitN(plaintext, ".", 2)[1], "secret must not be stored in plaintext")
+
+	authenticated, err := AuthenticateAPIKey(context.Background(), plaintext)
+	assert.NoError(t, err)
+	assert.Equal(t, key.ID, authenticated.ID)
+	assert.Equal(t, []string{models.ScopeItemsRead}, authenticated.Scopes)
+
+	mockDB.First(&stored, "id = ?", key.ID)
+	assert.NotNil(t, stored.LastUsedAt)
+
+	_, err = AuthenticateAPIKey(context.Background(), key.Prefix+".AAAA")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	assert.NoError(t, RevokeAPIKey(context.Background(), key.ID))
+	_, err = AuthenticateAPIKey(context.Background(), plaintext)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	assert.Error(t, RevokeAPIKey(context.Background(), key.ID), "revoking twice reports not found")
+}
+
+func TestAuthenticateAPIKey_Expired(t *testing.T) {
+	setupAPIKeyDB(t)
+
+	past := time.Now().Add(-time.Hour)
+	_, plaintext, err := CreateAPIKey(context.Background(), "old", []string{models.ScopeItemsWrite}, &past)
+	assert.NoError(t, err)
+
+	_, err = AuthenticateAPIKey(context.Background(), plaintext)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+}
+
+func TestCreateAPIKey_RejectsUnknownScopes(t *testing.T) {
+	setupAPIKeyDB(t)
+
+	_, _, err := CreateAPIKey(context.Background(), "bad", []string{models.ScopeAdmin}, nil)
+	assert.Error(t, err)
+	_, _, err = CreateAPIKey(context.Background(), "", []string{models.ScopeItemsRead}, nil)
+	assert.Error(t, err)
+}
@@ -2,101 +2,234 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
-	"time"
+	"log/slog"
 
 	"github.com/google/uuid"
 	"github.com/rahulmishra/go-crud-app/config"
 	"github.com/rahulmishra/go-crud-app/models"
 	"github.com/rahulmishra/go-crud-app/repository"
+	"github.com/rahulmishra/go-crud-app/tracing"
+	"github.com/rahulmishra/go-crud-app/validation"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
 )
 
-func CreateItem(item *models.Item) error {
-	if item.Name == "" || item.Price <= 0 {
-		return errors.New("invalid item data")
+// ItemQuery narrows the result of GetAllItems.
+type ItemQuery struct {
+	// OwnerID, when set, only returns items owned by that subject.
+	OwnerID string
+}
+
+func CreateItem(ctx context.Context, item *models.Item) (err error) {
+	ctx, span := tracing.Start(ctx, "services.CreateItem", attribute.String("item.id", item.ID.String()))
+	defer func() { tracing.End(span, err) }()
+
+	if err := validation.Struct(item); err != nil {
+		return err
+	}
+	item.OwnerID = ""
+	if actor := ActorFrom(ctx); actor != nil {
+		item.OwnerID = actor.ID
 	}
-	err := repository.CreateItem(item)
+	err = repository.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := repository.CreateItem(ctx, item); err != nil {
+			return err
+		}
+		return recordAudit(ctx, models.AuditOperationCreate, item.ID, nil, item)
+	})
 	if err == nil {
-		config.RedisClient.Del(context.Background(), "all_items")
+		if cacheWriteMode != config.CacheWriteInvalidate {
+			writeCache(ctx, itemCacheKey(item.ID), item)
+		}
+		invalidateCache(ctx, allItemsCacheKey)
+		invalidateTag(ctx, itemsTag)
+		indexSuggestions(ctx, *item)
 	}
 	return err
 }
 
-func GetAllItems() ([]models.Item, error) {
-	ctx := context.Background()
-	redisKey := "all_items"
+// GetAllItems returns the items visible to the actor in ctx. The cached list
+// holds every item and is filtered per caller, so one caller's view is never
+// served to another.
+func GetAllItems(ctx context.Context, query ItemQuery) (visible []models.Item, err error) {
+	ctx, span := tracing.Start(ctx, "services.GetAllItems")
+	defer func() { tracing.End(span, err) }()
 
-	cachedItems, err := config.RedisClient.Get(ctx, redisKey).Result()
-	if err == nil {
-		var items []models.Item
-		json.Unmarshal([]byte(cachedItems), &items)
-		fmt.Println("Cache hit for all items")
-		return items, nil
+	items, err := loadAllItems(ctx)
+	if err != nil {
+		return nil, err
 	}
 
+	if query.OwnerID != "" {
+		owned := make([]models.Item, 0, len(items))
+		for _, item := range items {
+			if item.OwnerID == query.OwnerID {
+				owned = append(owned, item)
+			}
+		}
+		items = owned
+	}
+	return filterVisible(ctx, items)
+}
+
+func loadAllItems(ctx context.Context) ([]models.Item, error) {
+	redisKey := allItemsCacheKey
+
 	var items []models.Item
-	err = repository.GetAllItems(&items)
+	if readCache(ctx, redisKey, &items) {
+		slog.DebugContext(ctx, "Cache hit", "key", redisKey)
+		return items, nil
+	}
+
+	err := repository.GetAllItems(ctx, &items)
 	if err != nil {
 		return nil, err
 	}
 
-	itemsJSON, _ := json.Marshal(items)
-	config.RedisClient.Set(ctx, redisKey, itemsJSON, 5*time.Minute)
+	writeCache(ctx, redisKey, items)
 
-	fmt.Println("Cache miss. Items fetched from DB and cached")
+	slog.DebugContext(ctx, "Cache miss", "key", redisKey, "items", len(items))
 	return items, nil
 }
 
-func GetItemByID(id uuid.UUID) (*models.Item, error) {
-	ctx := context.Background()
-	redisKey := fmt.Sprintf("item:%s", id.String())
+func GetItemByID(ctx context.Context, id uuid.UUID) (found *models.Item, err error) {
+	ctx, span := tracing.Start(ctx, "services.GetItemByID", attribute.String("item.id", id.String()))
+	defer func() { tracing.End(span, err) }()
 
-	cachedItem, err := config.RedisClient.Get(ctx, redisKey).Result()
-	if err == nil {
-		var item models.Item
-		json.Unmarshal([]byte(cachedItem), &item)
-		fmt.Println("Cache hit for item:", id)
-		return &item, nil
+	item, err := loadItem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkItemAccess(ctx, item, models.PermissionRead); err != nil {
+		return nil, err
 	}
+	return item, nil
+}
+
+func loadItem(ctx context.Context, id uuid.UUID) (*models.Item, error) {
+	redisKey := itemCacheKey(id)
 
 	var item models.Item
-	err = repository.GetItemByID(id, &item)
-	if err != nil {
-		return nil, errors.New("item not found")
+	if readCache(ctx, redisKey, &item) {
+		slog.DebugContext(ctx, "Cache hit", "key", redisKey)
+		return &item, nil
 	}
 
-	itemJSON, _ := json.Marshal(item)
-	config.RedisClient.Set(ctx, redisKey, itemJSON, 5*time.Minute)
+	if err := getItem(ctx, id, &item); err != nil {
+		return nil, err
+	}
+
+	writeCache(ctx, redisKey, item)
 
-	fmt.Println("Cache miss. Item fetched from DB:", id)
+	slog.DebugContext(ctx, "Cache miss", "key", redisKey)
 	return &item, nil
 }
 
-func UpdateItem(id uuid.UUID, updatedItem *models.Item) error {
-	item, err := GetItemByID(id)
+// getItem loads an item from the database. Only a missing row is reported as
+// ErrItemNotFound; other errors, such as a failed connection, are returned
+// as they are.
+func getItem(ctx context.Context, id uuid.UUID, item *models.Item) error {
+	err := repository.GetItemByID(ctx, id, item)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrItemNotFound
+	}
+	return err
+}
+
+func UpdateItem(ctx context.Context, id uuid.UUID, updatedItem *models.Item) (err error) {
+	ctx, span := tracing.Start(ctx, "services.UpdateItem", attribute.String("item.id", id.String()))
+	defer func() { tracing.End(span, err) }()
+
+	if err := validation.Struct(updatedItem); err != nil {
+		return err
+	}
+	item, err := loadItem(ctx, id)
 	if err != nil {
 		return err
 	}
+	if err := checkItemAccess(ctx, item, models.PermissionWrite); err != nil {
+		return err
+	}
 
-	item.Name = updatedItem.Name
-	item.Price = updatedItem.Price
+	if cacheWriteMode == config.CacheWriteBehind {
+		before := *item
+		item.Name = updatedItem.Name
+		item.Price = updatedItem.Price
+		if err := bufferItemUpdate(ctx, &before, item); err != nil {
+			return err
+		}
+		reindexSuggestion(ctx, &before, item)
+		return nil
+	}
 
-	err = repository.UpdateItem(item)
+	var before models.Item
+	err = repository.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := getItem(ctx, id, &before); err != nil {
+			return err
+		}
+		*item = before
+		item.Name = updatedItem.Name
+		item.Price = updatedItem.Price
+		if err := repository.UpdateItem(ctx, item); err != nil {
+			return err
+		}
+		return recordAudit(ctx, models.AuditOperationUpdate, id, &before, item)
+	})
 	if err == nil {
-
-		config.RedisClient.Del(context.Background(), fmt.Sprintf("item:%s", id.String()))
-		config.RedisClient.Del(context.Background(), "all_items")
+		if cacheWriteMode == config.CacheWriteThrough {
+			writeCache(ctx, itemCacheKey(id), item)
+			invalidateCache(ctx, allItemsCacheKey)
+		} else {
+			invalidateCache(ctx, itemCacheKey(id))
+			invalidateCache(ctx, allItemsCacheKey)
+		}
+		invalidateTag(ctx, itemsTag)
+		reindexSuggestion(ctx, &before, item)
 	}
 	return err
 }
 
-func DeleteItem(id uuid.UUID) error {
-	err := repository.SoftDeleteItem(id)
+// DeleteItem removes the item and its shares. Only the owner, an admin or a
+// caller with write access may delete an item.
+func DeleteItem(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "services.DeleteItem", attribute.String("item.id", id.String()))
+	defer func() { tracing.End(span, err) }()
+
+	if actor := ActorFrom(ctx); actor != nil && !actor.Admin {
+		item, err := loadItem(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkItemAccess(ctx, item, models.PermissionWrite); err != nil {
+			return err
+		}
+	}
+
+	var before models.Item
+	err = repository.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := getItem(ctx, id, &before); err != nil {
+			return err
+		}
+		if err := repository.SoftDeleteItem(ctx, id); err != nil {
+			return err
+		}
+		if err := repository.DeleteItemShares(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, models.AuditOperationDelete, id, &before, nil)
+	})
 	if err == nil {
-		config.RedisClient.Del(context.Background(), fmt.Sprintf("item:%s", id.String()))
-		config.RedisClient.Del(context.Background(), "all_items")
+		invalidateCache(ctx, itemCacheKey(id))
+		invalidateCache(ctx, allItemsCacheKey)
+		invalidateTag(ctx, itemsTag)
+		unindexSuggestion(ctx, &before)
+		if cacheWriteMode == config.CacheWriteBehind {
+			// The index already has the name of an update not yet flushed.
+			if buffered, ok := bufferedItem(ctx, id); ok {
+				unindexSuggestion(ctx, buffered)
+			}
+		}
 	}
 	return err
 }
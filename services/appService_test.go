@@ -3,13 +3,16 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/cache"
 	"github.com/rahulmishra/go-crud-app/config"
 	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/validation"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -35,6 +38,13 @@ func (m *MockRedisClient) Set(ctx context.Context, key string, value interface{}
 	return redis.NewStatusCmd(ctx)
 }
 
+func (m *MockRedisClient) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	args := m.Called(ctx, key, value, expiration)
+	cmd := redis.NewBoolCmd(ctx)
+	cmd.SetVal(args.Bool(0))
+	return cmd
+}
+
 func (m *MockRedisClient) Del(ctx context.Context, keys ...string) *redis.IntCmd {
 	args := []interface{}{ctx}
 	for _, key := range keys {
@@ -49,6 +59,16 @@ type MockAppRepository struct {
 	mock.Mock
 }
 
+func (m *MockRedisClient) ZAdd(ctx context.Context, key string, members ...redis.Z) *redis.IntCmd {
+	m.Called(ctx, key, members)
+	return redis.NewIntCmd(ctx)
+}
+
+func (m *MockRedisClient) ZRem(ctx context.Context, key string, members ...interface{}) *redis.IntCmd {
+	m.Called(ctx, key, members)
+	return redis.NewIntCmd(ctx)
+}
+
 func (m *MockAppRepository) CreateItem(item *models.Item) error {
 	args := m.Called(item)
 	return args.Error(0)
@@ -81,7 +101,7 @@ func TestCreateItem(t *testing.T) {
 	}
 	config.SetDB(mockDB)
 
-	err = mockDB.AutoMigrate(&models.Item{})
+	err = mockDB.AutoMigrate(&models.Item{}, &models.ItemShare{}, &models.AuditRecord{})
 	if err != nil {
 		t.Fatalf("Failed to migrate database schema: %v", err)
 	}
@@ -107,10 +127,10 @@ func TestGetAllItems_CacheHit(t *testing.T) {
 	mockUUID := uuid.Must(uuid.NewRandom())
 	mockItems := []models.Item{{ID: mockUUID, Name: "Item1", Price: 20}}
 
-	cachedData, _ := json.Marshal(mockItems)
+	cachedData, _ := cache.Marshal(mockItems)
 	mockRedis.On("Get", mock.Anything, "all_items").Return(string(cachedData), nil)
 
-	items, err := GetAllItems()
+	items, err := GetAllItems(context.Background(), ItemQuery{})
 
 	assert.NoError(t, err)
 	assert.Equal(t, len(items), 1)
@@ -124,12 +144,12 @@ func TestGetItemByID_CacheHit(t *testing.T) {
 	itemID := uuid.Must(uuid.NewRandom())
 	mockItem := models.Item{ID: itemID, Name: "Item1", Price: 20}
 
-	cachedData, _ := json.Marshal(mockItem)
+	cachedData, _ := cache.Marshal(mockItem)
 	mockRedis.On("Get", mock.Anything, "item:"+itemID.String()).Return(string(cachedData), nil)
 
 	config.RedisClient = mockRedis
 
-	item, err := GetItemByID(itemID)
+	item, err := GetItemByID(context.Background(), itemID)
 
 	assert.NoError(t, err)
 	assert.Equal(t, item.Name, "Item1")
@@ -137,6 +157,38 @@ func TestGetItemByID_CacheHit(t *testing.T) {
 	mockRedis.AssertExpectations(t)
 }
 
+func TestGetItemByID_StaleCacheIsMiss(t *testing.T) {
+	mockDB, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	config.SetDB(mockDB)
+
+	err = mockDB.AutoMigrate(&models.Item{}, &models.ItemShare{}, &models.AuditRecord{})
+	if err != nil {
+		t.Fatalf("Failed to migrate database schema: %v", err)
+	}
+
+	itemID := uuid.New()
+	mockDB.Create(&models.Item{ID: itemID, Name: "Fresh", Price: 15})
+
+	stale, _ := json.Marshal(models.Item{ID: itemID, Name: "Stale", Price: 1})
+	before := cache.GetStats().DecodeErrors
+
+	mockRedis := new(MockRedisClient)
+	mockRedis.On("Get", mock.Anything, "item:"+itemID.String()).Return(string(stale), nil)
+	mockRedis.On("Set", mock.Anything, "item:"+itemID.String(), mock.Anything, mock.Anything)
+	config.RedisClient = mockRedis
+
+	item, err := GetItemByID(context.Background(), itemID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Fresh", item.Name)
+	assert.Equal(t, before+1, cache.GetStats().DecodeErrors)
+
+	mockRedis.AssertExpectations(t)
+}
+
 func TestUpdateItem(t *testing.T) {
 	mockDB, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	if err != nil {
@@ -144,7 +196,7 @@ func TestUpdateItem(t *testing.T) {
 	}
 	config.SetDB(mockDB)
 
-	err = mockDB.AutoMigrate(&models.Item{})
+	err = mockDB.AutoMigrate(&models.Item{}, &models.ItemShare{}, &models.AuditRecord{})
 	if err != nil {
 		t.Fatalf("Failed to migrate database schema: %v", err)
 	}
@@ -158,8 +210,14 @@ func TestUpdateItem(t *testing.T) {
 	updatedItem := &models.Item{Name: "Updated Item1", Price: 30}
 
 	mockRedis.On("Get", mock.Anything, fmt.Sprintf("item:%s", itemID.String())).Return("cached_data", nil)
+	mockRedis.On("Set", mock.Anything, fmt.Sprintf("item:%s", itemID.String()), mock.Anything, mock.Anything)
 	mockRedis.On("Del", mock.Anything, fmt.Sprintf("item:%s", itemID.String())).Return(redis.NewIntCmd(context.Background()))
 	mockRedis.On("Del", mock.Anything, "all_items").Return(redis.NewIntCmd(context.Background()))
+	mockRedis.On("Del", mock.Anything, "tag:items").Return(redis.NewIntCmd(context.Background()))
+	// The suggestion index stores the price, so the price change replaces
+	// the item's entry.
+	mockRedis.On("ZRem", mock.Anything, "{suggest:items}", mock.Anything).Return(nil)
+	mockRedis.On("ZAdd", mock.Anything, "{suggest:items}", mock.Anything).Return(nil)
 
 	config.RedisClient = mockRedis
 
@@ -168,7 +226,7 @@ func TestUpdateItem(t *testing.T) {
 	_ = mockDB.First(&beforeUpdate, "id = ?", itemID).Error
 	fmt.Println("Before Update (Test):", beforeUpdate.Name, beforeUpdate.Price)
 
-	err = UpdateItem(itemID, updatedItem)
+	err = UpdateItem(context.Background(), itemID, updatedItem)
 	assert.NoError(t, err)
 
 	// Debug after update
@@ -190,7 +248,7 @@ func TestDeleteItem(t *testing.T) {
 
 	config.DB = mockDB
 
-	err = mockDB.AutoMigrate(&models.Item{})
+	err = mockDB.AutoMigrate(&models.Item{}, &models.ItemShare{}, &models.AuditRecord{})
 	if err != nil {
 		t.Fatalf("Failed to migrate database schema: %v", err)
 	}
@@ -204,8 +262,10 @@ func TestDeleteItem(t *testing.T) {
 
 	mockRedis.On("Del", mock.Anything, fmt.Sprintf("item:%s", itemID.String())).Return(nil)
 	mockRedis.On("Del", mock.Anything, "all_items").Return(nil)
+	mockRedis.On("Del", mock.Anything, "tag:items").Return(nil)
+	mockRedis.On("ZRem", mock.Anything, "{suggest:items}", mock.Anything).Return(nil)
 
-	err = DeleteItem(itemID)
+	err = DeleteItem(context.Background(), itemID)
 	assert.NoError(t, err, "DeleteItem should not return an error")
 
 	var retrievedItem models.Item
@@ -214,3 +274,53 @@ func TestDeleteItem(t *testing.T) {
 
 	mockRedis.AssertExpectations(t)
 }
+
+func TestUpdateItem_WriteThrough(t *testing.T) {
+	mockDB, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	config.SetDB(mockDB)
+
+	err = mockDB.AutoMigrate(&models.Item{}, &models.ItemShare{}, &models.AuditRecord{})
+	if err != nil {
+		t.Fatalf("Failed to migrate database schema: %v", err)
+	}
+
+	assert.NoError(t, SetCacheWriteMode(config.CacheWriteThrough))
+	defer SetCacheWriteMode(config.CacheWriteInvalidate)
+
+	itemID := uuid.New()
+	mockDB.Create(&models.Item{ID: itemID, Name: "Item", Price: 20})
+	cachedData, _ := cache.Marshal(models.Item{ID: itemID, Name: "Item", Price: 20})
+
+	mockRedis := new(MockRedisClient)
+	mockRedis.On("Get", mock.Anything, "item:"+itemID.String()).Return(string(cachedData), nil)
+	mockRedis.On("Set", mock.Anything, "item:"+itemID.String(), mock.MatchedBy(func(data []byte) bool {
+		var cached models.Item
+		return cache.Unmarshal(data, &cached) == nil && cached.Price == 30
+	}), mock.Anything)
+	mockRedis.On("Del", mock.Anything, "all_items")
+	mockRedis.On("Del", mock.Anything, "tag:items")
+	mockRedis.On("ZRem", mock.Anything, "{suggest:items}", mock.Anything).Return(nil)
+	mockRedis.On("ZAdd", mock.Anything, "{suggest:items}", mock.Anything).Return(nil)
+	config.RedisClient = mockRedis
+
+	err = UpdateItem(context.Background(), itemID, &models.Item{Name: "Item", Price: 30})
+	assert.NoError(t, err)
+
+	mockRedis.AssertExpectations(t)
+	mockRedis.AssertNotCalled(t, "Del", mock.Anything, "item:"+itemID.String())
+}
+
+func TestUpdateItem_RejectsInvalidData(t *testing.T) {
+	mockRedis := new(MockRedisClient)
+	config.RedisClient = mockRedis
+
+	err := UpdateItem(context.Background(), uuid.New(), &models.Item{Name: "", Price: -5})
+
+	var fields validation.Errors
+	assert.True(t, errors.As(err, &fields))
+	assert.Len(t, fields, 2)
+	mockRedis.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+}
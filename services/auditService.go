@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/logging"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/repository"
+	"github.com/rahulmishra/go-crud-app/tracing"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+	systemActor          = "system"
+)
+
+// recordAudit appends an audit record for a mutation of itemID. Called inside
+// a repository transaction, the record commits or rolls back with the change.
+func recordAudit(ctx context.Context, operation string, itemID uuid.UUID, before, after *models.Item) error {
+	record, err := newAuditRecord(ctx, operation, itemID, before, after)
+	if err != nil {
+		return err
+	}
+	return repository.AppendAuditRecord(ctx, record)
+}
+
+// newAuditRecord describes a mutation by the actor in ctx, ready to be
+// appended to the audit log.
+func newAuditRecord(ctx context.Context, operation string, itemID uuid.UUID, before, after *models.Item) (*models.AuditRecord, error) {
+	record := &models.AuditRecord{
+		ItemID:    itemID,
+		Actor:     systemActor,
+		RequestID: logging.RequestID(ctx),
+		Operation: operation,
+		CreatedAt: time.Now(),
+	}
+	if actor := ActorFrom(ctx); actor != nil {
+		record.Actor = actor.ID
+	}
+
+	var err error
+	if before != nil {
+		if record.Before, err = json.Marshal(before); err != nil {
+			return nil, err
+		}
+	}
+	if after != nil {
+		if record.After, err = json.Marshal(after); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+type AuditQuery struct {
+	ItemID   *uuid.UUID
+	Actor    string
+	Since    time.Time
+	Page     int
+	PageSize int
+}
+
+type AuditPage struct {
+	Records  []models.AuditRecord `json:"records"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+	Total    int64                `json:"total"`
+}
+
+// ListAuditRecords returns matching records, newest first.
+func ListAuditRecords(ctx context.Context, query AuditQuery) (page *AuditPage, err error) {
+	ctx, span := tracing.Start(ctx, "services.ListAuditRecords")
+	defer func() { tracing.End(span, err) }()
+
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 {
+		query.PageSize = defaultAuditPageSize
+	}
+	query.PageSize = min(query.PageSize, maxAuditPageSize)
+
+	page = &AuditPage{Records: []models.AuditRecord{}, Page: query.Page, PageSize: query.PageSize}
+	total, err := repository.ListAuditRecords(ctx, repository.AuditFilter{
+		ItemID: query.ItemID,
+		Actor:  query.Actor,
+		Since:  query.Since,
+		Offset: (query.Page - 1) * query.PageSize,
+		Limit:  query.PageSize,
+	}, &page.Records)
+	if err != nil {
+		return nil, err
+	}
+	page.Total = total
+	return page, nil
+}
+
+type AuditVerification struct {
+	Valid   bool   `json:"valid"`
+	Checked int    `json:"checked"`
+	Head    string `json:"head,omitempty"`
+	// BrokenAt is the ID of the first record whose hash does not match.
+	BrokenAt uint64 `json:"broken_at,omitempty"`
+}
+
+// VerifyAuditChain recomputes every hash in the audit log and reports the
+// first record where the chain breaks.
+func VerifyAuditChain(ctx context.Context) (result *AuditVerification, err error) {
+	ctx, span := tracing.Start(ctx, "services.VerifyAuditChain")
+	defer func() { tracing.End(span, err) }()
+
+	result = &AuditVerification{Valid: true}
+	prev := ""
+	err = repository.ScanAuditRecords(ctx, 1000, func(records []models.AuditRecord) error {
+		for i := range records {
+			record := &records[i]
+			if !result.Valid {
+				return nil
+			}
+			result.Checked++
+			if record.PrevHash != prev || record.ComputeHash() != record.Hash {
+				result.Valid = false
+				result.BrokenAt = record.ID
+				return nil
+			}
+			prev = record.Hash
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Head = prev
+	return result, nil
+}
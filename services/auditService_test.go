@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/rahulmishra/go-crud-app/config"
+	"github.com/rahulmishra/go-crud-app/logging"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditTrail(t *testing.T) {
+	setupOwnershipTest(t)
+	assert.NoError(t, repository.ProtectAuditLog(context.Background()))
+
+	ctx := logging.WithRequestID(WithActor(context.Background(), &Actor{ID: "alice"}), "req-1")
+
+	item := &models.Item{Name: "Lamp", Price: 40}
+	assert.NoError(t, CreateItem(ctx, item))
+	assert.NoError(t, UpdateItem(ctx, item.ID, &models.Item{Name: "Lamp", Price: 45}))
+	assert.NoError(t, DeleteItem(ctx, item.ID))
+
+	page, err := ListAuditRecords(context.Background(), AuditQuery{ItemID: &item.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+
+	update := page.Records[1]
+	assert.Equal(t, models.AuditOperationUpdate, update.Operation)
+	assert.Equal(t, "alice", update.Actor)
+	assert.Equal(t, "req-1", update.RequestID)
+
+	var before, after models.Item
+	assert.NoError(t, json.Unmarshal(update.Before, &before))
+	assert.NoError(t, json.Unmarshal(update.After, &after))
+	assert.Equal(t, 40.0, before.Price)
+	assert.Equal(t, 45.0, after.Price)
+	assert.Nil(t, []byte(page.Records[0].After), "deletes have no after snapshot")
+
+	byActor, err := ListAuditRecords(context.Background(), AuditQuery{Actor: "bob"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), byActor.Total)
+
+	verification, err := VerifyAuditChain(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, verification.Valid)
+	assert.Equal(t, 3, verification.Checked)
+
+	err = config.DB.Exec("UPDATE audit_records SET actor = 'mallory' WHERE operation = 'update'").Error
+	assert.Error(t, err, "the audit table rejects updates")
+
+	config.DB.Exec("DROP TRIGGER audit_records_no_UPDATE")
+	config.DB.Exec("UPDATE audit_records SET actor = 'mallory' WHERE operation = 'update'")
+	verification, err = VerifyAuditChain(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, verification.Valid)
+	assert.Equal(t, update.ID, verification.BrokenAt)
+}
+
+func TestAuditFailureRollsBackMutation(t *testing.T) {
+	setupOwnershipTest(t)
+	config.DB.Migrator().DropTable(&models.AuditRecord{})
+
+	item := &models.Item{Name: "Rug", Price: 99}
+	assert.Error(t, CreateItem(context.Background(), item))
+
+	var count int64
+	config.DB.Model(&models.Item{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
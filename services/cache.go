@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/cache"
+	"github.com/rahulmishra/go-crud-app/config"
+	"github.com/rahulmishra/go-crud-app/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	cacheTTL         = 5 * time.Minute
+	allItemsCacheKey = "all_items"
+)
+
+var cacheWriteMode = config.CacheWriteInvalidate
+
+// SetCacheWriteMode selects how item mutations update the cache. See
+// config.CacheConfig.WriteMode.
+func SetCacheWriteMode(mode string) error {
+	switch mode {
+	case config.CacheWriteInvalidate, config.CacheWriteThrough, config.CacheWriteBehind:
+		cacheWriteMode = mode
+		return nil
+	default:
+		return fmt.Errorf("unknown cache write mode %q", mode)
+	}
+}
+
+func itemCacheKey(id uuid.UUID) string {
+	return fmt.Sprintf("item:%s", id.String())
+}
+
+// readCache loads key into v. Values that fail to decode, including entries
+// written under an older schema version, are reported as misses.
+func readCache(ctx context.Context, key string, v interface{}) bool {
+	hit := lookupCache(ctx, key, v)
+	metrics.ObserveCache(cacheNamespace(key), hit)
+	return hit
+}
+
+func lookupCache(ctx context.Context, key string, v interface{}) bool {
+	data, err := config.RedisClient.Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			slog.WarnContext(ctx, "Cache read failed; using the database", "key", key, "error", err)
+		}
+		return false
+	}
+	if err := cache.Unmarshal(data, v); err != nil {
+		slog.WarnContext(ctx, "Ignoring cached value", "key", key, "error", err)
+		return false
+	}
+	return true
+}
+
+// cacheNamespace maps a key to its metrics label: "item" for item:<id> keys
+// and the key itself otherwise.
+func cacheNamespace(key string) string {
+	namespace, _, _ := strings.Cut(key, ":")
+	return namespace
+}
+
+func writeCache(ctx context.Context, key string, v interface{}) {
+	data, err := cache.Marshal(v)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to encode cache value", "key", key, "error", err)
+		return
+	}
+	if err := config.RedisClient.Set(ctx, key, data, cacheTTL).Err(); err != nil {
+		slog.WarnContext(ctx, "Cache write failed", "key", key, "error", err)
+	}
+}
+
+// invalidateCache deletes key after a committed change. The change is not
+// rolled back when this fails; readers may see the old value until it
+// expires, so the failure is logged as an error.
+func invalidateCache(ctx context.Context, key string) {
+	if err := config.RedisClient.Del(ctx, key).Err(); err != nil {
+		slog.ErrorContext(ctx, "Cache invalidation failed; the stale entry expires with its TTL",
+			"key", key, "ttl", cacheTTL, "error", err)
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rahulmishra/go-crud-app/repository"
+)
+
+const (
+	FacetPrice = "price"
+
+	maxPriceBounds    = 19
+	minPriceQuantiles = 2
+	maxPriceQuantiles = 10
+)
+
+var (
+	ErrUnknownFacet          = errors.New("unknown facet")
+	ErrInvalidPriceBuckets   = errors.New("invalid price buckets")
+	ErrInvalidPriceQuantiles = errors.New("invalid price quantiles")
+	ErrInvalidPriceRange     = errors.New("invalid price range")
+)
+
+var defaultPriceBounds = []float64{50, 200}
+
+// SetDefaultPriceBuckets sets the price facet boundaries used when a search
+// names neither buckets nor quantiles. See config.SearchConfig.
+func SetDefaultPriceBuckets(bounds []string) error {
+	parsed, err := ParsePriceBounds(strings.Join(bounds, ","))
+	if err != nil {
+		return err
+	}
+	defaultPriceBounds = parsed
+	return nil
+}
+
+// ParsePriceBounds parses comma-separated, strictly increasing, non-negative
+// prices, such as "50,200".
+func ParsePriceBounds(s string) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) > maxPriceBounds {
+		return nil, fmt.Errorf("%w: at most %d boundaries", ErrInvalidPriceBuckets, maxPriceBounds)
+	}
+	bounds := make([]float64, 0, len(parts))
+	for _, part := range parts {
+		bound, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || bound < 0 || (len(bounds) > 0 && bound <= bounds[len(bounds)-1]) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPriceBuckets, s)
+		}
+		bounds = append(bounds, bound)
+	}
+	return bounds, nil
+}
+
+// FacetQuery selects the facets returned with a search.
+type FacetQuery struct {
+	// Names lists the requested facets. Only FacetPrice exists; items have
+	// no categories or tags yet.
+	Names []string
+	// PriceBounds overrides the default price ranges.
+	PriceBounds []float64
+	// PriceQuantiles, when set, derives the price ranges from the matches
+	// instead, splitting them into that many groups of about equal size.
+	PriceQuantiles int
+}
+
+func (q FacetQuery) validate() error {
+	for _, name := range q.Names {
+		if name != FacetPrice {
+			return fmt.Errorf("%w %q", ErrUnknownFacet, name)
+		}
+	}
+	if q.PriceQuantiles != 0 && (q.PriceQuantiles < minPriceQuantiles || q.PriceQuantiles > maxPriceQuantiles) {
+		return fmt.Errorf("%w: must be between %d and %d", ErrInvalidPriceQuantiles, minPriceQuantiles, maxPriceQuantiles)
+	}
+	return nil
+}
+
+// key identifies the facet request within a cache key.
+func (q FacetQuery) key() string {
+	return fmt.Sprintf("%v|%v|%d", q.Names, q.PriceBounds, q.PriceQuantiles)
+}
+
+type Facets struct {
+	Price []PriceBucket `json:"price,omitempty"`
+}
+
+// PriceBucket counts the matches priced from Min, inclusive, up to Max,
+// exclusive. A nil bound is open.
+type PriceBucket struct {
+	// Key names the range as "min-max", with "*" for an open bound, e.g.
+	// "*-50" or "50-200".
+	Key   string   `json:"key"`
+	Min   *float64 `json:"min"`
+	Max   *float64 `json:"max"`
+	Count int64    `json:"count"`
+}
+
+// computeFacets counts the requested facets over the items filter matches.
+// The repository counts them, so they cover every match rather than only
+// the loaded ones.
+func computeFacets(ctx context.Context, filter repository.SearchFilter, query FacetQuery) (*Facets, error) {
+	facets := &Facets{}
+	if !slices.Contains(query.Names, FacetPrice) {
+		return facets, nil
+	}
+
+	bounds := query.PriceBounds
+	if bounds == nil && query.PriceQuantiles == 0 {
+		bounds = defaultPriceBounds
+	}
+	bounds, counts, err := repository.CountMatchesByPrice(ctx, filter, bounds, query.PriceQuantiles)
+	if err != nil {
+		return nil, err
+	}
+
+	facets.Price = make([]PriceBucket, len(counts))
+	for i, count := range counts {
+		bucket := PriceBucket{Count: count}
+		lo, hi := "*", "*"
+		if i > 0 {
+			lower := bounds[i-1]
+			bucket.Min = &lower
+			lo = formatPrice(lower)
+		}
+		if i < len(bounds) {
+			upper := bounds[i]
+			bucket.Max = &upper
+			hi = formatPrice(upper)
+		}
+		bucket.Key = lo + "-" + hi
+		facets.Price[i] = bucket
+	}
+	return facets, nil
+}
+
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', -1, 64)
+}
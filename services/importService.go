@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/repository"
+	"github.com/rahulmishra/go-crud-app/tracing"
+	"github.com/rahulmishra/go-crud-app/validation"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ImportResult counts the rows an import inserted.
+type ImportResult struct {
+	Items  int64 `json:"items"`
+	Shares int64 `json:"shares"`
+}
+
+// ImportItems bulk-inserts items and shares in one transaction, in batches
+// of batchSize. Rows whose ID already exists are left untouched, so
+// importing the same data twice inserts nothing the second time. When
+// anything was inserted, all given items are added to the suggestion index,
+// so an existing item renamed since an earlier import is suggested under
+// both names until the index is rebuilt. Items are validated as on create,
+// and nothing is stored if any of them is invalid. The import is audited as a whole: one record, not tied to an item, counts
+// the rows it inserted.
+func ImportItems(ctx context.Context, items []models.Item, shares []models.ItemShare, batchSize int) (result ImportResult, err error) {
+	ctx, span := tracing.Start(ctx, "services.ImportItems", attribute.Int("items", len(items)), attribute.Int("shares", len(shares)))
+	defer func() { tracing.End(span, err) }()
+
+	for i := range items {
+		if err := validation.Struct(&items[i]); err != nil {
+			return ImportResult{}, fmt.Errorf("item %d (%s): %w", i, items[i].ID, err)
+		}
+	}
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	err = repository.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if result.Items, err = repository.CreateItemsIfMissing(ctx, items, batchSize); err != nil {
+			return err
+		}
+		if result.Shares, err = repository.CreateItemSharesIfMissing(ctx, shares, batchSize); err != nil {
+			return err
+		}
+		if result.Items == 0 && result.Shares == 0 {
+			return nil
+		}
+		return recordImportAudit(ctx, result)
+	})
+	if err != nil {
+		return ImportResult{}, err
+	}
+	if result.Items > 0 {
+		invalidateCache(ctx, allItemsCacheKey)
+		invalidateTag(ctx, itemsTag)
+		indexSuggestions(ctx, items...)
+	}
+	if result.Shares > 0 {
+		invalidateTag(ctx, sharesTag)
+	}
+	return result, nil
+}
+
+// recordImportAudit appends the audit record of an import that inserted the
+// rows counted by result.
+func recordImportAudit(ctx context.Context, result ImportResult) error {
+	record, err := newAuditRecord(ctx, models.AuditOperationImport, uuid.Nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	if record.After, err = json.Marshal(result); err != nil {
+		return err
+	}
+	return repository.AppendAuditRecord(ctx, record)
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// itemsTag covers every query result derived from the items table, and
+// sharesTag those that depend on who an item is shared with.
+const (
+	itemsTag  = "items"
+	sharesTag = "shares"
+)
+
+// Query results are cached under a key that includes the current version of
+// each tag they depend on. Invalidating a tag deletes its version, so the
+// next reader starts a new one and every result cached under the old one
+// becomes unreachable and expires with its TTL. Versions are random rather
+// than counters, so a tag whose version was evicted can never return to a
+// value that old results were cached under.
+
+func tagVersionKey(tag string) string {
+	return "tag:" + tag
+}
+
+// tagVersion returns the current version of tag, starting a new one when
+// there is none.
+func tagVersion(ctx context.Context, tag string) (string, error) {
+	key := tagVersionKey(tag)
+	version, err := config.RedisClient.Get(ctx, key).Result()
+	if !errors.Is(err, redis.Nil) {
+		return version, err
+	}
+	version = uuid.NewString()
+	created, err := config.RedisClient.SetNX(ctx, key, version, 0).Result()
+	if err != nil || created {
+		return version, err
+	}
+	// Another reader started a version first.
+	return config.RedisClient.Get(ctx, key).Result()
+}
+
+// queryCacheKey builds the key for a query in namespace with the given
+// parameters, tied to the current versions of tags. It returns false when
+// the versions cannot be read, in which case the result must not be cached.
+func queryCacheKey(ctx context.Context, namespace string, tags []string, params ...string) (string, bool) {
+	hash := sha256.New()
+	for _, tag := range tags {
+		version, err := tagVersion(ctx, tag)
+		if err != nil {
+			slog.WarnContext(ctx, "Cache read failed; not caching the query", "tag", tag, "error", err)
+			return "", false
+		}
+		fmt.Fprintf(hash, "%s=%s\n", tag, version)
+	}
+	fmt.Fprintf(hash, "%s\n", strings.Join(params, "\x00"))
+	return "query:" + namespace + ":" + hex.EncodeToString(hash.Sum(nil)), true
+}
+
+// invalidateTag drops every cached query result that depends on tag.
+func invalidateTag(ctx context.Context, tag string) {
+	invalidateCache(ctx, tagVersionKey(tag))
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"html"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/repository"
+	"github.com/rahulmishra/go-crud-app/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+	// maxSearchMatches caps the ranked matches the caller may read that are
+	// fetched and cached for one query; pages beyond it are empty and the
+	// page is marked as truncated.
+	maxSearchMatches = 1000
+	maxSearchTerms   = 8
+	maxSearchTermLen = 50
+)
+
+var ErrInvalidSearch = errors.New("search query has no words")
+
+type SearchQuery struct {
+	// Q is free text. Every word in it must prefix a word of the item name.
+	Q        string
+	Page     int
+	PageSize int
+	// MinPrice and MaxPrice, when set, keep only items priced from MinPrice,
+	// inclusive, up to MaxPrice, exclusive, matching the price facet ranges.
+	MinPrice *float64
+	MaxPrice *float64
+	Facets   FacetQuery
+	// Readable, when set, further narrows the items the caller may see, such
+	// as policy.FilterReadable. It is applied before paginating.
+	Readable func([]models.Item) []models.Item
+	// Viewer identifies everything Readable and the actor's access depend
+	// on. When set, the whole page, facets included, is cached for callers
+	// with the same Viewer.
+	Viewer string
+}
+
+type SearchHit struct {
+	Item models.Item `json:"item"`
+	// Rank orders the results; higher is more relevant.
+	Rank float64 `json:"rank"`
+	// Snippet is the HTML-escaped item name with matched words wrapped in
+	// <mark>.
+	Snippet string `json:"snippet"`
+}
+
+type SearchPage struct {
+	Results  []SearchHit `json:"results"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+	Total    int         `json:"total"`
+	// Truncated is set when the query matched more than maxSearchMatches
+	// items the caller may read. Total then only counts the most relevant
+	// of them.
+	Truncated bool `json:"truncated"`
+	// Facets count every match the caller may read within the price filter.
+	Facets *Facets `json:"facets,omitempty"`
+}
+
+// cachedMatch is the cached form of a repository.SearchMatch.
+type cachedMatch struct {
+	Item models.Item `json:"item"`
+	Rank float64     `json:"rank"`
+}
+
+// searchMatches are the ranked matches of a query that one viewer may read.
+type searchMatches struct {
+	Matches   []cachedMatch `json:"matches"`
+	Truncated bool          `json:"truncated"`
+}
+
+// SearchItems returns the items visible to the actor in ctx whose names
+// match query.Q, most relevant first. The ranked matches are cached per
+// viewer, under the items and shares tags.
+func SearchItems(ctx context.Context, query SearchQuery) (page *SearchPage, err error) {
+	ctx, span := tracing.Start(ctx, "services.SearchItems", attribute.String("search.q", query.Q))
+	defer func() { tracing.End(span, err) }()
+
+	terms := searchTerms(query.Q)
+	if len(terms) == 0 {
+		return nil, ErrInvalidSearch
+	}
+	if (query.MinPrice != nil && *query.MinPrice < 0) || (query.MaxPrice != nil && *query.MaxPrice < 0) ||
+		(query.MinPrice != nil && query.MaxPrice != nil && *query.MinPrice >= *query.MaxPrice) {
+		return nil, ErrInvalidPriceRange
+	}
+	if err := query.Facets.validate(); err != nil {
+		return nil, err
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 {
+		query.PageSize = defaultSearchPageSize
+	}
+	query.PageSize = min(query.PageSize, maxSearchPageSize)
+
+	if query.Viewer == "" {
+		return searchPage(ctx, terms, query)
+	}
+	redisKey, cacheable := queryCacheKey(ctx, "search-page", []string{itemsTag, sharesTag},
+		query.Viewer, strings.Join(terms, " "), strconv.Itoa(query.Page), strconv.Itoa(query.PageSize),
+		formatOptionalPrice(query.MinPrice), formatOptionalPrice(query.MaxPrice), query.Facets.key())
+	if cacheable && readCache(ctx, redisKey, &page) {
+		slog.DebugContext(ctx, "Cache hit", "key", redisKey)
+		return page, nil
+	}
+	if page, err = searchPage(ctx, terms, query); err != nil {
+		return nil, err
+	}
+	if cacheable {
+		writeCache(ctx, redisKey, page)
+	}
+	return page, nil
+}
+
+func formatOptionalPrice(price *float64) string {
+	if price == nil {
+		return ""
+	}
+	return formatPrice(*price)
+}
+
+// searchPage builds the page of query for the caller in ctx.
+func searchPage(ctx context.Context, terms []string, query SearchQuery) (*SearchPage, error) {
+	found, err := loadSearchMatches(ctx, terms)
+	if err != nil {
+		return nil, err
+	}
+	matches := found.Matches
+
+	items := make([]models.Item, len(matches))
+	for i, match := range matches {
+		items[i] = match.Item
+	}
+	if query.Readable != nil {
+		items = query.Readable(items)
+	}
+	visible := make(map[uuid.UUID]bool, len(items))
+	for _, item := range items {
+		visible[item.ID] = true
+	}
+
+	page := &SearchPage{Results: []SearchHit{}, Page: query.Page, PageSize: query.PageSize, Truncated: found.Truncated}
+	if len(query.Facets.Names) > 0 {
+		filter := repository.SearchFilter{Terms: terms, Viewer: searchViewer(ctx), MinPrice: query.MinPrice, MaxPrice: query.MaxPrice}
+		// The read policy cannot be expressed in SQL, so the matches it hid
+		// are left out by ID. Matches beyond maxSearchMatches are never
+		// loaded, and are counted without it.
+		for _, match := range matches {
+			if !visible[match.Item.ID] {
+				filter.Exclude = append(filter.Exclude, match.Item.ID)
+			}
+		}
+		if page.Facets, err = computeFacets(ctx, filter, query.Facets); err != nil {
+			return nil, err
+		}
+	}
+	offset := (query.Page - 1) * query.PageSize
+	for _, match := range matches {
+		if !visible[match.Item.ID] || !inPriceRange(match.Item.Price, query.MinPrice, query.MaxPrice) {
+			continue
+		}
+		if page.Total >= offset && len(page.Results) < query.PageSize {
+			page.Results = append(page.Results, SearchHit{
+				Item:    match.Item,
+				Rank:    match.Rank,
+				Snippet: highlight(match.Item.Name, terms),
+			})
+		}
+		page.Total++
+	}
+	return page, nil
+}
+
+func inPriceRange(price float64, lower, upper *float64) bool {
+	return (lower == nil || price >= *lower) && (upper == nil || price < *upper)
+}
+
+// loadSearchMatches returns the ranked matches of terms that the actor in
+// ctx may read. Callers who see the same items share a cache entry.
+func loadSearchMatches(ctx context.Context, terms []string) (*searchMatches, error) {
+	viewer := searchViewer(ctx)
+	viewerKey := "*"
+	if viewer != nil {
+		groups := slices.Clone(viewer.Groups)
+		slices.Sort(groups)
+		viewerKey = viewer.UserID + "|" + strings.Join(groups, ",")
+	}
+	redisKey, cacheable := queryCacheKey(ctx, "search", []string{itemsTag, sharesTag}, append([]string{viewerKey}, terms...)...)
+
+	var matches *searchMatches
+	if cacheable && readCache(ctx, redisKey, &matches) {
+		slog.DebugContext(ctx, "Cache hit", "key", redisKey)
+		return matches, nil
+	}
+
+	// One extra row tells whether the cap cut anything off.
+	found, err := repository.SearchItems(ctx, repository.SearchFilter{Terms: terms, Viewer: viewer}, maxSearchMatches+1)
+	if err != nil {
+		return nil, err
+	}
+	matches = &searchMatches{Truncated: len(found) > maxSearchMatches}
+	found = found[:min(len(found), maxSearchMatches)]
+	matches.Matches = make([]cachedMatch, len(found))
+	for i, match := range found {
+		matches.Matches[i] = cachedMatch{Item: match.Item, Rank: match.Rank}
+	}
+
+	if cacheable {
+		writeCache(ctx, redisKey, matches)
+	}
+	slog.DebugContext(ctx, "Cache miss", "key", redisKey, "matches", len(matches.Matches))
+	return matches, nil
+}
+
+// searchTerms splits q into lower-case words of letters and digits, sorted
+// and without duplicates so equivalent queries share a cache entry. Other
+// characters separate words, so no query syntax reaches the database.
+func searchTerms(q string) []string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(q), isSeparator) {
+		if len(terms) == maxSearchTerms {
+			break
+		}
+		if runes := []rune(word); len(runes) > maxSearchTermLen {
+			word = string(runes[:maxSearchTermLen])
+		}
+		terms = append(terms, word)
+	}
+	slices.Sort(terms)
+	return slices.Compact(terms)
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// highlight escapes name for HTML and wraps each word that starts with one
+// of terms in <mark>.
+func highlight(name string, terms []string) string {
+	var b strings.Builder
+	word := func(w string) {
+		lower := strings.ToLower(w)
+		for _, term := range terms {
+			if strings.HasPrefix(lower, term) {
+				b.WriteString("<mark>" + html.EscapeString(w) + "</mark>")
+				return
+			}
+		}
+		b.WriteString(html.EscapeString(w))
+	}
+
+	start := -1
+	for i, r := range name {
+		if isSeparator(r) {
+			if start >= 0 {
+				word(name[start:i])
+				start = -1
+			}
+			b.WriteString(html.EscapeString(string(r)))
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		word(name[start:])
+	}
+	return b.String()
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/config"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupOwnershipTest(t *testing.T) {
+	mockDB, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	config.SetDB(mockDB)
+
+	err = mockDB.AutoMigrate(&models.Item{}, &models.ItemShare{}, &models.AuditRecord{})
+	if err != nil {
+		t.Fatalf("Failed to migrate database schema: %v", err)
+	}
+
+	// Every read is a cache miss so access is always checked against the DB.
+	mockRedis := new(MockRedisClient)
+	mockRedis.On("Get", mock.Anything, mock.Anything).Return("", redis.Nil)
+	mockRedis.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockRedis.On("SetNX", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true)
+	mockRedis.On("Del", mock.Anything, mock.Anything)
+	mockRedis.On("ZAdd", mock.Anything, mock.Anything, mock.Anything)
+	mockRedis.On("ZRem", mock.Anything, mock.Anything, mock.Anything)
+	config.RedisClient = mockRedis
+}
+
+func TestItemOwnershipAndSharing(t *testing.T) {
+	setupOwnershipTest(t)
+
+	alice := WithActor(context.Background(), &Actor{ID: "alice"})
+	bob := WithActor(context.Background(), &Actor{ID: "bob", Groups: []string{"buyers"}})
+	carol := WithActor(context.Background(), &Actor{ID: "carol"})
+	admin := WithActor(context.Background(), &Actor{ID: "root", Admin: true})
+
+	item := &models.Item{Name: "Desk", Price: 300, OwnerID: "mallory"}
+	assert.NoError(t, CreateItem(alice, item))
+	assert.Equal(t, "alice", item.OwnerID, "owner comes from the actor, not the request")
+
+	_, err := GetItemByID(bob, item.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = GetItemByID(admin, item.ID)
+	assert.NoError(t, err)
+
+	_, err = ShareItem(bob, item.ID, models.GranteeUser, "bob", models.PermissionWrite)
+	assert.ErrorIs(t, err, ErrForbidden, "only the owner may share")
+
+	_, err = ShareItem(alice, item.ID, models.GranteeGroup, "buyers", models.PermissionRead)
+	assert.NoError(t, err)
+
+	_, err = GetItemByID(bob, item.ID)
+	assert.NoError(t, err, "group share grants read")
+	assert.ErrorIs(t, UpdateItem(bob, item.ID, &models.Item{Name: "Desk", Price: 1}), ErrForbidden)
+
+	share, err := ShareItem(alice, item.ID, models.GranteeGroup, "buyers", models.PermissionWrite)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PermissionWrite, share.Permission)
+	assert.NoError(t, UpdateItem(bob, item.ID, &models.Item{Name: "Desk", Price: 250}))
+
+	shares, err := ListItemShares(alice, item.ID)
+	assert.NoError(t, err)
+	assert.Len(t, shares, 1, "re-sharing with the same grantee replaces the permission")
+
+	other := &models.Item{Name: "Chair", Price: 80}
+	assert.NoError(t, CreateItem(carol, other))
+
+	visible, err := GetAllItems(bob, ItemQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, visible, 1)
+	assert.Equal(t, item.ID, visible[0].ID)
+
+	mine, err := GetAllItems(carol, ItemQuery{OwnerID: "carol"})
+	assert.NoError(t, err)
+	assert.Len(t, mine, 1)
+	assert.Equal(t, other.ID, mine[0].ID)
+
+	assert.ErrorIs(t, DeleteItem(carol, item.ID), ErrForbidden)
+	assert.NoError(t, RevokeItemShare(alice, item.ID, share.ID))
+	assert.ErrorIs(t, RevokeItemShare(alice, item.ID, share.ID), ErrShareNotFound)
+	assert.NoError(t, DeleteItem(alice, item.ID))
+}
+
+func TestShareItem_Validation(t *testing.T) {
+	setupOwnershipTest(t)
+
+	_, err := ShareItem(context.Background(), uuid.New(), "team", "x", models.PermissionRead)
+	assert.ErrorIs(t, err, ErrInvalidShare)
+	_, err = ShareItem(context.Background(), uuid.New(), models.GranteeUser, "x", "own")
+	assert.ErrorIs(t, err, ErrInvalidShare)
+	_, err = ShareItem(context.Background(), uuid.New(), models.GranteeUser, "x", models.PermissionRead)
+	assert.ErrorIs(t, err, ErrItemNotFound)
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/config"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/repository"
+	"github.com/rahulmishra/go-crud-app/tracing"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// The suggestion index is a sorted set in which every member has score 0,
+// so members are ordered by their bytes and ZRANGEBYLEX finds all names
+// with a given prefix. A member is the lower-case name, followed by the
+// name, owner, ID and price separated by NUL bytes, which item names cannot
+// contain. Members carry every attribute policy rules can refer to, so
+// suggestions are filtered without loading the items. The index and the copy a rebuild fills share a hash tag, so the
+// RENAME that swaps them in works in cluster mode.
+const (
+	suggestIndexKey     = "{suggest:items}"
+	suggestRebuildKey   = suggestIndexKey + ":rebuild"
+	defaultSuggestLimit = 10
+	maxSuggestLimit     = 50
+	// maxSuggestScan caps the index entries read for one request while
+	// skipping items the caller may not read.
+	maxSuggestScan = 1000
+)
+
+var ErrInvalidPrefix = errors.New("suggestion prefix is empty")
+
+type SuggestQuery struct {
+	// Prefix is matched case-insensitively against the start of item names.
+	Prefix string
+	Limit  int
+	// Readable, when set, further narrows the items the caller may see, such
+	// as policy.FilterReadable.
+	Readable func([]models.Item) []models.Item
+}
+
+type Suggestion struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+func suggestMember(item *models.Item) string {
+	price := strconv.FormatFloat(item.Price, 'g', -1, 64)
+	return strings.Join([]string{strings.ToLower(item.Name), item.Name, item.OwnerID, item.ID.String(), price}, "\x00")
+}
+
+// parseSuggestMember rejects members without a price, written before the
+// price was indexed; "suggest rebuild" replaces them.
+func parseSuggestMember(member string) (models.Item, bool) {
+	parts := strings.Split(member, "\x00")
+	if len(parts) != 5 {
+		return models.Item{}, false
+	}
+	id, err := uuid.Parse(parts[3])
+	if err != nil {
+		return models.Item{}, false
+	}
+	price, err := strconv.ParseFloat(parts[4], 64)
+	if err != nil {
+		return models.Item{}, false
+	}
+	return models.Item{ID: id, Name: parts[1], OwnerID: parts[2], Price: price}, true
+}
+
+// SuggestItems returns up to query.Limit items visible to the actor in ctx
+// whose names start with query.Prefix, in alphabetical order. It reads only
+// the suggestion index, plus the caller's cached shares when the actor is
+// not an admin.
+func SuggestItems(ctx context.Context, query SuggestQuery) (suggestions []Suggestion, err error) {
+	ctx, span := tracing.Start(ctx, "services.SuggestItems", attribute.String("suggest.prefix", query.Prefix))
+	defer func() { tracing.End(span, err) }()
+
+	prefix := strings.ToLower(strings.TrimSpace(query.Prefix))
+	if prefix == "" {
+		return nil, ErrInvalidPrefix
+	}
+	if query.Limit < 1 {
+		query.Limit = defaultSuggestLimit
+	}
+	query.Limit = min(query.Limit, maxSuggestLimit)
+	canRead, err := readableBy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// "\xff" never occurs in UTF-8, so it sorts after every name with the
+	// prefix.
+	bounds := &redis.ZRangeBy{Min: "[" + prefix, Max: "[" + prefix + "\xff", Count: int64(max(2*query.Limit, 20))}
+	suggestions = []Suggestion{}
+	for bounds.Offset < maxSuggestScan && len(suggestions) < query.Limit {
+		members, err := config.RedisClient.ZRangeByLex(ctx, suggestIndexKey, bounds).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		items := make([]models.Item, 0, len(members))
+		for _, member := range members {
+			if item, ok := parseSuggestMember(member); ok && canRead(&item) {
+				items = append(items, item)
+			}
+		}
+		if query.Readable != nil {
+			items = query.Readable(items)
+		}
+		for _, item := range items {
+			if len(suggestions) == query.Limit {
+				break
+			}
+			suggestions = append(suggestions, Suggestion{ID: item.ID, Name: item.Name})
+		}
+
+		if int64(len(members)) < bounds.Count {
+			break
+		}
+		bounds.Offset += bounds.Count
+	}
+	return suggestions, nil
+}
+
+// indexSuggestions adds items to the suggestion index. Like cache
+// invalidation, a failure is logged rather than failing the committed
+// change; RebuildSuggestIndex repairs the index.
+func indexSuggestions(ctx context.Context, items ...models.Item) {
+	if len(items) == 0 {
+		return
+	}
+	members := make([]redis.Z, len(items))
+	for i := range items {
+		members[i] = redis.Z{Member: suggestMember(&items[i])}
+	}
+	if err := config.RedisClient.ZAdd(ctx, suggestIndexKey, members...).Err(); err != nil {
+		slog.ErrorContext(ctx, "Suggestion index update failed", "items", len(items), "error", err)
+	}
+}
+
+func unindexSuggestion(ctx context.Context, item *models.Item) {
+	if err := config.RedisClient.ZRem(ctx, suggestIndexKey, suggestMember(item)).Err(); err != nil {
+		slog.ErrorContext(ctx, "Suggestion index update failed", "item_id", item.ID, "error", err)
+	}
+}
+
+// reindexSuggestion replaces before with after in the suggestion index when
+// the indexed fields changed.
+func reindexSuggestion(ctx context.Context, before, after *models.Item) {
+	if suggestMember(before) == suggestMember(after) {
+		return
+	}
+	unindexSuggestion(ctx, before)
+	indexSuggestions(ctx, *after)
+}
+
+// RebuildSuggestIndex rebuilds the suggestion index from the database in
+// batches of batchSize and returns the number of items indexed. The new
+// index replaces the old one atomically, but changes made while it is being
+// built may be missing from it.
+func RebuildSuggestIndex(ctx context.Context, batchSize int) (indexed int, err error) {
+	ctx, span := tracing.Start(ctx, "services.RebuildSuggestIndex")
+	defer func() { tracing.End(span, err) }()
+
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	if err := config.RedisClient.Del(ctx, suggestRebuildKey).Err(); err != nil {
+		return 0, err
+	}
+	err = repository.ScanItems(ctx, batchSize, func(items []models.Item) error {
+		members := make([]redis.Z, len(items))
+		for i := range items {
+			members[i] = redis.Z{Member: suggestMember(&items[i])}
+		}
+		indexed += len(items)
+		return config.RedisClient.ZAdd(ctx, suggestRebuildKey, members...).Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+	if indexed == 0 {
+		return 0, config.RedisClient.Del(ctx, suggestIndexKey).Err()
+	}
+	return indexed, config.RedisClient.Rename(ctx, suggestRebuildKey, suggestIndexKey).Err()
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/cache"
+	"github.com/rahulmishra/go-crud-app/config"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/repository"
+	"github.com/rahulmishra/go-crud-app/tracing"
+	"github.com/redis/go-redis/v9"
+)
+
+// Buffered updates live in a Redis hash keyed by item ID. A flush renames the
+// pending hash to the in-flight hash, applies it to the database and only then
+// deletes it, so a crash mid-flush leaves the in-flight hash behind to be
+// replayed by the next flush. Both keys share a hash tag so RENAME works in
+// cluster mode.
+//
+// Every update also leaves its audit record in the hash, under a field with
+// the auditFieldPrefix. The flush appends the records in the same transaction
+// that writes their items, so the audit log never shows an update that did
+// not reach the database.
+const (
+	writeBehindPendingKey  = "{item:writebehind}:pending"
+	writeBehindInflightKey = "{item:writebehind}:inflight"
+	auditFieldPrefix       = "audit:"
+)
+
+// bufferItemUpdate records item as the latest state to be flushed, along with
+// the audit record of the change from before, and serves it from the cache
+// until then. Cached lists and query results are dropped, like for any other
+// write; the flush drops them again once the database has the update.
+func bufferItemUpdate(ctx context.Context, before, item *models.Item) error {
+	data, err := cache.Marshal(item)
+	if err != nil {
+		return err
+	}
+	record, err := newAuditRecord(ctx, models.AuditOperationUpdate, item.ID, before, item)
+	if err != nil {
+		return err
+	}
+	auditData, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = config.RedisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, writeBehindPendingKey, item.ID.String(), data, auditFieldPrefix+uuid.NewString(), auditData)
+		pipe.Set(ctx, itemCacheKey(item.ID), data, cacheTTL)
+		pipe.Del(ctx, allItemsCacheKey)
+		pipe.Del(ctx, tagVersionKey(itemsTag))
+		return nil
+	})
+	return err
+}
+
+// bufferedItem returns the latest update buffered for id that has not been
+// flushed yet.
+func bufferedItem(ctx context.Context, id uuid.UUID) (*models.Item, bool) {
+	for _, key := range []string{writeBehindPendingKey, writeBehindInflightKey} {
+		data, err := config.RedisClient.HGet(ctx, key, id.String()).Bytes()
+		if err != nil {
+			continue
+		}
+		var item models.Item
+		if err := cache.Unmarshal(data, &item); err == nil {
+			return &item, true
+		}
+	}
+	return nil, false
+}
+
+// FlushWriteBehind applies buffered item updates to the database in batches
+// of batchSize and returns the number of items written.
+func FlushWriteBehind(ctx context.Context, batchSize int) (flushed int, err error) {
+	ctx, span := tracing.Start(ctx, "services.FlushWriteBehind")
+	defer func() { tracing.End(span, err) }()
+
+	exists, err := config.RedisClient.Exists(ctx, writeBehindInflightKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	if exists == 0 {
+		err = config.RedisClient.Rename(ctx, writeBehindPendingKey, writeBehindInflightKey).Err()
+		if err != nil {
+			if strings.Contains(err.Error(), "no such key") {
+				return 0, nil
+			}
+			return 0, err
+		}
+	}
+
+	entries, err := config.RedisClient.HGetAll(ctx, writeBehindInflightKey).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	items := make([]models.Item, 0, len(entries))
+	audits := make(map[uuid.UUID][]models.AuditRecord)
+	auditFields := make(map[uuid.UUID][]string)
+	for field, data := range entries {
+		if strings.HasPrefix(field, auditFieldPrefix) {
+			var record models.AuditRecord
+			if err := json.Unmarshal([]byte(data), &record); err != nil {
+				slog.ErrorContext(ctx, "Dropping unreadable write-behind audit record", "field", field, "error", err)
+				continue
+			}
+			audits[record.ItemID] = append(audits[record.ItemID], record)
+			auditFields[record.ItemID] = append(auditFields[record.ItemID], field)
+			continue
+		}
+		var item models.Item
+		if err := cache.Unmarshal([]byte(data), &item); err != nil {
+			slog.ErrorContext(ctx, "Dropping unreadable write-behind entry", "item_id", field, "error", err)
+			continue
+		}
+		items = append(items, item)
+	}
+
+	if batchSize <= 0 {
+		batchSize = len(items)
+	}
+	for start := 0; start < len(items); start += batchSize {
+		end := min(start+batchSize, len(items))
+		batch := items[start:end]
+		err := repository.WithTransaction(ctx, func(ctx context.Context) error {
+			updated, err := repository.UpdateItems(ctx, batch)
+			if err != nil {
+				return err
+			}
+			// Items deleted since the update was buffered are not written,
+			// so their updates are not audited either.
+			var records []models.AuditRecord
+			for _, id := range updated {
+				records = append(records, audits[id]...)
+			}
+			slices.SortStableFunc(records, func(a, b models.AuditRecord) int {
+				return a.CreatedAt.Compare(b.CreatedAt)
+			})
+			for i := range records {
+				if err := repository.AppendAuditRecord(ctx, &records[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return start, err
+		}
+
+		// A later batch may fail, so the committed entries are removed now
+		// rather than replayed, and audited, a second time.
+		fields := make([]string, 0, len(batch))
+		for _, item := range batch {
+			fields = append(fields, item.ID.String())
+			fields = append(fields, auditFields[item.ID]...)
+		}
+		if err := config.RedisClient.HDel(ctx, writeBehindInflightKey, fields...).Err(); err != nil {
+			return end, err
+		}
+	}
+
+	if err := config.RedisClient.Del(ctx, writeBehindInflightKey).Err(); err != nil {
+		return len(items), err
+	}
+	// The cached list and query results were loaded from the database before
+	// the flush. They live in other cluster slots than the in-flight key, so
+	// they are not deleted with it: a DEL of all three would fail with
+	// CROSSSLOT and the same batch would be replayed forever.
+	invalidateCache(ctx, allItemsCacheKey)
+	invalidateTag(ctx, itemsTag)
+	return len(items), nil
+}
+
+// StartWriteBehindWorker flushes buffered updates every interval until ctx is
+// cancelled. Anything left in flight by a previous process is replayed first.
+func StartWriteBehindWorker(ctx context.Context, interval time.Duration, batchSize int) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if n, err := FlushWriteBehind(ctx, batchSize); err != nil {
+				slog.ErrorContext(ctx, "Write-behind flush failed", "error", err)
+			} else if n > 0 {
+				slog.InfoContext(ctx, "Write-behind flushed items", "items", n)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
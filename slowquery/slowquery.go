@@ -0,0 +1,235 @@
+// Package slowquery records GORM statements that exceed a latency threshold
+// and, on Postgres, captures their query plans.
+package slowquery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rahulmishra/go-crud-app/gormhooks"
+	"github.com/rahulmishra/go-crud-app/logging"
+	"gorm.io/gorm"
+)
+
+const startTimeKey = "slowquery:start_time"
+
+type Options struct {
+	// Threshold is the duration above which a statement is recorded.
+	// Zero disables the log.
+	Threshold time.Duration
+	// Capacity is the number of distinct statements kept, slowest first.
+	Capacity int
+	// Explain captures EXPLAIN (FORMAT JSON) for recorded SELECTs. It is only
+	// supported on Postgres and is ignored elsewhere.
+	Explain bool
+	// ExplainTimeout bounds each EXPLAIN.
+	ExplainTimeout time.Duration
+}
+
+// Entry describes the slowest execution seen of one SQL statement.
+type Entry struct {
+	SQL          string          `json:"sql"`
+	Operation    string          `json:"operation"`
+	Table        string          `json:"table,omitempty"`
+	BindCount    int             `json:"bind_count"`
+	RowsAffected int64           `json:"rows_affected"`
+	DurationMS   float64         `json:"duration_ms"`
+	Count        int             `json:"count"`
+	RequestID    string          `json:"request_id,omitempty"`
+	LastSeenAt   time.Time       `json:"last_seen_at"`
+	Plan         json.RawMessage `json:"plan,omitempty"`
+	PlanError    string          `json:"plan_error,omitempty"`
+
+	duration time.Duration
+	vars     []interface{}
+}
+
+// explainFunc runs EXPLAIN for sql with its bind values.
+type explainFunc func(ctx context.Context, sql string, vars []interface{}) (json.RawMessage, error)
+
+var (
+	mu      sync.Mutex
+	opts    Options
+	entries = map[string]*Entry{}
+	explain explainFunc
+)
+
+// Instrument times every statement run through db and records those slower
+// than opts.Threshold.
+func Instrument(db *gorm.DB, o Options) error {
+	if o.Threshold <= 0 {
+		return nil
+	}
+	if o.Capacity <= 0 {
+		o.Capacity = 50
+	}
+	if o.ExplainTimeout <= 0 {
+		o.ExplainTimeout = 5 * time.Second
+	}
+
+	mu.Lock()
+	opts = o
+	explain = nil
+	if o.Explain && db.Dialector.Name() == "postgres" {
+		explain = postgresExplainer(db)
+	}
+	mu.Unlock()
+
+	return gormhooks.Register(db, "slowquery", gormhooks.Always(startTimer), observe)
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(startTimeKey, time.Now())
+}
+
+func observe(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startTimeKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+		elapsed := time.Since(start)
+
+		mu.Lock()
+		threshold := opts.Threshold
+		mu.Unlock()
+		if elapsed <= threshold {
+			return
+		}
+
+		ctx := db.Statement.Context
+		entry := Entry{
+			SQL:          db.Statement.SQL.String(),
+			Operation:    operation,
+			Table:        db.Statement.Table,
+			BindCount:    len(db.Statement.Vars),
+			RowsAffected: db.RowsAffected,
+			DurationMS:   float64(elapsed.Microseconds()) / 1000,
+			RequestID:    logging.RequestID(ctx),
+			LastSeenAt:   time.Now().UTC(),
+			duration:     elapsed,
+			vars:         append([]interface{}(nil), db.Statement.Vars...),
+		}
+		slog.WarnContext(ctx, "Slow query",
+			"sql", entry.SQL,
+			"operation", operation,
+			"bind_count", entry.BindCount,
+			"rows_affected", entry.RowsAffected,
+			"duration", elapsed,
+		)
+		record(entry)
+	}
+}
+
+// record stores entry if it is among the slowest statements and starts an
+// EXPLAIN when a new plan is needed.
+func record(entry Entry) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if existing, ok := entries[entry.SQL]; ok {
+		existing.Count++
+		existing.LastSeenAt = entry.LastSeenAt
+		if entry.duration > existing.duration {
+			existing.duration = entry.duration
+			existing.DurationMS = entry.DurationMS
+			existing.RowsAffected = entry.RowsAffected
+			existing.RequestID = entry.RequestID
+			existing.vars = entry.vars
+		}
+		return
+	}
+
+	if len(entries) >= opts.Capacity {
+		var fastest *Entry
+		for _, e := range entries {
+			if fastest == nil || e.duration < fastest.duration {
+				fastest = e
+			}
+		}
+		if fastest.duration >= entry.duration {
+			return
+		}
+		delete(entries, fastest.SQL)
+	}
+
+	entry.Count = 1
+	stored := &entry
+	entries[entry.SQL] = stored
+	if explain != nil && explainable(entry.SQL) {
+		go capturePlan(explain, opts.ExplainTimeout, stored.SQL, stored.vars)
+	}
+}
+
+// explainable reports whether a plan should be captured for sql. EXPLAIN
+// without ANALYZE does not run the statement, but plans are only useful
+// for reads, where missing indexes show up.
+func explainable(sql string) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(sql)), "SELECT")
+}
+
+func capturePlan(run explainFunc, timeout time.Duration, sql string, vars []interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	plan, err := run(ctx, sql, vars)
+
+	mu.Lock()
+	defer mu.Unlock()
+	entry, ok := entries[sql]
+	if !ok {
+		return
+	}
+	if err != nil {
+		entry.PlanError = err.Error()
+		return
+	}
+	entry.Plan = plan
+}
+
+// postgresExplainer runs EXPLAIN on the underlying connection pool so the
+// EXPLAIN itself does not pass through the GORM callbacks.
+func postgresExplainer(db *gorm.DB) explainFunc {
+	return func(ctx context.Context, sql string, vars []interface{}) (json.RawMessage, error) {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		var plan []byte
+		if err := sqlDB.QueryRowContext(ctx, "EXPLAIN (FORMAT JSON) "+sql, vars...).Scan(&plan); err != nil {
+			return nil, err
+		}
+		if !json.Valid(plan) {
+			return nil, errors.New("slowquery: EXPLAIN returned invalid JSON")
+		}
+		return plan, nil
+	}
+}
+
+// Entries returns the recorded statements, slowest first.
+func Entries() []Entry {
+	mu.Lock()
+	defer mu.Unlock()
+	out := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, *e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].duration > out[j].duration })
+	return out
+}
+
+// Reset clears the recorded statements.
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	entries = map[string]*Entry{}
+}
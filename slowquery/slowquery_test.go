@@ -0,0 +1,89 @@
+package slowquery
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rahulmishra/go-crud-app/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type widget struct {
+	ID   uint
+	Name string
+}
+
+func setupSlowQueryDB(t *testing.T, o Options) *gorm.DB {
+	t.Helper()
+	Reset()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&widget{}))
+	require.NoError(t, Instrument(db, o))
+	t.Cleanup(Reset)
+	return db
+}
+
+func TestInstrument_RecordsSlowStatements(t *testing.T) {
+	db := setupSlowQueryDB(t, Options{Threshold: time.Nanosecond})
+
+	ctx := logging.WithRequestID(context.Background(), "req-9")
+	require.NoError(t, db.WithContext(ctx).Create(&widget{Name: "a"}).Error)
+	var found []widget
+	require.NoError(t, db.WithContext(ctx).Where("name = ? AND id > ?", "a", 0).Find(&found).Error)
+	require.NoError(t, db.WithContext(ctx).Where("name = ? AND id > ?", "a", 0).Find(&found).Error)
+
+	entries := Entries()
+	require.Len(t, entries, 2)
+	var query Entry
+	for _, e := range entries {
+		if e.Operation == "query" {
+			query = e
+		}
+	}
+	assert.Contains(t, query.SQL, "SELECT * FROM `widgets` WHERE name = ? AND id > ?")
+	assert.Equal(t, 2, query.BindCount)
+	assert.Equal(t, int64(1), query.RowsAffected)
+	assert.Equal(t, 2, query.Count, "repeated statements are grouped")
+	assert.Equal(t, "req-9", query.RequestID)
+	assert.Equal(t, "widgets", query.Table)
+	assert.Empty(t, query.Plan, "plans are only captured on Postgres")
+}
+
+func TestInstrument_IgnoresFastStatements(t *testing.T) {
+	db := setupSlowQueryDB(t, Options{Threshold: time.Hour})
+	require.NoError(t, db.Create(&widget{Name: "a"}).Error)
+	assert.Empty(t, Entries())
+}
+
+func TestRecord_KeepsSlowestAndCapturesPlans(t *testing.T) {
+	setupSlowQueryDB(t, Options{Threshold: time.Nanosecond, Capacity: 2})
+	explained := make(chan string, 3)
+	mu.Lock()
+	explain = func(ctx context.Context, sql string, vars []interface{}) (json.RawMessage, error) {
+		explained <- sql
+		return json.RawMessage(`[{"Plan":{"Node Type":"Seq Scan"}}]`), nil
+	}
+	mu.Unlock()
+
+	record(Entry{SQL: "SELECT 1", duration: 3 * time.Second})
+	record(Entry{SQL: "UPDATE widgets SET name = ?", duration: 2 * time.Second})
+	record(Entry{SQL: "SELECT 2", duration: time.Second})
+	record(Entry{SQL: "SELECT 3", duration: 4 * time.Second})
+
+	assert.ElementsMatch(t, []string{"SELECT 1", "SELECT 3"}, []string{<-explained, <-explained})
+	assert.Eventually(t, func() bool {
+		entries := Entries()
+		return len(entries) == 2 && entries[0].Plan != nil && entries[1].Plan != nil
+	}, time.Second, 10*time.Millisecond)
+
+	entries := Entries()
+	assert.Equal(t, "SELECT 3", entries[0].SQL)
+	assert.Equal(t, "SELECT 1", entries[1].SQL)
+	assert.Empty(t, explained, "writes and statements that were not kept are not explained")
+}
@@ -0,0 +1,56 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rahulmishra/go-crud-app/middleware"
+	"github.com/rahulmishra/go-crud-app/services"
+)
+
+// User is a caller identified by a JWT.
+type User struct {
+	ID     string
+	Roles  []string
+	Groups []string
+}
+
+// Reader, Editor and Admin return users holding the corresponding role.
+func Reader(id string, groups ...string) User {
+	return User{ID: id, Roles: []string{middleware.RoleReader}, Groups: groups}
+}
+
+func Editor(id string, groups ...string) User {
+	return User{ID: id, Roles: []string{middleware.RoleEditor}, Groups: groups}
+}
+
+func Admin(id string) User {
+	return User{ID: id, Roles: []string{middleware.RoleAdmin}}
+}
+
+// Actor returns the service-layer actor for u, for fixtures that call
+// services directly.
+func (u User) Actor() *services.Actor {
+	principal := &middleware.Principal{Subject: u.ID, Roles: u.Roles, Groups: u.Groups, Method: middleware.AuthMethodJWT}
+	return principal.Actor()
+}
+
+// Token signs a bearer token for u that the server accepts.
+func (s *Server) Token(u User) string {
+	s.t.Helper()
+	return s.token(s.t, u)
+}
+
+func (s *Server) token(t testing.TB, u User) string {
+	t.Helper()
+	claims := jwt.MapClaims{
+		"sub":    u.ID,
+		"roles":  u.Roles,
+		"groups": u.Groups,
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(JWTSecret))
+	must(t, err)
+	return token
+}
@@ -0,0 +1,64 @@
+package testutil
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/cache"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/seed"
+	"github.com/rahulmishra/go-crud-app/services"
+)
+
+// CreateItem stores an item owned by owner through the service layer, so
+// audit records and cache invalidation happen as they would for a request.
+func (s *Server) CreateItem(owner User, name string, price float64) models.Item {
+	s.t.Helper()
+	item := models.Item{ID: uuid.New(), Name: name, Price: price}
+	must(s.t, services.CreateItem(s.Context(owner), &item))
+	return item
+}
+
+// ShareItem grants grantee access to an item owned by owner.
+func (s *Server) ShareItem(owner User, itemID uuid.UUID, granteeType, granteeID, permission string) models.ItemShare {
+	s.t.Helper()
+	share, err := services.ShareItem(s.Context(owner), itemID, granteeType, granteeID, permission)
+	must(s.t, err)
+	return *share
+}
+
+// Seed inserts the deterministic catalog described by opts, as the seed
+// command would, and returns it. Owners default to the fixture users alice
+// and bob.
+func (s *Server) Seed(opts seed.Options) seed.Dataset {
+	s.t.Helper()
+	if opts.Owners == nil {
+		opts.Owners = []string{"alice", "bob"}
+	}
+	data, _, err := seed.Run(context.Background(), opts)
+	must(s.t, err)
+	return data
+}
+
+// Context returns a context acting as u.
+func (s *Server) Context(u User) context.Context {
+	return services.WithActor(context.Background(), u.Actor())
+}
+
+// StoredItem loads an item straight from the database, bypassing the cache.
+// Soft-deleted items are not found.
+func (s *Server) StoredItem(id uuid.UUID) (models.Item, bool) {
+	var item models.Item
+	err := s.DB.First(&item, "id = ?", id).Error
+	return item, err == nil
+}
+
+// CachedItem decodes the cached copy of an item, if there is one.
+func (s *Server) CachedItem(id uuid.UUID) (models.Item, bool) {
+	var item models.Item
+	data, ok := s.Redis.Get("item:" + id.String())
+	if !ok || cache.Unmarshal(data, &item) != nil {
+		return models.Item{}, false
+	}
+	return item, true
+}
@@ -0,0 +1,89 @@
+package redistest
+
+import "strings"
+
+// Redis Cluster rejects commands whose keys hash to different slots. The
+// server is a single node, but with slot checks enabled it rejects the same
+// commands, so code meant to run against a cluster can be tested here.
+
+const clusterSlots = 16384
+
+var errCrossSlot = errorReply("CROSSSLOT Keys in request don't hash to the same slot")
+
+// EnforceSlots enables or disables cluster slot checks for multi-key
+// commands.
+func (s *Server) EnforceSlots(enforce bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enforceSlots = enforce
+}
+
+// commandKeys returns the keys named by a multi-key command, or nil for
+// commands with at most one key.
+func commandKeys(args []string) []string {
+	switch strings.ToLower(args[0]) {
+	case "del", "unlink", "exists", "mget":
+		return args[1:]
+	case "rename":
+		return args[1:3]
+	case "eval", "evalsha":
+		if len(args) < 3 {
+			return nil
+		}
+		n := 0
+		for _, ch := range args[2] {
+			if ch < '0' || ch > '9' {
+				return nil
+			}
+			n = n*10 + int(ch-'0')
+		}
+		if 3+n > len(args) {
+			return nil
+		}
+		return args[3 : 3+n]
+	}
+	return nil
+}
+
+// checkSlots returns a CROSSSLOT error when slot checks are enabled and the
+// keys of args span more than one slot. The caller holds the lock.
+func (s *Server) checkSlots(args []string) any {
+	if !s.enforceSlots {
+		return nil
+	}
+	keys := commandKeys(args)
+	for _, key := range keys[min(1, len(keys)):] {
+		if KeySlot(key) != KeySlot(keys[0]) {
+			return errCrossSlot
+		}
+	}
+	return nil
+}
+
+// KeySlot returns the cluster slot of key: the CRC16 of its hash tag, the
+// part between the first "{" and the next "}", or of the whole key when it
+// has no non-empty tag.
+func KeySlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key)) % clusterSlots
+}
+
+// crc16 is the CRC-16/XMODEM checksum Redis Cluster uses.
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,531 @@
+package redistest
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type call struct {
+	server *Server
+	client *client
+	args   []string
+}
+
+const (
+	// flagNoLock marks commands that must not hold the server lock, because
+	// they only touch connection or subscription state.
+	flagNoLock = 1 << iota
+	// flagNoScript marks commands scripts may not call.
+	flagNoScript
+)
+
+type command struct {
+	// arity follows Redis: a positive value is the exact number of
+	// arguments including the command name, a negative value the minimum.
+	arity int
+	flags int
+	fn    func(*call) any
+}
+
+// commands is filled in by init because EVAL refers back to the table.
+var commands map[string]command
+
+// allowedWhileSubscribed lists the commands a connection in pub/sub mode
+// may still send.
+var allowedWhileSubscribed = map[string]bool{
+	"subscribe": true, "unsubscribe": true, "psubscribe": true, "punsubscribe": true,
+	"ping": true, "quit": true,
+}
+
+func init() {
+	commands = map[string]command{
+		// Connection
+		"ping":   {-1, 0, ping},
+		"echo":   {2, 0, func(c *call) any { return c.args[1] }},
+		"quit":   {1, flagNoLock | flagNoScript, func(*call) any { return okReply }},
+		"select": {2, flagNoLock, selectDB},
+		"client": {-2, flagNoLock | flagNoScript, clientCmd},
+		"hello":  {-1, flagNoLock | flagNoScript, unknownCommand},
+		"auth":   {-2, flagNoLock | flagNoScript, auth},
+
+		// Server
+		"flushall": {-1, 0, flushAll},
+		"flushdb":  {-1, 0, flushAll},
+		"dbsize":   {1, 0, func(c *call) any { return len(c.server.keys("*")) }},
+
+		// Keys
+		"del":     {-2, 0, del},
+		"unlink":  {-2, 0, del},
+		"exists":  {-2, 0, exists},
+		"type":    {2, 0, typeCmd},
+		"keys":    {2, 0, func(c *call) any { return c.server.keys(c.args[1]) }},
+		"rename":  {3, 0, rename},
+		"expire":  {3, 0, func(c *call) any { return expire(c, time.Second) }},
+		"pexpire": {3, 0, func(c *call) any { return expire(c, time.Millisecond) }},
+		"persist": {2, 0, persist},
+		"ttl":     {2, 0, func(c *call) any { return ttl(c, time.Second) }},
+		"pttl":    {2, 0, func(c *call) any { return ttl(c, time.Millisecond) }},
+
+		// Strings
+		"get":    {2, 0, get},
+		"mget":   {-2, 0, mget},
+		"set":    {-3, 0, setCmd},
+		"setnx":  {3, 0, setNX},
+		"incr":   {2, 0, func(c *call) any { return incrBy(c, c.args[1], 1) }},
+		"decr":   {2, 0, func(c *call) any { return incrBy(c, c.args[1], -1) }},
+		"incrby": {3, 0, func(c *call) any { return incrByArg(c, 1) }},
+		"decrby": {3, 0, func(c *call) any { return incrByArg(c, -1) }},
+
+		// Hashes
+		"hset":    {-4, 0, hset},
+		"hget":    {3, 0, hget},
+		"hgetall": {2, 0, hgetall},
+		"hdel":    {-3, 0, hdel},
+		"hlen":    {2, 0, hlen},
+
+		// Sets
+		"sadd":      {-3, 0, sadd},
+		"srem":      {-3, 0, srem},
+		"smembers":  {2, 0, smembers},
+		"sismember": {3, 0, sismember},
+		"scard":     {2, 0, scard},
+
+		// Sorted sets
+		"zadd":             {-4, 0, zadd},
+		"zincrby":          {4, 0, zincrby},
+		"zrem":             {-3, 0, zrem},
+		"zscore":           {3, 0, zscore},
+		"zcard":            {2, 0, zcard},
+		"zrange":           {-4, 0, zrange},
+		"zrevrange":        {-4, 0, func(c *call) any { return zrangeAlias(c, "", true) }},
+		"zrangebyscore":    {-4, 0, func(c *call) any { return zrangeAlias(c, "byscore", false) }},
+		"zrevrangebyscore": {-4, 0, func(c *call) any { return zrangeAlias(c, "byscore", true) }},
+		"zrangebylex":      {-4, 0, func(c *call) any { return zrangeAlias(c, "bylex", false) }},
+		"zrevrangebylex":   {-4, 0, func(c *call) any { return zrangeAlias(c, "bylex", true) }},
+
+		// Pub/sub
+		"subscribe":    {-2, flagNoLock | flagNoScript, subscribe},
+		"psubscribe":   {-2, flagNoLock | flagNoScript, psubscribe},
+		"unsubscribe":  {-1, flagNoLock | flagNoScript, unsubscribe},
+		"punsubscribe": {-1, flagNoLock | flagNoScript, punsubscribe},
+		"publish":      {3, 0, publish},
+
+		// Scripting
+		"eval":    {-3, flagNoScript, eval},
+		"evalsha": {-3, flagNoScript, evalSHA},
+		"script":  {-2, flagNoScript, script},
+	}
+}
+
+// lookupCommand resolves args[0] and checks the argument count.
+func lookupCommand(args []string) (command, any) {
+	name := strings.ToLower(args[0])
+	cmd, ok := commands[name]
+	if !ok {
+		return command{}, errorf("ERR unknown command '%s'", args[0])
+	}
+	if (cmd.arity > 0 && len(args) != cmd.arity) || (cmd.arity < 0 && len(args) < -cmd.arity) {
+		return command{}, errorf("ERR wrong number of arguments for '%s' command", name)
+	}
+	return cmd, nil
+}
+
+func unknownCommand(c *call) any {
+	return errorf("ERR unknown command '%s'", c.args[0])
+}
+
+func ping(c *call) any {
+	if c.client != nil && c.server.pubsub.subscribed(c.client) {
+		message := ""
+		if len(c.args) > 1 {
+			message = c.args[1]
+		}
+		return []any{"pong", message}
+	}
+	if len(c.args) > 1 {
+		return c.args[1]
+	}
+	return statusReply("PONG")
+}
+
+func selectDB(c *call) any {
+	if c.args[1] != "0" {
+		return errorReply("ERR DB index is out of range")
+	}
+	return okReply
+}
+
+func clientCmd(c *call) any {
+	switch strings.ToLower(c.args[1]) {
+	case "setname", "setinfo":
+		return okReply
+	case "getname":
+		return nil
+	default:
+		return errorf("ERR unknown subcommand '%s'", c.args[1])
+	}
+}
+
+func auth(*call) any {
+	return errorReply("ERR AUTH <password> called without any password configured for the default user")
+}
+
+func flushAll(c *call) any {
+	c.server.data = map[string]*entry{}
+	return okReply
+}
+
+func del(c *call) any {
+	n := 0
+	for _, key := range c.args[1:] {
+		if c.server.lookup(key) != nil {
+			delete(c.server.data, key)
+			n++
+		}
+	}
+	return n
+}
+
+func exists(c *call) any {
+	n := 0
+	for _, key := range c.args[1:] {
+		if c.server.lookup(key) != nil {
+			n++
+		}
+	}
+	return n
+}
+
+func typeCmd(c *call) any {
+	e := c.server.lookup(c.args[1])
+	if e == nil {
+		return statusReply("none")
+	}
+	switch e.value.(type) {
+	case string:
+		return statusReply("string")
+	case hash:
+		return statusReply("hash")
+	case set:
+		return statusReply("set")
+	default:
+		return statusReply("zset")
+	}
+}
+
+func rename(c *call) any {
+	e := c.server.lookup(c.args[1])
+	if e == nil {
+		return errNoSuchKey
+	}
+	delete(c.server.data, c.args[1])
+	c.server.data[c.args[2]] = e
+	return okReply
+}
+
+func expire(c *call, unit time.Duration) any {
+	n, err := strconv.ParseInt(c.args[2], 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	e := c.server.lookup(c.args[1])
+	if e == nil {
+		return 0
+	}
+	e.expireAt = c.server.now().Add(time.Duration(n) * unit)
+	// A TTL that has already passed deletes the key right away.
+	c.server.lookup(c.args[1])
+	return 1
+}
+
+func persist(c *call) any {
+	e := c.server.lookup(c.args[1])
+	if e == nil || e.expireAt.IsZero() {
+		return 0
+	}
+	e.expireAt = time.Time{}
+	return 1
+}
+
+func ttl(c *call, unit time.Duration) any {
+	e := c.server.lookup(c.args[1])
+	switch {
+	case e == nil:
+		return -2
+	case e.expireAt.IsZero():
+		return -1
+	}
+	remaining := e.expireAt.Sub(c.server.now())
+	return int64((remaining + unit/2) / unit)
+}
+
+func get(c *call) any {
+	e := c.server.lookup(c.args[1])
+	if e == nil {
+		return nil
+	}
+	value, ok := e.value.(string)
+	if !ok {
+		return errWrongType
+	}
+	return value
+}
+
+func mget(c *call) any {
+	values := make([]any, len(c.args)-1)
+	for i, key := range c.args[1:] {
+		if e := c.server.lookup(key); e != nil {
+			if value, ok := e.value.(string); ok {
+				values[i] = value
+			}
+		}
+	}
+	return values
+}
+
+// setCmd implements SET key value [NX|XX] [GET] [EX s|PX ms|KEEPTTL].
+func setCmd(c *call) any {
+	key, value := c.args[1], c.args[2]
+	var nx, xx, returnOld, keepTTL bool
+	var expireIn time.Duration
+	for i := 3; i < len(c.args); i++ {
+		switch strings.ToLower(c.args[i]) {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "get":
+			returnOld = true
+		case "keepttl":
+			keepTTL = true
+		case "ex", "px":
+			if i+1 >= len(c.args) || expireIn != 0 {
+				return errSyntax
+			}
+			n, err := strconv.ParseInt(c.args[i+1], 10, 64)
+			if err != nil {
+				return errNotInteger
+			}
+			if n <= 0 {
+				return errorReply("ERR invalid expire time in 'set' command")
+			}
+			unit := time.Second
+			if strings.EqualFold(c.args[i], "px") {
+				unit = time.Millisecond
+			}
+			expireIn = time.Duration(n) * unit
+			i++
+		default:
+			return errSyntax
+		}
+	}
+	if (nx && xx) || (keepTTL && expireIn != 0) {
+		return errSyntax
+	}
+
+	existing := c.server.lookup(key)
+	var old any
+	if existing != nil {
+		s, ok := existing.value.(string)
+		if !ok && returnOld {
+			return errWrongType
+		}
+		old = s
+	}
+	if (nx && existing != nil) || (xx && existing == nil) {
+		if returnOld {
+			return old
+		}
+		return nil
+	}
+
+	next := &entry{value: value}
+	if expireIn != 0 {
+		next.expireAt = c.server.now().Add(expireIn)
+	} else if keepTTL && existing != nil {
+		next.expireAt = existing.expireAt
+	}
+	c.server.data[key] = next
+	if returnOld {
+		return old
+	}
+	return okReply
+}
+
+func setNX(c *call) any {
+	if c.server.lookup(c.args[1]) != nil {
+		return 0
+	}
+	c.server.data[c.args[1]] = &entry{value: c.args[2]}
+	return 1
+}
+
+func incrByArg(c *call, sign int64) any {
+	delta, err := strconv.ParseInt(c.args[2], 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	return incrBy(c, c.args[1], sign*delta)
+}
+
+func incrBy(c *call, key string, delta int64) any {
+	e := c.server.lookup(key)
+	if e == nil {
+		e = &entry{value: "0"}
+		c.server.data[key] = e
+	}
+	s, ok := e.value.(string)
+	if !ok {
+		return errWrongType
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+		return errorReply("ERR increment or decrement would overflow")
+	}
+	n += delta
+	e.value = strconv.FormatInt(n, 10)
+	return n
+}
+
+func hset(c *call) any {
+	if len(c.args)%2 != 0 {
+		return errorReply("ERR wrong number of arguments for 'hset' command")
+	}
+	h, err := lookupAs(c.server, c.args[1], func() hash { return hash{} })
+	if err != nil {
+		return err
+	}
+	added := 0
+	for i := 2; i < len(c.args); i += 2 {
+		if _, ok := h[c.args[i]]; !ok {
+			added++
+		}
+		h[c.args[i]] = c.args[i+1]
+	}
+	return added
+}
+
+func hget(c *call) any {
+	h, err := lookupAs[hash](c.server, c.args[1], nil)
+	if err != nil {
+		return err
+	}
+	if value, ok := h[c.args[2]]; ok {
+		return value
+	}
+	return nil
+}
+
+func hgetall(c *call) any {
+	h, err := lookupAs[hash](c.server, c.args[1], nil)
+	if err != nil {
+		return err
+	}
+	fields := make([]string, 0, len(h))
+	for field := range h {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+	reply := make([]string, 0, 2*len(h))
+	for _, field := range fields {
+		reply = append(reply, field, h[field])
+	}
+	return reply
+}
+
+func hdel(c *call) any {
+	h, err := lookupAs[hash](c.server, c.args[1], nil)
+	if err != nil {
+		return err
+	}
+	n := 0
+	for _, field := range c.args[2:] {
+		if _, ok := h[field]; ok {
+			delete(h, field)
+			n++
+		}
+	}
+	if h != nil {
+		c.server.dropIfEmpty(c.args[1], len(h))
+	}
+	return n
+}
+
+func hlen(c *call) any {
+	h, err := lookupAs[hash](c.server, c.args[1], nil)
+	if err != nil {
+		return err
+	}
+	return len(h)
+}
+
+func sadd(c *call) any {
+	members, err := lookupAs(c.server, c.args[1], func() set { return set{} })
+	if err != nil {
+		return err
+	}
+	added := 0
+	for _, member := range c.args[2:] {
+		if _, ok := members[member]; !ok {
+			members[member] = struct{}{}
+			added++
+		}
+	}
+	return added
+}
+
+func srem(c *call) any {
+	members, err := lookupAs[set](c.server, c.args[1], nil)
+	if err != nil {
+		return err
+	}
+	removed := 0
+	for _, member := range c.args[2:] {
+		if _, ok := members[member]; ok {
+			delete(members, member)
+			removed++
+		}
+	}
+	if members != nil {
+		c.server.dropIfEmpty(c.args[1], len(members))
+	}
+	return removed
+}
+
+func smembers(c *call) any {
+	members, err := lookupAs[set](c.server, c.args[1], nil)
+	if err != nil {
+		return err
+	}
+	reply := make([]string, 0, len(members))
+	for member := range members {
+		reply = append(reply, member)
+	}
+	slices.Sort(reply)
+	return reply
+}
+
+func sismember(c *call) any {
+	members, err := lookupAs[set](c.server, c.args[1], nil)
+	if err != nil {
+		return err
+	}
+	if _, ok := members[c.args[2]]; ok {
+		return 1
+	}
+	return 0
+}
+
+func scard(c *call) any {
+	members, err := lookupAs[set](c.server, c.args[1], nil)
+	if err != nil {
+		return err
+	}
+	return len(members)
+}
@@ -0,0 +1,255 @@
+package redistest
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// scriptTimeout stops runaway scripts, which would otherwise hang the test
+// while holding the server lock.
+const scriptTimeout = 5 * time.Second
+
+func eval(c *call) any {
+	return c.server.runScript(c.client, c.args[1], c.args[2:])
+}
+
+func evalSHA(c *call) any {
+	src, ok := c.server.scripts[strings.ToLower(c.args[1])]
+	if !ok {
+		return errNoScript
+	}
+	return c.server.runScript(c.client, src, c.args[2:])
+}
+
+// script implements SCRIPT LOAD, EXISTS and FLUSH.
+func script(c *call) any {
+	switch strings.ToLower(c.args[1]) {
+	case "load":
+		if len(c.args) != 3 {
+			return errorReply("ERR wrong number of arguments for 'script|load' command")
+		}
+		sha := sha1Hex(c.args[2])
+		c.server.scripts[sha] = c.args[2]
+		return sha
+	case "exists":
+		found := make([]any, 0, len(c.args)-2)
+		for _, sha := range c.args[2:] {
+			_, ok := c.server.scripts[strings.ToLower(sha)]
+			found = append(found, boolInt(ok))
+		}
+		return found
+	case "flush":
+		c.server.scripts = map[string]string{}
+		return okReply
+	default:
+		return errorf("ERR unknown subcommand '%s'", c.args[1])
+	}
+}
+
+// runScript evaluates src with args in the form "numkeys key... arg...".
+// Replies are converted between Redis and Lua as Redis does.
+func (s *Server) runScript(c *client, src string, args []string) any {
+	numKeys, err := strconv.Atoi(args[0])
+	if err != nil {
+		return errNotInteger
+	}
+	if numKeys < 0 {
+		return errorReply("ERR Number of keys can't be negative")
+	}
+	if numKeys > len(args)-1 {
+		return errorReply("ERR Number of keys can't be greater than number of args")
+	}
+	s.scripts[sha1Hex(src)] = src
+
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	defer L.Close()
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), scriptTimeout)
+	defer cancel()
+	L.SetContext(ctx)
+
+	L.SetGlobal("KEYS", stringTable(L, args[1:1+numKeys]))
+	L.SetGlobal("ARGV", stringTable(L, args[1+numKeys:]))
+	L.SetGlobal("redis", s.redisModule(L, c))
+
+	fn, err := L.LoadString(src)
+	if err != nil {
+		return errorf("ERR Error compiling script: %s", firstLine(err.Error()))
+	}
+	L.Push(fn)
+	if err := L.PCall(0, 1, nil); err != nil {
+		var apiErr *lua.ApiError
+		if errors.As(err, &apiErr) {
+			if t, ok := apiErr.Object.(*lua.LTable); ok {
+				if msg, ok := t.RawGetString("err").(lua.LString); ok {
+					return errorReply(msg)
+				}
+			}
+		}
+		return errorf("ERR Error running script: %s", firstLine(err.Error()))
+	}
+	return fromLua(L.Get(-1))
+}
+
+func (s *Server) redisModule(L *lua.LState, c *client) *lua.LTable {
+	module := L.NewTable()
+	L.SetField(module, "call", L.NewFunction(func(L *lua.LState) int {
+		return s.luaCall(L, c, false)
+	}))
+	L.SetField(module, "pcall", L.NewFunction(func(L *lua.LState) int {
+		return s.luaCall(L, c, true)
+	}))
+	L.SetField(module, "error_reply", L.NewFunction(func(L *lua.LState) int {
+		L.Push(replyTable(L, "err", L.CheckString(1)))
+		return 1
+	}))
+	L.SetField(module, "status_reply", L.NewFunction(func(L *lua.LState) int {
+		L.Push(replyTable(L, "ok", L.CheckString(1)))
+		return 1
+	}))
+	L.SetField(module, "sha1hex", L.NewFunction(func(L *lua.LState) int {
+		L.Push(lua.LString(sha1Hex(L.CheckString(1))))
+		return 1
+	}))
+	return module
+}
+
+// luaCall runs a command from redis.call or redis.pcall. Errors raise for
+// call and are returned as an error table for pcall.
+func (s *Server) luaCall(L *lua.LState, c *client, protected bool) int {
+	n := L.GetTop()
+	if n == 0 {
+		L.Error(replyTable(L, "err", "ERR Please specify at least one argument for this redis lib call"), 1)
+		return 0
+	}
+	args := make([]string, n)
+	for i := range args {
+		switch v := L.Get(i + 1).(type) {
+		case lua.LString:
+			args[i] = string(v)
+		case lua.LNumber:
+			args[i] = v.String()
+		default:
+			L.Error(replyTable(L, "err", "ERR Lua redis lib command arguments must be strings or integers"), 1)
+			return 0
+		}
+	}
+
+	reply := s.dispatch(c, args, true)
+	if errReply, ok := reply.(errorReply); ok && !protected {
+		L.Error(replyTable(L, "err", string(errReply)), 1)
+		return 0
+	}
+	L.Push(toLua(L, reply))
+	return 1
+}
+
+func toLua(L *lua.LState, reply any) lua.LValue {
+	switch v := reply.(type) {
+	case nil:
+		return lua.LFalse
+	case string:
+		return lua.LString(v)
+	case int:
+		return lua.LNumber(v)
+	case int64:
+		return lua.LNumber(v)
+	case statusReply:
+		return replyTable(L, "ok", string(v))
+	case errorReply:
+		return replyTable(L, "err", string(v))
+	case []string:
+		return stringTable(L, v)
+	case []any:
+		t := L.CreateTable(len(v), 0)
+		for _, item := range v {
+			t.Append(toLua(L, item))
+		}
+		return t
+	default:
+		return lua.LFalse
+	}
+}
+
+// fromLua converts a script's return value: numbers are truncated to
+// integers, false becomes nil, and arrays stop at the first nil.
+func fromLua(value lua.LValue) any {
+	switch v := value.(type) {
+	case lua.LString:
+		return string(v)
+	case lua.LNumber:
+		return int64(v)
+	case lua.LBool:
+		if v {
+			return int64(1)
+		}
+		return nil
+	case *lua.LTable:
+		if msg, ok := v.RawGetString("err").(lua.LString); ok {
+			return errorReply(msg)
+		}
+		if msg, ok := v.RawGetString("ok").(lua.LString); ok {
+			return statusReply(msg)
+		}
+		items := []any{}
+		for i := 1; ; i++ {
+			item := v.RawGetInt(i)
+			if item == lua.LNil {
+				return items
+			}
+			items = append(items, fromLua(item))
+		}
+	default:
+		return nil
+	}
+}
+
+func stringTable(L *lua.LState, values []string) *lua.LTable {
+	t := L.CreateTable(len(values), 0)
+	for _, value := range values {
+		t.Append(lua.LString(value))
+	}
+	return t
+}
+
+func replyTable(L *lua.LState, field, message string) *lua.LTable {
+	t := L.NewTable()
+	t.RawSetString(field, lua.LString(message))
+	return t
+}
+
+func sha1Hex(src string) string {
+	sum := sha1.Sum([]byte(src))
+	return hex.EncodeToString(sum[:])
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
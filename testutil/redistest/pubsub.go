@@ -0,0 +1,133 @@
+package redistest
+
+import (
+	"slices"
+	"sync"
+)
+
+// broker tracks channel and pattern subscriptions. It has its own lock so
+// subscribing never waits on a running command.
+type broker struct {
+	mu       sync.Mutex
+	channels map[string]map[*client]struct{}
+	patterns map[string]map[*client]struct{}
+}
+
+func (b *broker) subscribed(c *client) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(c.channels)+len(c.patterns) > 0
+}
+
+// remove drops every subscription of a disconnected client.
+func (b *broker) remove(c *client) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for channel := range c.channels {
+		delete(b.channels[channel], c)
+	}
+	for pattern := range c.patterns {
+		delete(b.patterns[pattern], c)
+	}
+	clear(c.channels)
+	clear(c.patterns)
+}
+
+func subscribe(c *call) any {
+	return c.server.pubsub.add(c.client, "subscribe", c.args[1:], false)
+}
+
+func psubscribe(c *call) any {
+	return c.server.pubsub.add(c.client, "psubscribe", c.args[1:], true)
+}
+
+func unsubscribe(c *call) any {
+	return c.server.pubsub.drop(c.client, "unsubscribe", c.args[1:], false)
+}
+
+func punsubscribe(c *call) any {
+	return c.server.pubsub.drop(c.client, "punsubscribe", c.args[1:], true)
+}
+
+func (b *broker) add(c *client, kind string, names []string, pattern bool) pushes {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	index, own := b.index(c, pattern)
+	replies := make(pushes, 0, len(names))
+	for _, name := range names {
+		if (*index)[name] == nil {
+			(*index)[name] = map[*client]struct{}{}
+		}
+		(*index)[name][c] = struct{}{}
+		own[name] = struct{}{}
+		replies = append(replies, []any{kind, name, len(c.channels) + len(c.patterns)})
+	}
+	return replies
+}
+
+// drop removes the named subscriptions, or all of them when names is empty.
+func (b *broker) drop(c *client, kind string, names []string, pattern bool) pushes {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	index, own := b.index(c, pattern)
+	if len(names) == 0 {
+		for name := range own {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+	}
+	if len(names) == 0 {
+		return pushes{[]any{kind, nil, len(c.channels) + len(c.patterns)}}
+	}
+	replies := make(pushes, 0, len(names))
+	for _, name := range names {
+		delete((*index)[name], c)
+		delete(own, name)
+		replies = append(replies, []any{kind, name, len(c.channels) + len(c.patterns)})
+	}
+	return replies
+}
+
+func (b *broker) index(c *client, pattern bool) (*map[string]map[*client]struct{}, map[string]struct{}) {
+	if pattern {
+		if b.patterns == nil {
+			b.patterns = map[string]map[*client]struct{}{}
+		}
+		return &b.patterns, c.patterns
+	}
+	if b.channels == nil {
+		b.channels = map[string]map[*client]struct{}{}
+	}
+	return &b.channels, c.channels
+}
+
+// publish delivers a message to channel and pattern subscribers and returns
+// the number of deliveries.
+func publish(c *call) any {
+	channel, message := c.args[1], c.args[2]
+	type delivery struct {
+		to      *client
+		message []any
+	}
+
+	b := &c.server.pubsub
+	b.mu.Lock()
+	var deliveries []delivery
+	for subscriber := range b.channels[channel] {
+		deliveries = append(deliveries, delivery{subscriber, []any{"message", channel, message}})
+	}
+	for pattern, subscribers := range b.patterns {
+		if !matchGlob(pattern, channel) {
+			continue
+		}
+		for subscriber := range subscribers {
+			deliveries = append(deliveries, delivery{subscriber, []any{"pmessage", pattern, channel, message}})
+		}
+	}
+	b.mu.Unlock()
+
+	for _, d := range deliveries {
+		d.to.send(d.message)
+	}
+	return len(deliveries)
+}
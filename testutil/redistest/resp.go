@@ -0,0 +1,130 @@
+package redistest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Reply values are written according to their Go type: nil is a null bulk
+// string, string a bulk string, int and int64 integers, []string and []any
+// arrays, and statusReply and errorReply simple strings and errors.
+type (
+	statusReply string
+	errorReply  string
+	// pushes holds several top-level replies produced by one command, as
+	// SUBSCRIBE does with one confirmation per channel.
+	pushes []any
+)
+
+const (
+	okReply     = statusReply("OK")
+	queuedReply = statusReply("QUEUED")
+)
+
+const maxBulkLength = 512 << 20
+
+var (
+	errWrongType    = errorReply("WRONGTYPE Operation against a key holding the wrong kind of value")
+	errSyntax       = errorReply("ERR syntax error")
+	errNotInteger   = errorReply("ERR value is not an integer or out of range")
+	errNotFloat     = errorReply("ERR value is not a valid float")
+	errNoSuchKey    = errorReply("ERR no such key")
+	errProtocol     = errors.New("protocol error")
+	errNestedMulti  = errorReply("ERR MULTI calls can not be nested")
+	errExecNoMulti  = errorReply("ERR EXEC without MULTI")
+	errDiscNoMulti  = errorReply("ERR DISCARD without MULTI")
+	errExecAbort    = errorReply("EXECABORT Transaction discarded because of previous errors.")
+	errNoScript     = errorReply("NOSCRIPT No matching script. Please use EVAL.")
+	errScriptDenied = errorReply("ERR This Redis command is not allowed from script")
+)
+
+func (e errorReply) Error() string { return string(e) }
+
+func errorf(format string, args ...any) errorReply {
+	return errorReply(fmt.Sprintf(format, args...))
+}
+
+// readCommand reads one command, either as a RESP array of bulk strings or
+// as an inline command.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n > 1024*1024 {
+		return nil, errProtocol
+	}
+	args := make([]string, 0, max(n, 0))
+	for i := 0; i < n; i++ {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(line, "$") {
+			return nil, errProtocol
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 || size > maxBulkLength {
+			return nil, errProtocol
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		if buf[size] != '\r' || buf[size+1] != '\n' {
+			return nil, errProtocol
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+func writeReply(w *bufio.Writer, reply any) {
+	switch v := reply.(type) {
+	case nil:
+		w.WriteString("$-1\r\n")
+	case statusReply:
+		w.WriteString("+" + string(v) + "\r\n")
+	case errorReply:
+		w.WriteString("-" + string(v) + "\r\n")
+	case int:
+		w.WriteString(":" + strconv.Itoa(v) + "\r\n")
+	case int64:
+		w.WriteString(":" + strconv.FormatInt(v, 10) + "\r\n")
+	case string:
+		w.WriteString("$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n")
+	case []string:
+		w.WriteString("*" + strconv.Itoa(len(v)) + "\r\n")
+		for _, s := range v {
+			writeReply(w, s)
+		}
+	case []any:
+		w.WriteString("*" + strconv.Itoa(len(v)) + "\r\n")
+		for _, item := range v {
+			writeReply(w, item)
+		}
+	case pushes:
+		for _, item := range v {
+			writeReply(w, item)
+		}
+	default:
+		panic(fmt.Sprintf("redistest: cannot encode reply of type %T", reply))
+	}
+}
@@ -0,0 +1,399 @@
+// Package redistest runs an in-process server that speaks the Redis protocol,
+// so tests in any package can point real go-redis clients, pipelines and Lua
+// scripts at it without an external Redis.
+//
+// It implements the subset of commands the application relies on: strings
+// with expiry and INCR, generic key commands, hashes, sets, sorted sets,
+// pub/sub, MULTI/EXEC and scripting through EVAL, EVALSHA and SCRIPT. Scripts
+// run on an embedded Lua interpreter with redis.call and redis.pcall wired to
+// the same command table. There is a single database and no persistence.
+package redistest
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type entry struct {
+	// value is a string, hash, set or *sortedSet.
+	value    any
+	expireAt time.Time
+}
+
+type (
+	hash map[string]string
+	set  map[string]struct{}
+)
+
+// Server is a running instance. It is safe for concurrent use; commands are
+// executed one at a time, as they would be by Redis.
+type Server struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	// mu guards everything below and is held while a command runs.
+	mu      sync.Mutex
+	data    map[string]*entry
+	scripts map[string]string
+	offset  time.Duration
+	clients map[*client]struct{}
+	closed  bool
+	// enforceSlots rejects multi-key commands across cluster slots.
+	enforceSlots bool
+
+	pubsub broker
+}
+
+// Start listens on a random local port and serves until Close is called.
+func Start() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		listener: listener,
+		data:     map[string]*entry{},
+		scripts:  map[string]string{},
+		clients:  map[*client]struct{}{},
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Run starts a server that is closed when t ends.
+func Run(t testing.TB) *Server {
+	t.Helper()
+	s, err := Start()
+	if err != nil {
+		t.Fatalf("redistest: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// Addr returns the host:port the server listens on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// NewClient returns a go-redis client connected to s. The caller closes it.
+func (s *Server) NewClient() *redis.Client {
+	return redis.NewClient(&redis.Options{Addr: s.Addr()})
+}
+
+// Close stops the listener, disconnects every client and waits for their
+// goroutines to finish.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	err := s.listener.Close()
+	for c := range s.clients {
+		c.conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		c := newClient(s, conn)
+		s.clients[c] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go func() {
+			defer s.wg.Done()
+			c.serve()
+			s.pubsub.remove(c)
+			s.mu.Lock()
+			delete(s.clients, c)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// FastForward moves the server clock forward by d, expiring keys whose TTL
+// has passed.
+func (s *Server) FastForward(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offset += d
+}
+
+// FlushAll removes every key.
+func (s *Server) FlushAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = map[string]*entry{}
+}
+
+// Has reports whether key exists.
+func (s *Server) Has(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lookup(key) != nil
+}
+
+// Get returns the string stored at key.
+func (s *Server) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.lookup(key)
+	if e == nil {
+		return nil, false
+	}
+	value, ok := e.value.(string)
+	return []byte(value), ok
+}
+
+// Set stores a raw string without expiry, for example a corrupted cache
+// entry.
+func (s *Server) Set(key string, value []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = &entry{value: string(value)}
+}
+
+// TTL returns the remaining time to live of key, or zero if it has none or
+// does not exist.
+func (s *Server) TTL(key string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.lookup(key)
+	if e == nil || e.expireAt.IsZero() {
+		return 0
+	}
+	return e.expireAt.Sub(s.now())
+}
+
+// Keys returns every live key in sorted order.
+func (s *Server) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys("*")
+}
+
+func (s *Server) now() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+// lookup returns the live entry at key, deleting it first if it has expired.
+func (s *Server) lookup(key string) *entry {
+	e, ok := s.data[key]
+	if !ok {
+		return nil
+	}
+	if !e.expireAt.IsZero() && !s.now().Before(e.expireAt) {
+		delete(s.data, key)
+		return nil
+	}
+	return e
+}
+
+// lookupAs returns the value at key as a T. A missing key yields create()
+// stored under key, or the zero value if create is nil.
+func lookupAs[T any](s *Server, key string, create func() T) (T, error) {
+	var zero T
+	e := s.lookup(key)
+	if e == nil {
+		if create == nil {
+			return zero, nil
+		}
+		value := create()
+		s.data[key] = &entry{value: value}
+		return value, nil
+	}
+	value, ok := e.value.(T)
+	if !ok {
+		return zero, errWrongType
+	}
+	return value, nil
+}
+
+// dropIfEmpty deletes key once its collection has no members left, as Redis
+// never keeps empty hashes, sets or sorted sets.
+func (s *Server) dropIfEmpty(key string, size int) {
+	if size == 0 {
+		delete(s.data, key)
+	}
+}
+
+func (s *Server) keys(pattern string) []string {
+	keys := []string{}
+	for key := range s.data {
+		if s.lookup(key) != nil && matchGlob(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// client is one connection. Replies from its own goroutine and messages
+// published by others are serialized through wmu.
+type client struct {
+	server *Server
+	conn   net.Conn
+	r      *bufio.Reader
+
+	wmu sync.Mutex
+	w   *bufio.Writer
+
+	// Transaction state: queued is non-nil between MULTI and EXEC.
+	queued [][]string
+	dirty  bool
+
+	// Subscriptions, guarded by the broker.
+	channels map[string]struct{}
+	patterns map[string]struct{}
+}
+
+func newClient(s *Server, conn net.Conn) *client {
+	return &client{
+		server:   s,
+		conn:     conn,
+		r:        bufio.NewReader(conn),
+		w:        bufio.NewWriter(conn),
+		channels: map[string]struct{}{},
+		patterns: map[string]struct{}{},
+	}
+}
+
+func (c *client) serve() {
+	defer c.conn.Close()
+	for {
+		args, err := readCommand(c.r)
+		if err != nil {
+			if errors.Is(err, errProtocol) {
+				c.send(errorReply("ERR Protocol error"))
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		c.send(c.handle(args))
+		if strings.EqualFold(args[0], "quit") {
+			return
+		}
+	}
+}
+
+func (c *client) send(reply any) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	writeReply(c.w, reply)
+	c.w.Flush()
+}
+
+func (c *client) handle(args []string) any {
+	name := strings.ToLower(args[0])
+	if c.server.pubsub.subscribed(c) && !allowedWhileSubscribed[name] {
+		return errorf("ERR Can't execute '%s': only (P)SUBSCRIBE / (P)UNSUBSCRIBE / PING / QUIT are allowed in this context", name)
+	}
+
+	switch name {
+	case "multi":
+		if c.queued != nil {
+			return errNestedMulti
+		}
+		c.queued, c.dirty = [][]string{}, false
+		return okReply
+	case "discard":
+		if c.queued == nil {
+			return errDiscNoMulti
+		}
+		c.queued = nil
+		return okReply
+	case "exec":
+		return c.exec()
+	}
+
+	if c.queued != nil {
+		if _, errReply := lookupCommand(args); errReply != nil {
+			c.dirty = true
+			return errReply
+		}
+		c.queued = append(c.queued, args)
+		return queuedReply
+	}
+	return c.server.run(c, args)
+}
+
+func (c *client) exec() any {
+	if c.queued == nil {
+		return errExecNoMulti
+	}
+	queued, dirty := c.queued, c.dirty
+	c.queued = nil
+	if dirty {
+		return errExecAbort
+	}
+
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+	replies := make([]any, len(queued))
+	for i, args := range queued {
+		replies[i] = c.server.dispatch(c, args, false)
+	}
+	return replies
+}
+
+// run executes one command, holding the server lock unless the command
+// manages its own locking.
+func (s *Server) run(c *client, args []string) any {
+	cmd, errReply := lookupCommand(args)
+	if errReply != nil {
+		return errReply
+	}
+	if cmd.flags&flagNoLock != 0 {
+		return cmd.fn(&call{server: s, client: c, args: args})
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if errReply := s.checkSlots(args); errReply != nil {
+		return errReply
+	}
+	return cmd.fn(&call{server: s, client: c, args: args})
+}
+
+// dispatch executes a command with the server lock already held, from EXEC
+// or a script.
+func (s *Server) dispatch(c *client, args []string, fromScript bool) any {
+	cmd, errReply := lookupCommand(args)
+	if errReply != nil {
+		return errReply
+	}
+	if fromScript && cmd.flags&flagNoScript != 0 {
+		return errScriptDenied
+	}
+	if errReply := s.checkSlots(args); errReply != nil {
+		return errReply
+	}
+	return cmd.fn(&call{server: s, client: c, args: args})
+}
@@ -0,0 +1,216 @@
+package redistest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T) (*Server, *redis.Client) {
+	s := Run(t)
+	client := s.NewClient()
+	t.Cleanup(func() { client.Close() })
+	return s, client
+}
+
+func TestStringsAndExpiry(t *testing.T) {
+	ctx := context.Background()
+	s, client := newTestClient(t)
+
+	require.NoError(t, client.Ping(ctx).Err())
+	require.NoError(t, client.Set(ctx, "item:1", "one", time.Minute).Err())
+	assert.Equal(t, "one", client.Get(ctx, "item:1").Val())
+	assert.Equal(t, 60*time.Second, client.TTL(ctx, "item:1").Val())
+
+	ok, err := client.SetNX(ctx, "item:1", "other", 0).Result()
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	s.FastForward(2 * time.Minute)
+	assert.ErrorIs(t, client.Get(ctx, "item:1").Err(), redis.Nil, "values expire")
+	assert.False(t, s.Has("item:1"))
+
+	assert.Equal(t, int64(1), client.Incr(ctx, "counter").Val())
+	assert.Equal(t, int64(11), client.IncrBy(ctx, "counter", 10).Val())
+	require.NoError(t, client.Set(ctx, "text", "abc", 0).Err())
+	assert.ErrorContains(t, client.Incr(ctx, "text").Err(), "not an integer")
+
+	assert.Equal(t, int64(2), client.Del(ctx, "counter", "text", "missing").Val())
+	assert.Empty(t, s.Keys())
+}
+
+func TestKeysHashesAndWrongType(t *testing.T) {
+	ctx := context.Background()
+	s, client := newTestClient(t)
+
+	require.NoError(t, client.HSet(ctx, "pending", "a", "1", "b", "2").Err())
+	require.NoError(t, client.Rename(ctx, "pending", "inflight").Err())
+	assert.Equal(t, int64(0), client.Exists(ctx, "pending").Val())
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, client.HGetAll(ctx, "inflight").Val())
+	assert.ErrorContains(t, client.Rename(ctx, "pending", "inflight").Err(), "no such key")
+	assert.ErrorContains(t, client.Get(ctx, "inflight").Err(), "WRONGTYPE")
+
+	require.NoError(t, client.Set(ctx, "item:1", "x", 0).Err())
+	assert.Equal(t, []string{"inflight", "item:1"}, s.Keys())
+	assert.Equal(t, []string{"item:1"}, client.Keys(ctx, "item:*").Val())
+	assert.Equal(t, "hash", client.Type(ctx, "inflight").Val())
+
+	assert.Equal(t, int64(2), client.HDel(ctx, "inflight", "a", "b").Val())
+	assert.False(t, s.Has("inflight"), "empty hashes are removed")
+}
+
+func TestSetsAndSortedSets(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	assert.Equal(t, int64(2), client.SAdd(ctx, "tags", "a", "b", "a").Val())
+	assert.True(t, client.SIsMember(ctx, "tags", "a").Val())
+	assert.Equal(t, []string{"a", "b"}, client.SMembers(ctx, "tags").Val())
+	assert.Equal(t, int64(1), client.SRem(ctx, "tags", "a").Val())
+	assert.Equal(t, int64(1), client.SCard(ctx, "tags").Val())
+
+	require.NoError(t, client.ZAdd(ctx, "prices",
+		redis.Z{Score: 3, Member: "c"}, redis.Z{Score: 1, Member: "a"}, redis.Z{Score: 2, Member: "b"}).Err())
+	assert.Equal(t, []string{"a", "b", "c"}, client.ZRange(ctx, "prices", 0, -1).Val())
+	assert.Equal(t, []string{"c", "b"}, client.ZRevRange(ctx, "prices", 0, 1).Val())
+	assert.Equal(t, []redis.Z{{Score: 2, Member: "b"}, {Score: 3, Member: "c"}},
+		client.ZRangeByScoreWithScores(ctx, "prices", &redis.ZRangeBy{Min: "(1", Max: "+inf"}).Val())
+	assert.Equal(t, 2.0, client.ZScore(ctx, "prices", "b").Val())
+
+	require.NoError(t, client.ZAdd(ctx, "names",
+		redis.Z{Member: "apple"}, redis.Z{Member: "apricot"}, redis.Z{Member: "banana"}).Err())
+	assert.Equal(t, []string{"apple", "apricot"},
+		client.ZRangeByLex(ctx, "names", &redis.ZRangeBy{Min: "[ap", Max: "(aq"}).Val())
+	assert.Equal(t, []string{"apricot"},
+		client.ZRangeByLex(ctx, "names", &redis.ZRangeBy{Min: "[ap", Max: "+", Offset: 1, Count: 1}).Val())
+	assert.Equal(t, []string{"apricot", "apple"},
+		client.ZRangeArgs(ctx, redis.ZRangeArgs{Key: "names", Start: "-", Stop: "[b", ByLex: true, Rev: true, Count: 2}).Val())
+	assert.Equal(t, int64(1), client.ZRem(ctx, "names", "apple").Val())
+	assert.Equal(t, int64(2), client.ZCard(ctx, "names").Val())
+}
+
+func TestPipelinesAndTransactions(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	cmds, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, "a", "1", 0)
+		pipe.Incr(ctx, "a")
+		pipe.Get(ctx, "a")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "2", cmds[2].(*redis.StringCmd).Val())
+
+	cmds, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Incr(ctx, "a")
+		pipe.Del(ctx, "a")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), cmds[0].(*redis.IntCmd).Val())
+	assert.Equal(t, int64(0), client.Exists(ctx, "a").Val())
+}
+
+func TestScripts(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	incrTo := redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[1])) or 0
+if current >= tonumber(ARGV[1]) then
+  return {current, "capped"}
+end
+redis.call("SET", KEYS[1], current + 1, "PX", 60000)
+return {current + 1, redis.status_reply("ok")}
+`)
+	values, err := incrTo.Run(ctx, client, []string{"n"}, 2).Slice()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(1), "ok"}, values)
+	incrTo.Run(ctx, client, []string{"n"}, 2)
+	values, err = incrTo.Run(ctx, client, []string{"n"}, 2).Slice()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(2), "capped"}, values)
+
+	exists, err := client.ScriptExists(ctx, incrTo.Hash()).Result()
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true}, exists)
+
+	err = client.Eval(ctx, `return redis.call("HGETALL", KEYS[1], "extra")`, []string{"n"}).Err()
+	assert.ErrorContains(t, err, "wrong number of arguments")
+	result, err := client.Eval(ctx, `return redis.pcall("INCR", KEYS[1])`, []string{"missing-hash"}).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result)
+	assert.ErrorContains(t, client.Eval(ctx, `return redis.error_reply("boom")`, nil).Err(), "boom")
+	assert.Error(t, client.Eval(ctx, `return (`, nil).Err(), "syntax errors are reported")
+}
+
+func TestPubSub(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	sub := client.Subscribe(ctx, "items")
+	defer sub.Close()
+	_, err := sub.Receive(ctx)
+	require.NoError(t, err)
+	require.NoError(t, sub.PSubscribe(ctx, "audit.*"))
+	_, err = sub.Receive(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), client.Publish(ctx, "items", "created").Val())
+	assert.Equal(t, int64(1), client.Publish(ctx, "audit.item", "appended").Val())
+	assert.Equal(t, int64(0), client.Publish(ctx, "other", "ignored").Val())
+
+	msg, err := sub.ReceiveMessage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "items", msg.Channel)
+	assert.Equal(t, "created", msg.Payload)
+	msg, err = sub.ReceiveMessage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "audit.*", msg.Pattern)
+	assert.Equal(t, "appended", msg.Payload)
+}
+
+func TestMatchGlob(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "anything", true},
+		{"item:*", "item:1", true},
+		{"item:?", "item:12", false},
+		{"h[ae]llo", "hello", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{`a\*b`, "a*b", true},
+		{`a\*b`, "axb", false},
+	} {
+		assert.Equal(t, tc.want, matchGlob(tc.pattern, tc.s), "%s ~ %s", tc.pattern, tc.s)
+	}
+}
+
+func TestClusterSlots(t *testing.T) {
+	ctx := context.Background()
+	s, client := newTestClient(t)
+
+	assert.Equal(t, 12739, KeySlot("123456789"))
+	assert.Equal(t, KeySlot("user"), KeySlot("{user}:followers"))
+	assert.Equal(t, KeySlot("{}a"), KeySlot("{}a"))
+	assert.NotEqual(t, KeySlot("{}a"), KeySlot("{}b"), "an empty tag hashes the whole key")
+
+	require.NoError(t, client.Set(ctx, "a", "1", 0).Err())
+	require.NoError(t, client.Set(ctx, "b", "2", 0).Err())
+	assert.Equal(t, int64(2), client.Exists(ctx, "a", "b").Val(), "slots are not checked by default")
+
+	s.EnforceSlots(true)
+	assert.ErrorContains(t, client.Del(ctx, "a", "b").Err(), "CROSSSLOT")
+	assert.ErrorContains(t, client.Rename(ctx, "a", "b").Err(), "CROSSSLOT")
+	assert.ErrorContains(t, client.Eval(ctx, "return 1", []string{"a", "b"}).Err(), "CROSSSLOT")
+	require.NoError(t, client.Set(ctx, "{t}:1", "1", 0).Err())
+	assert.NoError(t, client.Rename(ctx, "{t}:1", "{t}:2").Err())
+	assert.Equal(t, int64(1), client.Del(ctx, "a").Val())
+}
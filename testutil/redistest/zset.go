@@ -0,0 +1,345 @@
+package redistest
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// sortedSet maps members to scores. Members are sorted on demand, which is
+// plenty for test-sized data.
+type sortedSet map[string]float64
+
+type scored struct {
+	member string
+	score  float64
+}
+
+func (z sortedSet) sorted() []scored {
+	members := make([]scored, 0, len(z))
+	for member, score := range z {
+		members = append(members, scored{member, score})
+	}
+	slices.SortFunc(members, func(a, b scored) int {
+		if c := cmp.Compare(a.score, b.score); c != 0 {
+			return c
+		}
+		return strings.Compare(a.member, b.member)
+	})
+	return members
+}
+
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
+
+func parseScore(s string) (float64, bool) {
+	score, err := strconv.ParseFloat(s, 64)
+	return score, err == nil && !math.IsNaN(score)
+}
+
+// zadd implements ZADD key [NX|XX] [CH] score member [score member ...].
+func zadd(c *call) any {
+	var nx, xx, ch bool
+	i := 2
+options:
+	for ; i < len(c.args); i++ {
+		switch strings.ToLower(c.args[i]) {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "ch":
+			ch = true
+		default:
+			break options
+		}
+	}
+	pairs := c.args[i:]
+	if len(pairs) == 0 || len(pairs)%2 != 0 || (nx && xx) {
+		return errSyntax
+	}
+	scores := make([]float64, 0, len(pairs)/2)
+	for j := 0; j < len(pairs); j += 2 {
+		score, ok := parseScore(pairs[j])
+		if !ok {
+			return errNotFloat
+		}
+		scores = append(scores, score)
+	}
+
+	z, err := lookupAs(c.server, c.args[1], func() sortedSet { return sortedSet{} })
+	if err != nil {
+		return err
+	}
+	added, changed := 0, 0
+	for j, score := range scores {
+		member := pairs[2*j+1]
+		old, exists := z[member]
+		if (nx && exists) || (xx && !exists) {
+			continue
+		}
+		z[member] = score
+		if !exists {
+			added++
+		} else if old != score {
+			changed++
+		}
+	}
+	c.server.dropIfEmpty(c.args[1], len(z))
+	if ch {
+		return added + changed
+	}
+	return added
+}
+
+func zincrby(c *call) any {
+	delta, ok := parseScore(c.args[2])
+	if !ok {
+		return errNotFloat
+	}
+	z, err := lookupAs(c.server, c.args[1], func() sortedSet { return sortedSet{} })
+	if err != nil {
+		return err
+	}
+	z[c.args[3]] += delta
+	return formatScore(z[c.args[3]])
+}
+
+func zrem(c *call) any {
+	z, err := lookupAs[sortedSet](c.server, c.args[1], nil)
+	if err != nil {
+		return err
+	}
+	removed := 0
+	for _, member := range c.args[2:] {
+		if _, ok := z[member]; ok {
+			delete(z, member)
+			removed++
+		}
+	}
+	if z != nil {
+		c.server.dropIfEmpty(c.args[1], len(z))
+	}
+	return removed
+}
+
+func zscore(c *call) any {
+	z, err := lookupAs[sortedSet](c.server, c.args[1], nil)
+	if err != nil {
+		return err
+	}
+	if score, ok := z[c.args[2]]; ok {
+		return formatScore(score)
+	}
+	return nil
+}
+
+func zcard(c *call) any {
+	z, err := lookupAs[sortedSet](c.server, c.args[1], nil)
+	if err != nil {
+		return err
+	}
+	return len(z)
+}
+
+// zrangeAlias rewrites the legacy range commands as the equivalent ZRANGE.
+func zrangeAlias(c *call, by string, rev bool) any {
+	args := slices.Clone(c.args[:4])
+	if by != "" {
+		args = append(args, by)
+	}
+	if rev {
+		args = append(args, "rev")
+	}
+	return zrange(&call{server: c.server, client: c.client, args: append(args, c.args[4:]...)})
+}
+
+// zrange implements ZRANGE key start stop [BYSCORE|BYLEX] [REV]
+// [LIMIT offset count] [WITHSCORES].
+func zrange(c *call) any {
+	var by string
+	var rev, withScores, limited bool
+	var offset, count int
+	for i := 4; i < len(c.args); i++ {
+		switch option := strings.ToLower(c.args[i]); option {
+		case "byscore", "bylex":
+			by = option
+		case "rev":
+			rev = true
+		case "withscores":
+			withScores = true
+		case "limit":
+			if i+2 >= len(c.args) {
+				return errSyntax
+			}
+			var err1, err2 error
+			offset, err1 = strconv.Atoi(c.args[i+1])
+			count, err2 = strconv.Atoi(c.args[i+2])
+			if err1 != nil || err2 != nil {
+				return errNotInteger
+			}
+			limited = true
+			i += 2
+		default:
+			return errSyntax
+		}
+	}
+	if limited && by == "" {
+		return errorReply("ERR syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX")
+	}
+	if withScores && by == "bylex" {
+		return errorReply("ERR syntax error, WITHSCORES not supported in combination with BYLEX")
+	}
+
+	z, err := lookupAs[sortedSet](c.server, c.args[1], nil)
+	if err != nil {
+		return err
+	}
+	members := z.sorted()
+	if rev {
+		slices.Reverse(members)
+	}
+
+	start, stop := c.args[2], c.args[3]
+	if rev && by != "" {
+		start, stop = stop, start
+	}
+	var selected []scored
+	switch by {
+	case "":
+		from, err1 := strconv.Atoi(start)
+		to, err2 := strconv.Atoi(stop)
+		if err1 != nil || err2 != nil {
+			return errNotInteger
+		}
+		selected = indexRange(members, from, to)
+	case "byscore":
+		lo, ok1 := parseScoreBound(start)
+		hi, ok2 := parseScoreBound(stop)
+		if !ok1 || !ok2 {
+			return errorReply("ERR min or max is not a float")
+		}
+		for _, m := range members {
+			if lo.below(m.score) && hi.above(m.score) {
+				selected = append(selected, m)
+			}
+		}
+	case "bylex":
+		lo, ok1 := parseLexBound(start)
+		hi, ok2 := parseLexBound(stop)
+		if !ok1 || !ok2 {
+			return errorReply("ERR min or max not valid string range item")
+		}
+		for _, m := range members {
+			if lo.below(m.member) && hi.above(m.member) {
+				selected = append(selected, m)
+			}
+		}
+	}
+
+	if limited {
+		if offset < 0 || offset >= len(selected) {
+			selected = nil
+		} else {
+			selected = selected[offset:]
+			if count >= 0 && count < len(selected) {
+				selected = selected[:count]
+			}
+		}
+	}
+
+	reply := make([]string, 0, len(selected))
+	for _, m := range selected {
+		reply = append(reply, m.member)
+		if withScores {
+			reply = append(reply, formatScore(m.score))
+		}
+	}
+	return reply
+}
+
+func indexRange(members []scored, start, stop int) []scored {
+	n := len(members)
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if stop < 0 {
+		stop = n + stop
+	}
+	stop = min(stop, n-1)
+	if start > stop || start >= n {
+		return nil
+	}
+	return members[start : stop+1]
+}
+
+type scoreBound struct {
+	value     float64
+	exclusive bool
+}
+
+func parseScoreBound(s string) (scoreBound, bool) {
+	exclusive := strings.HasPrefix(s, "(")
+	if exclusive {
+		s = s[1:]
+	}
+	value, ok := parseScore(s)
+	return scoreBound{value, exclusive}, ok
+}
+
+// below reports whether the bound, used as a minimum, admits score.
+func (b scoreBound) below(score float64) bool {
+	return score > b.value || (!b.exclusive && score == b.value)
+}
+
+// above reports whether the bound, used as a maximum, admits score.
+func (b scoreBound) above(score float64) bool {
+	return score < b.value || (!b.exclusive && score == b.value)
+}
+
+// lexBound is "-", "+", "[value" or "(value".
+type lexBound struct {
+	value     string
+	exclusive bool
+	// infinity is -1 for "-" and 1 for "+".
+	infinity int
+}
+
+func parseLexBound(s string) (lexBound, bool) {
+	switch {
+	case s == "-":
+		return lexBound{infinity: -1}, true
+	case s == "+":
+		return lexBound{infinity: 1}, true
+	case strings.HasPrefix(s, "["):
+		return lexBound{value: s[1:]}, true
+	case strings.HasPrefix(s, "("):
+		return lexBound{value: s[1:], exclusive: true}, true
+	}
+	return lexBound{}, false
+}
+
+func (b lexBound) below(member string) bool {
+	if b.infinity != 0 {
+		return b.infinity < 0
+	}
+	c := strings.Compare(member, b.value)
+	return c > 0 || (!b.exclusive && c == 0)
+}
+
+func (b lexBound) above(member string) bool {
+	if b.infinity != 0 {
+		return b.infinity > 0
+	}
+	c := strings.Compare(member, b.value)
+	return c < 0 || (!b.exclusive && c == 0)
+}
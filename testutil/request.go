@@ -0,0 +1,149 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Request is built fluently and sent with Expect:
+//
+//	s.POST("/items/", body).As(alice).Expect(t).Status(http.StatusCreated).JSON(&item)
+type Request struct {
+	s      *Server
+	method string
+	path   string
+	body   interface{}
+	user   *User
+	header http.Header
+}
+
+func (s *Server) Request(method, path string) *Request {
+	return &Request{s: s, method: method, path: path, header: http.Header{}}
+}
+
+func (s *Server) GET(path string) *Request {
+	return s.Request(http.MethodGet, path)
+}
+
+func (s *Server) POST(path string, body interface{}) *Request {
+	return s.Request(http.MethodPost, path).JSON(body)
+}
+
+func (s *Server) PUT(path string, body interface{}) *Request {
+	return s.Request(http.MethodPut, path).JSON(body)
+}
+
+func (s *Server) DELETE(path string) *Request {
+	return s.Request(http.MethodDelete, path)
+}
+
+// As authenticates the request as u.
+func (r *Request) As(u User) *Request {
+	r.user = &u
+	return r
+}
+
+func (r *Request) Header(key, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// JSON sets the body. Strings and byte slices are sent verbatim, so
+// malformed documents can be posted; anything else is marshalled.
+func (r *Request) JSON(body interface{}) *Request {
+	r.body = body
+	r.header.Set("Content-Type", "application/json")
+	return r
+}
+
+// Expect sends the request and returns the response for assertions
+// reported against t. Building the request is deferred to here so that
+// failures are reported against t too, which matters inside fuzz targets.
+func (r *Request) Expect(t testing.TB) *Response {
+	t.Helper()
+	var body io.Reader
+	switch v := r.body.(type) {
+	case nil:
+	case string:
+		body = strings.NewReader(v)
+	case []byte:
+		body = bytes.NewReader(v)
+	default:
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(r.method, r.s.URL+r.path, body)
+	require.NoError(t, err)
+	req.Header = r.header.Clone()
+	if r.user != nil {
+		req.Header.Set("Authorization", "Bearer "+r.s.token(t, *r.user))
+	}
+
+	resp, err := r.s.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return &Response{t: t, Response: resp, Body: data}
+}
+
+// Response holds a completed response. Assertion methods return the
+// response so they can be chained.
+type Response struct {
+	*http.Response
+	Body []byte
+	t    testing.TB
+}
+
+func (r *Response) Status(code int) *Response {
+	r.t.Helper()
+	assert.Equal(r.t, code, r.StatusCode, "unexpected status; body: %s", r.Body)
+	return r
+}
+
+func (r *Response) HasHeader(key, value string) *Response {
+	r.t.Helper()
+	assert.Equal(r.t, value, r.Header.Get(key), "header %s", key)
+	return r
+}
+
+// JSON decodes the body into v.
+func (r *Response) JSON(v interface{}) *Response {
+	r.t.Helper()
+	require.NoError(r.t, json.Unmarshal(r.Body, v), "body: %s", r.Body)
+	return r
+}
+
+// JSONEq asserts that the body is equivalent to the expected document.
+func (r *Response) JSONEq(expected string) *Response {
+	r.t.Helper()
+	assert.JSONEq(r.t, expected, string(r.Body))
+	return r
+}
+
+// Field asserts the value of a top-level field in a JSON object body.
+func (r *Response) Field(name string, expected interface{}) *Response {
+	r.t.Helper()
+	var object map[string]interface{}
+	r.JSON(&object)
+	want, err := json.Marshal(expected)
+	require.NoError(r.t, err)
+	got, err := json.Marshal(object[name])
+	require.NoError(r.t, err)
+	assert.JSONEq(r.t, string(want), string(got), "field %s", name)
+	return r
+}
+
+func (r *Response) Contains(substring string) *Response {
+	r.t.Helper()
+	assert.Contains(r.t, string(r.Body), substring)
+	return r
+}
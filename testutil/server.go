@@ -0,0 +1,143 @@
+// Package testutil boots the full HTTP API against in-memory SQLite and an
+// in-process Redis server, with fixtures and a fluent request API for end to
+// end tests.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rahulmishra/go-crud-app/cache"
+	"github.com/rahulmishra/go-crud-app/config"
+	"github.com/rahulmishra/go-crud-app/faults"
+	"github.com/rahulmishra/go-crud-app/middleware"
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/rahulmishra/go-crud-app/policy"
+	"github.com/rahulmishra/go-crud-app/repository"
+	"github.com/rahulmishra/go-crud-app/routes"
+	"github.com/rahulmishra/go-crud-app/services"
+	"github.com/rahulmishra/go-crud-app/testutil/redistest"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// JWTSecret signs the tokens issued by Server.Token.
+const JWTSecret = "testutil-secret"
+
+// Server is a running API. The application uses package-level state
+// (config.DB, config.RedisClient and the middleware configuration), so
+// servers must not be used from parallel tests.
+type Server struct {
+	*httptest.Server
+	DB    *gorm.DB
+	Redis *redistest.Server
+	t     testing.TB
+}
+
+type options struct {
+	cacheWriteMode string
+	policyFile     string
+	faults         bool
+}
+
+type Option func(*options)
+
+// WithCacheWriteMode selects the cache write mode; the default is
+// invalidate.
+func WithCacheWriteMode(mode string) Option {
+	return func(o *options) { o.cacheWriteMode = mode }
+}
+
+// WithPolicyFile loads an authorization policy instead of the default.
+func WithPolicyFile(path string) Option {
+	return func(o *options) { o.policyFile = path }
+}
+
+// WithFaults installs the fault injector on the database and Redis client
+// and serves /admin/faults. Rules are cleared when the test ends.
+func WithFaults() Option {
+	return func(o *options) { o.faults = true }
+}
+
+// NewServer starts the API with a fresh database and cache. Everything is
+// torn down and the previous globals are restored when the test ends.
+func NewServer(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+	o := options{cacheWriteMode: config.CacheWriteInvalidate}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	previousDB, previousRedis := config.DB, config.RedisClient
+	t.Cleanup(func() {
+		config.SetDB(previousDB)
+		config.SetRedisClient(previousRedis)
+		services.SetCacheWriteMode(config.CacheWriteInvalidate)
+		policy.Configure("")
+	})
+
+	db := newDatabase(t)
+	redisServer := redistest.Run(t)
+	client := redisServer.NewClient()
+	t.Cleanup(func() { client.Close() })
+	config.SetDB(db)
+	config.SetRedisClient(client)
+	if o.faults {
+		must(t, faults.InstrumentGORM(db))
+		faults.InstrumentRedis(client)
+		t.Cleanup(faults.Reset)
+	}
+
+	must(t, cache.Configure(cache.Options{SchemaVersion: models.CacheSchemaVersion}))
+	must(t, services.SetCacheWriteMode(o.cacheWriteMode))
+	must(t, middleware.ConfigureAuth(config.AuthConfig{JWTSecret: JWTSecret, RolesClaim: "roles", GroupsClaim: "groups"}))
+	must(t, policy.Configure(o.policyFile))
+	must(t, middleware.ConfigureRateLimit(config.RateLimitConfig{Enabled: false}, nil))
+
+	gin.SetMode(gin.TestMode)
+	router, err := routes.NewEngine(nil)
+	must(t, err)
+	router.Use(gin.Recovery(), middleware.RequestLogger())
+	routes.SetupItemRoutes(router)
+	routes.SetupAuditRoutes(router)
+	routes.SetupAdminRoutes(router)
+	routes.SetupMetricsRoutes(router)
+	if o.faults {
+		routes.SetupFaultRoutes(router)
+	}
+
+	s := &Server{Server: httptest.NewServer(router), DB: db, Redis: redisServer, t: t}
+	t.Cleanup(s.Close)
+	return s
+}
+
+// newDatabase opens a private in-memory SQLite database. It is limited to
+// one connection because every new connection to ":memory:" would get an
+// empty database of its own.
+func newDatabase(t testing.TB) *gorm.DB {
+	dsn := fmt.Sprintf("file:testutil-%s?mode=memory", uuid.NewString())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	must(t, err)
+	sqlDB, err := db.DB()
+	must(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	must(t, db.AutoMigrate(&models.Item{}, &models.ItemShare{}, &models.APIKey{}, &models.AuditRecord{}))
+	config.SetDB(db)
+	must(t, repository.ProtectAuditLog(context.Background()))
+	must(t, repository.PrepareItemSearch(context.Background()))
+	return db
+}
+
+func must(t testing.TB, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("testutil: %v", err)
+	}
+}
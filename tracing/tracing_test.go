@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSetup_RejectsUnknownExporter(t *testing.T) {
+	_, err := Setup(context.Background(), Options{Exporter: "zipkin"})
+	assert.Error(t, err)
+
+	shutdown, err := Setup(context.Background(), Options{Exporter: ExporterNone})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestStartEnd_RecordsErrors(t *testing.T) {
+	exporter := SetupInMemory()
+
+	_, span := Start(context.Background(), "services.Test")
+	End(span, errors.New("boom"))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "services.Test", spans[0].Name)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Len(t, spans[0].Events, 1, "the error is recorded as an event")
+}
+
+func TestInstrumentGORM_CreatesChildSpans(t *testing.T) {
+	exporter := SetupInMemory()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, InstrumentGORM(db))
+
+	type widget struct {
+		ID   uint
+		Name string
+	}
+	require.NoError(t, db.AutoMigrate(&widget{}))
+	exporter.Reset()
+
+	ctx, parent := Start(context.Background(), "services.GetWidget")
+	var found widget
+	assert.ErrorIs(t, db.WithContext(ctx).First(&found, 1).Error, gorm.ErrRecordNotFound)
+	End(parent, nil)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	query := spans[0]
+	assert.Equal(t, "gorm.query", query.Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), query.Parent.SpanID())
+	assert.Equal(t, codes.Unset, query.Status.Code, "a missing record is not an error")
+
+	attrs := map[string]string{}
+	for _, attr := range query.Attributes {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	assert.Equal(t, "sqlite", attrs["db.system"])
+	assert.Contains(t, attrs["db.statement"], "SELECT * FROM `widgets`")
+}
+
+func TestInstrumentRedis_CreatesClientSpans(t *testing.T) {
+	exporter := SetupInMemory()
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	defer client.Close()
+	InstrumentRedis(client)
+
+	ctx, parent := Start(context.Background(), "services.UpdateItem")
+	client.Del(ctx, "all_items")
+	End(parent, nil)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "redis.del", spans[0].Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent.SpanID())
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+}
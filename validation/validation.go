@@ -0,0 +1,181 @@
+// Package validation holds the rules shared by every path that accepts item
+// data: the gin binder for HTTP requests and the service layer for
+// everything else.
+package validation
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/rahulmishra/go-crud-app/i18n"
+	"golang.org/x/text/language"
+)
+
+// FieldError describes why one field was rejected.
+type FieldError struct {
+	Field  string `json:"field"`
+	Rule   string `json:"rule"`
+	Param  string `json:"param,omitempty"`
+	Reason string `json:"reason"`
+
+	message string
+	args    []interface{}
+}
+
+// Errors is returned when a value fails validation.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Reason
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Localize returns a copy of e with every Reason translated into lang.
+func (e Errors) Localize(lang language.Tag) Errors {
+	out := make(Errors, len(e))
+	for i, fe := range e {
+		if fe.message != "" {
+			fe.Reason = i18n.Translate(lang, fe.message, fe.args...)
+		}
+		out[i] = fe
+	}
+	return out
+}
+
+var (
+	validate *validator.Validate
+	once     sync.Once
+)
+
+// Setup registers the custom rules with gin's binder so ShouldBindJSON
+// enforces them. It is safe to call more than once.
+func Setup() {
+	once.Do(func() {
+		validate = validator.New()
+		validate.SetTagName("binding")
+		register(validate)
+		if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			register(engine)
+		}
+	})
+}
+
+func register(v *validator.Validate) {
+	v.RegisterTagNameFunc(jsonFieldName)
+	v.RegisterValidation("itemname", validateItemName)
+	v.RegisterValidation("precision", validatePrecision)
+}
+
+// Struct validates s against its `binding` tags and returns Errors on
+// failure.
+func Struct(s interface{}) error {
+	Setup()
+	return Translate(validate.Struct(s))
+}
+
+// Translate converts validator errors into Errors. Other errors, including
+// nil, are returned unchanged.
+func Translate(err error) error {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+	out := make(Errors, len(verrs))
+	for i, fe := range verrs {
+		message, args := reason(fe.Tag(), fe.Param(), fe.Kind())
+		out[i] = FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Reason:  i18n.Translate(i18n.Default, message, args...),
+			message: message,
+			args:    args,
+		}
+	}
+	return out
+}
+
+// reason returns the i18n message key and arguments describing a failed
+// rule.
+func reason(tag, param string, kind reflect.Kind) (string, []interface{}) {
+	switch tag {
+	case "required":
+		return i18n.MsgValidationRequired, nil
+	case "gt":
+		return i18n.MsgValidationGT, []interface{}{param}
+	case "gte":
+		return i18n.MsgValidationGTE, []interface{}{param}
+	case "lt":
+		return i18n.MsgValidationLT, []interface{}{param}
+	case "lte":
+		return i18n.MsgValidationLTE, []interface{}{param}
+	case "min":
+		if kind == reflect.String {
+			return i18n.MsgValidationMinLength, []interface{}{param}
+		}
+		return i18n.MsgValidationGTE, []interface{}{param}
+	case "max":
+		if kind == reflect.String {
+			return i18n.MsgValidationMaxLength, []interface{}{param}
+		}
+		return i18n.MsgValidationLTE, []interface{}{param}
+	case "itemname":
+		return i18n.MsgValidationItemName, nil
+	case "precision":
+		return i18n.MsgValidationPrecision, []interface{}{param}
+	default:
+		return i18n.MsgValidationOther, []interface{}{tag}
+	}
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// validateItemName rejects blank names and names containing control
+// characters.
+func validateItemName(fl validator.FieldLevel) bool {
+	name := fl.Field().String()
+	if strings.TrimSpace(name) == "" {
+		return false
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// validatePrecision checks that a float is finite and has at most param
+// decimal places in its shortest decimal representation.
+func validatePrecision(fl validator.FieldLevel) bool {
+	value := fl.Field().Float()
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return false
+	}
+	places, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		return false
+	}
+	formatted := strconv.FormatFloat(value, 'f', -1, 64)
+	_, decimals, _ := strings.Cut(formatted, ".")
+	return len(decimals) <= places
+}
@@ -0,0 +1,71 @@
+package validation
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/rahulmishra/go-crud-app/models"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func TestStruct_Item(t *testing.T) {
+	cases := []struct {
+		name  string
+		item  models.Item
+		field string
+		rule  string
+	}{
+		{"valid", models.Item{Name: "Laptop", Price: 1200.50}, "", ""},
+		{"unicode name", models.Item{Name: "Café ☕", Price: 3}, "", ""},
+		{"blank name", models.Item{Name: "   ", Price: 1}, "name", "itemname"},
+		{"missing name", models.Item{Price: 1}, "name", "required"},
+		{"control character", models.Item{Name: "bad\x00name", Price: 1}, "name", "itemname"},
+		{"long name", models.Item{Name: strings.Repeat("é", 201), Price: 1}, "name", "max"},
+		{"negative price", models.Item{Name: "x", Price: -5}, "price", "gt"},
+		{"missing price", models.Item{Name: "x"}, "price", "required"},
+		{"huge price", models.Item{Name: "x", Price: 2e9}, "price", "lte"},
+		{"too precise", models.Item{Name: "x", Price: 1.005}, "price", "precision"},
+		{"NaN", models.Item{Name: "x", Price: math.NaN()}, "price", "gt"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Struct(&tc.item)
+			if tc.field == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var fields Errors
+			assert.True(t, errors.As(err, &fields), "expected validation.Errors, got %v", err)
+			assert.Len(t, fields, 1)
+			assert.Equal(t, tc.field, fields[0].Field)
+			assert.Equal(t, tc.rule, fields[0].Rule)
+			assert.NotEmpty(t, fields[0].Reason)
+		})
+	}
+}
+
+func TestStruct_ReportsEveryField(t *testing.T) {
+	var fields Errors
+	assert.True(t, errors.As(Struct(&models.Item{Name: "", Price: -1}), &fields))
+	assert.Len(t, fields, 2)
+	assert.Equal(t, "name", fields[0].Field)
+	assert.Equal(t, "price", fields[1].Field)
+	assert.Equal(t, "must be greater than 0", fields[1].Reason)
+}
+
+func TestErrors_Localize(t *testing.T) {
+	var fields Errors
+	assert.True(t, errors.As(Struct(&models.Item{Name: "", Price: -1}), &fields))
+
+	german := fields.Localize(language.German)
+	assert.Equal(t, "ist erforderlich", german[0].Reason)
+	assert.Equal(t, "muss größer als 0 sein", german[1].Reason)
+	assert.Equal(t, "must be greater than 0", fields[1].Reason, "Localize must not modify the receiver")
+
+	french := Errors{{Field: "x", Rule: "custom", Reason: "kept"}}.Localize(language.French)
+	assert.Equal(t, "kept", french[0].Reason, "errors built without a message key keep their reason")
+}